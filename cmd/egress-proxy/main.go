@@ -0,0 +1,65 @@
+// Command egress-proxy is the sidecar DockerRuntime.Create launches
+// alongside sandboxes that set Config.AllowedHosts: an HTTP(S) forward
+// proxy that only permits connections to the configured allowlist,
+// logging a structured JSON line for every connection it refuses. See
+// internal/egress for the policy/proxy implementation this wraps, and
+// internal/sandbox/egress.go for how the sidecar container and the
+// HTTP_PROXY/HTTPS_PROXY env vars pointing at it get wired up.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/egress"
+)
+
+func main() {
+	log.Println("Starting Cloud Sandbox egress proxy...")
+
+	hosts := splitNonEmpty(os.Getenv("ALLOWED_HOSTS"), ",")
+	if len(hosts) == 0 {
+		log.Fatal("[egress-proxy] ALLOWED_HOSTS must list at least one allowed host")
+	}
+
+	var ports []int
+	for _, p := range splitNonEmpty(os.Getenv("ALLOWED_PORTS"), ",") {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			log.Fatalf("[egress-proxy] invalid ALLOWED_PORTS entry %q: %v", p, err)
+		}
+		ports = append(ports, port)
+	}
+
+	policy, err := egress.NewPolicy(hosts, ports)
+	if err != nil {
+		log.Fatalf("[egress-proxy] failed to build policy: %v", err)
+	}
+
+	addr := os.Getenv("LISTEN_ADDR")
+	if addr == "" {
+		addr = ":3128"
+	}
+
+	log.Printf("[egress-proxy] listening on %s, allowing %v", addr, hosts)
+	if err := http.ListenAndServe(addr, egress.NewProxy(policy)); err != nil {
+		log.Fatalf("[egress-proxy] server failed: %v", err)
+	}
+}
+
+// splitNonEmpty splits s on sep, trims whitespace, and drops empty
+// fields - so a trailing comma or an unset env var both come back as a
+// nil slice instead of a slice holding "".
+func splitNonEmpty(s, sep string) []string {
+	var out []string
+	for _, part := range strings.Split(s, sep) {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -4,17 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httputil"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+
 	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
 	"github.com/cloud-sandbox/cloud-sandbox/internal/middleware"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/proxy"
 )
 
 // Config holds gateway configuration
@@ -24,45 +28,122 @@ type Config struct {
 	SessionManagerURL string
 	JWTSecret         string
 	AllowedOrigins    []string
+	// ExecTimeout bounds how long the scheduler has to respond to a
+	// /api/v1/execute request. Long-running execs need far more headroom
+	// than the rest of the API, so it gets its own route timeout instead
+	// of sharing one global client timeout.
+	ExecTimeout time.Duration
+
+	RedisAddr string
+
+	// JWTAlgorithm is "HS256" (default) or "RS256". RS256 lets the
+	// scheduler and session manager verify tokens via JWKS instead of
+	// sharing JWTSecret.
+	JWTAlgorithm string
+	// KeyRotationInterval is how often the RS256 signing key rotates.
+	// Ignored in HS256 mode.
+	KeyRotationInterval time.Duration
 }
 
 // Gateway is the API gateway server
 type Gateway struct {
-	config      Config
-	jwtAuth     *auth.JWTAuth
-	rateLimiter *middleware.RateLimiter
-	httpClient  *http.Client
+	config             Config
+	jwtAuth            *auth.JWTAuth
+	rateLimiter        *middleware.RateLimiter
+	schedulerProxy     *httputil.ReverseProxy
+	sessionMgrProxy    *httputil.ReverseProxy
+	schedulerExecProxy *httputil.ReverseProxy
+	refreshStore       auth.RefreshStore
+	revocation         *auth.RevocationSet
 }
 
 func main() {
 	log.Println("Starting Cloud Sandbox Gateway...")
 
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
+
+	if shutdown, err := metrics.InitTracer(rootCtx, "gateway"); err != nil {
+		log.Printf("[Gateway] Warning: failed to init tracing: %v", err)
+	} else if shutdown != nil {
+		defer shutdown(context.Background())
+	}
+
 	config := Config{
-		Port:              getEnv("PORT", "8080"),
-		SchedulerURL:      getEnv("SCHEDULER_URL", "http://localhost:9090"),
-		SessionManagerURL: getEnv("SESSION_MANAGER_URL", "http://localhost:9091"),
-		JWTSecret:         getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-		AllowedOrigins:    []string{"*"},
+		Port:                getEnv("PORT", "8080"),
+		SchedulerURL:        getEnv("SCHEDULER_URL", "http://localhost:9090"),
+		SessionManagerURL:   getEnv("SESSION_MANAGER_URL", "http://localhost:9091"),
+		JWTSecret:           getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		AllowedOrigins:      []string{"*"},
+		ExecTimeout:         30 * time.Minute,
+		RedisAddr:           getEnv("REDIS_ADDR", "localhost:6379"),
+		JWTAlgorithm:        getEnv("JWT_ALGORITHM", "HS256"),
+		KeyRotationInterval: 24 * time.Hour,
 	}
 
 	jwtAuth := auth.NewJWTAuth(auth.Config{
-		SecretKey:   config.JWTSecret,
-		TokenExpiry: 24 * time.Hour,
+		SecretKey:     config.JWTSecret,
+		TokenExpiry:   24 * time.Hour,
+		RefreshExpiry: 7 * 24 * time.Hour,
+		Algorithm:     config.JWTAlgorithm,
 	})
 
+	redisClient := redis.NewClient(&redis.Options{Addr: config.RedisAddr})
+	refreshStore := auth.NewRedisRefreshStore(redisClient, jwtAuth.RefreshExpiry())
+	revocation := auth.NewRevocationSet(redisClient)
+	jwtAuth.AttachRevocationSet(revocation)
+
+	if err := revocation.Warm(rootCtx); err != nil {
+		log.Printf("[Gateway] Warning: failed to warm revocation set: %v", err)
+	}
+	go revocation.StartResync(rootCtx, 5*time.Minute)
+
+	if keys := jwtAuth.Keys(); keys != nil {
+		go keys.StartRotation(rootCtx, config.KeyRotationInterval, 2)
+	}
+
 	rateLimiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
 		Rate:     100,
 		Interval: time.Minute,
 		Burst:    200,
 	})
 
+	schedulerProxy, err := proxy.New(proxy.Config{
+		Target:                config.SchedulerURL,
+		ResponseHeaderTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("failed to build scheduler proxy: %v", err)
+	}
+
+	// Exec requests can legitimately run far longer than ordinary API
+	// calls, so they get their own proxy whose response-header timeout is
+	// config.ExecTimeout rather than the scheduler proxy's 30s bound.
+	schedulerExecProxy, err := proxy.New(proxy.Config{
+		Target:                config.SchedulerURL,
+		ResponseHeaderTimeout: config.ExecTimeout,
+	})
+	if err != nil {
+		log.Fatalf("failed to build scheduler exec proxy: %v", err)
+	}
+
+	sessionMgrProxy, err := proxy.New(proxy.Config{
+		Target:                config.SessionManagerURL,
+		ResponseHeaderTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("failed to build session manager proxy: %v", err)
+	}
+
 	gateway := &Gateway{
-		config:      config,
-		jwtAuth:     jwtAuth,
-		rateLimiter: rateLimiter,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute,
-		},
+		config:             config,
+		jwtAuth:            jwtAuth,
+		rateLimiter:        rateLimiter,
+		schedulerProxy:     schedulerProxy,
+		sessionMgrProxy:    sessionMgrProxy,
+		schedulerExecProxy: schedulerExecProxy,
+		refreshStore:       refreshStore,
+		revocation:         revocation,
 	}
 
 	// Create router
@@ -71,6 +152,9 @@ func main() {
 	// Public endpoints (no auth required)
 	mux.HandleFunc("/health", gateway.handleHealth)
 	mux.HandleFunc("/api/v1/auth/token", gateway.handleToken)
+	mux.HandleFunc("/api/v1/auth/refresh", gateway.handleRefresh)
+	mux.HandleFunc("/api/v1/auth/revoke", gateway.handleRevoke)
+	mux.HandleFunc("/.well-known/jwks.json", gateway.handleJWKS)
 
 	// Protected endpoints
 	mux.HandleFunc("/api/v1/sandbox/", gateway.handleSandbox)
@@ -92,7 +176,7 @@ func main() {
 		Addr:         ":" + config.Port,
 		Handler:      handler,
 		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 5 * time.Minute,
+		WriteTimeout: 0, // streamed/long-lived responses (exec, tty) set their own deadlines
 	}
 
 	// Start server
@@ -153,7 +237,11 @@ func (g *Gateway) handleToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	refreshToken, _ := g.jwtAuth.GenerateRefreshToken(req.UserID)
+	refreshToken, err := g.refreshStore.NewFamily(r.Context(), req.UserID)
+	if err != nil {
+		http.Error(w, `{"error":"token generation failed"}`, http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -164,6 +252,93 @@ func (g *Gateway) handleToken(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleRefresh exchanges a refresh token for a new access token, rotating
+// the refresh token in the same call. Reusing a refresh token that was
+// already rotated revokes its whole family, per RefreshStore.Rotate.
+func (g *Gateway) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, `{"error":"invalid_request","message":"refresh_token required"}`, http.StatusBadRequest)
+		return
+	}
+
+	userID, nextRefreshToken, err := g.refreshStore.Rotate(r.Context(), req.RefreshToken)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":   "unauthorized",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	accessToken, err := g.jwtAuth.GenerateToken(userID, "")
+	if err != nil {
+		http.Error(w, `{"error":"token generation failed"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token":  accessToken,
+		"refresh_token": nextRefreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    "86400",
+	})
+}
+
+// handleRevoke revokes a refresh token's whole family, logging the user
+// out of every access token minted from it going forward. The caller's
+// current access token, if any, is also revoked immediately rather than
+// waiting for it to expire.
+func (g *Gateway) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, `{"error":"invalid_request","message":"refresh_token required"}`, http.StatusBadRequest)
+		return
+	}
+
+	if err := g.refreshStore.Revoke(r.Context(), req.RefreshToken); err != nil {
+		http.Error(w, `{"error":"revoke_failed"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if token, err := auth.ExtractTokenFromRequest(r); err == nil {
+		if claims, err := g.jwtAuth.ValidateToken(token); err == nil {
+			ttl := time.Until(claims.ExpiresAt.Time)
+			g.revocation.Revoke(r.Context(), claims.ID, ttl)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "revoked"})
+}
+
+// handleJWKS publishes the gateway's current and previous RS256 public
+// keys so the scheduler and session manager can verify access tokens
+// without sharing JWTSecret. Empty in HS256 mode.
+func (g *Gateway) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": g.jwtAuth.JWKS(),
+	})
+}
+
 // handleSandbox proxies sandbox management requests
 func (g *Gateway) handleSandbox(w http.ResponseWriter, r *http.Request) {
 	// Authenticate
@@ -171,8 +346,17 @@ func (g *Gateway) handleSandbox(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Proxy to scheduler
-	g.proxy(w, r, g.config.SchedulerURL)
+	// A session's interactive terminal is exposed as a sandbox route
+	// (wss://gateway/api/v1/sandbox/{id}/tty) and needs to hijack the
+	// connection instead of going through the buffered reverse proxy.
+	if proxy.IsWebSocketUpgrade(r) {
+		if err := proxy.HijackAndPipe(w, r, g.config.SchedulerURL); err != nil {
+			log.Printf("[Gateway] WebSocket upgrade failed: %v", err)
+		}
+		return
+	}
+
+	g.schedulerProxy.ServeHTTP(w, r)
 }
 
 // handleSessions proxies session list/create requests
@@ -207,15 +391,25 @@ func (g *Gateway) handleSessions(w http.ResponseWriter, r *http.Request) {
 		r.URL.RawQuery = q.Encode()
 	}
 
-	g.proxy(w, r, g.config.SessionManagerURL)
+	g.sessionMgrProxy.ServeHTTP(w, r)
 }
 
-// handleSession proxies individual session requests
+// handleSession proxies individual session requests. A request for
+// wss://gateway/api/v1/sessions/{id}/tty is upgraded and piped straight
+// through to the scheduler, which owns the sandbox's agent connection.
 func (g *Gateway) handleSession(w http.ResponseWriter, r *http.Request) {
 	if !g.authenticate(w, r) {
 		return
 	}
-	g.proxy(w, r, g.config.SessionManagerURL)
+
+	if proxy.IsWebSocketUpgrade(r) {
+		if err := proxy.HijackAndPipe(w, r, g.config.SchedulerURL); err != nil {
+			log.Printf("[Gateway] WebSocket upgrade failed: %v", err)
+		}
+		return
+	}
+
+	g.sessionMgrProxy.ServeHTTP(w, r)
 }
 
 // handleExecute proxies code execution requests
@@ -223,7 +417,7 @@ func (g *Gateway) handleExecute(w http.ResponseWriter, r *http.Request) {
 	if !g.authenticate(w, r) {
 		return
 	}
-	g.proxy(w, r, g.config.SchedulerURL)
+	g.schedulerExecProxy.ServeHTTP(w, r)
 }
 
 // handleFiles proxies file operation requests
@@ -231,7 +425,7 @@ func (g *Gateway) handleFiles(w http.ResponseWriter, r *http.Request) {
 	if !g.authenticate(w, r) {
 		return
 	}
-	g.proxy(w, r, g.config.SchedulerURL)
+	g.schedulerProxy.ServeHTTP(w, r)
 }
 
 // authenticate validates JWT and adds claims to context
@@ -258,69 +452,23 @@ func (g *Gateway) authenticate(w http.ResponseWriter, r *http.Request) bool {
 		return false
 	}
 
-	// Add claims to request context
-	ctx := auth.SetClaimsContext(r.Context(), claims)
-	*r = *r.WithContext(ctx)
-
-	return true
-}
-
-// proxy forwards the request to the backend service
-func (g *Gateway) proxy(w http.ResponseWriter, r *http.Request, targetURL string) {
-	// Build target URL
-	url := targetURL + r.URL.Path
-	if r.URL.RawQuery != "" {
-		url += "?" + r.URL.RawQuery
-	}
-
-	// Create proxy request
-	body, _ := io.ReadAll(r.Body)
-	proxyReq, err := http.NewRequestWithContext(r.Context(), r.Method, url, bytes.NewReader(body))
-	if err != nil {
-		http.Error(w, `{"error":"proxy_error"}`, http.StatusInternalServerError)
-		return
-	}
-
-	// Copy headers
-	for key, values := range r.Header {
-		for _, value := range values {
-			proxyReq.Header.Add(key, value)
-		}
-	}
-
-	// Add forwarded headers
-	proxyReq.Header.Set("X-Forwarded-For", r.RemoteAddr)
-	proxyReq.Header.Set("X-Forwarded-Host", r.Host)
-
-	// Add user ID header
-	if claims, ok := auth.GetClaimsFromContext(r.Context()); ok {
-		proxyReq.Header.Set("X-User-ID", claims.UserID)
-	}
-
-	// Send request
-	resp, err := g.httpClient.Do(proxyReq)
-	if err != nil {
-		log.Printf("[Gateway] Proxy error: %v", err)
+	if revoked, err := g.revocation.IsRevoked(r.Context(), claims.ID); err != nil {
+		log.Printf("[Gateway] revocation check failed: %v", err)
+	} else if revoked {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadGateway)
+		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": fmt.Sprintf("backend service unavailable: %s", targetURL),
+			"error":   "unauthorized",
+			"message": "token revoked",
 		})
-		return
+		return false
 	}
-	defer resp.Body.Close()
 
-	// Copy response headers
-	for key, values := range resp.Header {
-		for _, value := range values {
-			w.Header().Add(key, value)
-		}
-	}
+	// Add claims to request context
+	ctx := auth.SetClaimsContext(r.Context(), claims)
+	*r = *r.WithContext(ctx)
 
-	// Copy status code and body
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	return true
 }
 
 func getEnv(key, defaultValue string) string {
@@ -2,23 +2,53 @@ package main
 
 import (
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent/pb"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent/server"
 )
 
 func main() {
 	log.Println("Starting Sandbox Agent...")
 
-	// This agent runs INSIDE each sandbox container/VM
-	// It handles:
-	// - Code execution requests
-	// - File operations
-	// - Process management
-	// - Resource monitoring
+	// This agent runs INSIDE each sandbox container/VM. It exposes a gRPC
+	// control plane for exec, file sync, filesystem watch, and resource
+	// metrics, replacing the scheduler's former docker-exec/docker-cp path.
+	addr := getEnv("AGENT_LISTEN_ADDR", ":7000")
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
+
+	// DockerRuntime.Create mints AGENT_TLS_CERT/AGENT_TLS_KEY/AGENT_CA_CERT
+	// (see internal/agent.CertAuthority) and AGENT_BOOTSTRAP_TOKEN
+	// out-of-band before the container even starts, so by the time this
+	// process comes up it already holds everything it needs to require
+	// mutual TLS. Only a sandbox started without a runtime that wires those
+	// env vars (e.g. a bare `docker run` during local development) falls
+	// back to insecure.NewCredentials().
+	grpcServer := grpc.NewServer(serverOptions()...)
 
-	// TODO: Start gRPC server for receiving commands
-	// TODO: Start metrics collection
+	agentServer := server.New(server.Config{
+		WorkDir: getEnv("WORKSPACE_DIR", "/workspace"),
+	})
+	pb.RegisterAgentServer(grpcServer, agentServer)
+
+	go func() {
+		log.Printf("Sandbox Agent gRPC listening on %s", addr)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("gRPC server error: %v", err)
+		}
+	}()
 
 	log.Println("Sandbox Agent is running")
 
@@ -28,4 +58,39 @@ func main() {
 	<-sigChan
 
 	log.Println("Sandbox Agent shutting down...")
+	grpcServer.GracefulStop()
+}
+
+// serverOptions builds the gRPC server options: mutual TLS credentials
+// plus the bootstrap-token interceptors, when AGENT_TLS_CERT/AGENT_TLS_KEY/
+// AGENT_CA_CERT are present, otherwise an insecure server for local dev.
+func serverOptions() []grpc.ServerOption {
+	certPEM := os.Getenv("AGENT_TLS_CERT")
+	keyPEM := os.Getenv("AGENT_TLS_KEY")
+	caCertPEM := os.Getenv("AGENT_CA_CERT")
+
+	if certPEM == "" || keyPEM == "" || caCertPEM == "" {
+		log.Println("[Agent] WARNING: AGENT_TLS_CERT/AGENT_TLS_KEY/AGENT_CA_CERT not set, serving insecure gRPC (local dev only)")
+		return []grpc.ServerOption{grpc.Creds(insecure.NewCredentials())}
+	}
+
+	tlsConfig, err := agent.ServerTLSConfig([]byte(certPEM), []byte(keyPEM), []byte(caCertPEM))
+	if err != nil {
+		log.Fatalf("Failed to build agent TLS config: %v", err)
+	}
+
+	gate := agent.NewTokenGate(os.Getenv("AGENT_BOOTSTRAP_TOKEN"))
+
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.ChainUnaryInterceptor(gate.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(gate.StreamServerInterceptor),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
 }
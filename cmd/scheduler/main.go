@@ -1,31 +1,171 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
 	"github.com/cloud-sandbox/cloud-sandbox/internal/sandbox"
 )
 
+// eventRingCapacity bounds how many lifecycle events handleEvents can
+// replay to a reconnecting SSE subscriber; older events simply age out,
+// the same tradeoff Docker's own /events backlog makes.
+const eventRingCapacity = 1024
+
+// Per-route timeout budgets for httpTimeout, replacing reliance on the
+// server-wide 5-minute WriteTimeout for every route. acquireRouteTimeout
+// is deliberately generous: it's a backstop above the Wait-Timeout a
+// caller typically requests (see handleAcquire), not the thing enforcing
+// it - AcquireWithOptions's own ctx deadline and ErrQueueFull handle the
+// pool-pressure cases we actually want a specific response for. A
+// Wait-Timeout longer than acquireRouteTimeout is cut short by it.
+const (
+	healthRouteTimeout      = 2 * time.Second
+	statsRouteTimeout       = 2 * time.Second
+	releaseRouteTimeout     = 5 * time.Second
+	acquireRouteTimeout     = 65 * time.Second
+	filesRouteTimeout       = 30 * time.Second
+	defaultExecRouteTimeout = 2 * time.Minute
+)
+
+// httpTimeout wraps handler in http.TimeoutHandler with budget d, the
+// source of each route's deadline instead of the one-size-fits-all
+// WriteTimeout on the *http.Server. Deliberately NOT used for attach,
+// events, files/archive, or execute/batch: http.TimeoutHandler's
+// ResponseWriter implements neither http.Flusher nor http.Hijacker, so
+// wrapping a streaming or hijacked handler in it would break the very
+// thing that handler does.
+func httpTimeout(handler http.HandlerFunc, d time.Duration, message string) http.Handler {
+	return http.TimeoutHandler(handler, d, message)
+}
+
 type Server struct {
-	pool    *sandbox.Pool
-	runtime *sandbox.DockerRuntime
+	pool      *sandbox.Pool
+	runtime   sandbox.Runtime
+	eventRing *events.RingBuffer
+
+	// batchesMu guards batches, the cancel funcs for in-flight
+	// /api/v1/execute/batch runs, keyed by batch_id so
+	// DELETE /api/v1/execute/batch/{id} can stop one early.
+	batchesMu sync.Mutex
+	batches   map[string]context.CancelFunc
 }
 
 func main() {
 	log.Println("Starting Cloud Sandbox Scheduler...")
 
-	// Initialize Docker runtime
+	if shutdown, err := metrics.InitTracer(context.Background(), "scheduler"); err != nil {
+		log.Printf("[Scheduler] Warning: failed to init tracing: %v", err)
+	} else if shutdown != nil {
+		defer shutdown(context.Background())
+	}
+
+	// Initialize the sandbox runtime. RUNTIME_ENGINE selects "docker"
+	// (default), "gvisor", "podman", or "containerd" - see
+	// sandbox.Config.RuntimeEngine.
 	config := sandbox.DefaultConfig()
-	runtime, err := sandbox.NewDockerRuntime(config)
+	if engine := os.Getenv("RUNTIME_ENGINE"); engine != "" {
+		config.RuntimeEngine = engine
+	}
+	if socket := os.Getenv("RUNTIME_SOCKET"); socket != "" {
+		config.RuntimeSocket = socket
+	}
+	if namespace := os.Getenv("CONTAINERD_NAMESPACE"); namespace != "" {
+		config.ContainerdNamespace = namespace
+	}
+	runtime, err := sandbox.NewRuntime(config)
 	if err != nil {
-		log.Fatalf("Failed to create Docker runtime: %v", err)
+		log.Fatalf("Failed to create sandbox runtime: %v", err)
+	}
+
+	// Wire the cross-cutting events.Bus into the runtime and pool so
+	// sandbox.created/acquired/released/destroyed, exec.started/completed,
+	// sandbox.oom/timeout, and file.written reach both the optional
+	// webhook/Kafka sinks (mirroring cmd/session-manager/main.go's
+	// EVENT_BUS_* env vars) and the in-memory ring buffer handleEvents
+	// replays over SSE.
+	eventBus := events.NewBus()
+	eventRing := events.NewRingBuffer(eventRingCapacity)
+	eventBus.Subscribe(eventRing, nil)
+
+	var eventSpool *events.Spool
+	var kafkaEventSink *events.KafkaSink
+	if webhookURL := os.Getenv("EVENT_BUS_WEBHOOK_URL"); webhookURL != "" || os.Getenv("EVENT_BUS_KAFKA_BROKERS") != "" {
+		var filter *events.Filter
+		if expr := os.Getenv("EVENT_BUS_FILTER"); expr != "" {
+			parsed, err := events.ParseFilter(expr)
+			if err != nil {
+				log.Printf("[Scheduler] Warning: ignoring invalid EVENT_BUS_FILTER: %v", err)
+			} else {
+				filter = parsed
+			}
+		}
+
+		if webhookURL != "" {
+			webhookConfig := events.DefaultWebhookConfig()
+			webhookConfig.URL = webhookURL
+			webhookConfig.Secret = os.Getenv("EVENT_BUS_WEBHOOK_SECRET")
+			webhookConfig.AuthHeader = os.Getenv("EVENT_BUS_WEBHOOK_AUTH_HEADER")
+			webhookConfig.AuthToken = os.Getenv("EVENT_BUS_WEBHOOK_AUTH_TOKEN")
+
+			webhookSink, err := events.NewWebhookSink(webhookConfig)
+			if err != nil {
+				log.Printf("[Scheduler] Warning: Failed to set up event bus webhook sink: %v", err)
+			} else {
+				var sink events.Sink = webhookSink
+				if spoolDir := os.Getenv("EVENT_BUS_SPOOL_DIR"); spoolDir != "" {
+					spool, err := events.NewSpool(sink, events.SpoolConfig{Dir: filepath.Join(spoolDir, "webhook")})
+					if err != nil {
+						log.Printf("[Scheduler] Warning: Failed to set up event spool for webhook sink: %v", err)
+					} else {
+						sink = spool
+						eventSpool = spool
+					}
+				}
+				eventBus.Subscribe(sink, filter)
+				log.Printf("[Scheduler] Publishing lifecycle events to webhook %s", webhookURL)
+			}
+		}
+
+		if brokers := os.Getenv("EVENT_BUS_KAFKA_BROKERS"); brokers != "" {
+			kafkaConfig := events.DefaultKafkaConfig()
+			kafkaConfig.Brokers = strings.Split(brokers, ",")
+			if topic := os.Getenv("EVENT_BUS_KAFKA_TOPIC"); topic != "" {
+				kafkaConfig.Topic = topic
+			}
+
+			sink, err := events.NewKafkaSink(kafkaConfig)
+			if err != nil {
+				log.Printf("[Scheduler] Warning: Failed to set up event bus Kafka sink: %v", err)
+			} else {
+				kafkaEventSink = sink
+				eventBus.Subscribe(sink, filter)
+				log.Printf("[Scheduler] Publishing lifecycle events to Kafka topic %s", kafkaConfig.Topic)
+			}
+		}
+	}
+
+	if eventSource, ok := runtime.(interface{ AttachEventBus(*events.Bus) }); ok {
+		eventSource.AttachEventBus(eventBus)
 	}
 
 	// Initialize sandbox pool
@@ -36,36 +176,65 @@ func main() {
 		IdleTimeout:     30 * time.Minute,
 		CleanupInterval: 5 * time.Minute,
 		SandboxConfig:   config,
+		MaxQueueSize:    100,
 	}
 	pool := sandbox.NewPool(poolConfig, runtime)
+	pool.AttachEventBus(eventBus)
 
 	server := &Server{
-		pool:    pool,
-		runtime: runtime,
+		pool:      pool,
+		runtime:   runtime,
+		eventRing: eventRing,
+		batches:   make(map[string]context.CancelFunc),
+	}
+
+	execRouteTimeout := defaultExecRouteTimeout
+	if s := os.Getenv("EXECUTE_ROUTE_TIMEOUT_SECONDS"); s != "" {
+		if seconds, err := strconv.Atoi(s); err == nil && seconds > 0 {
+			execRouteTimeout = time.Duration(seconds) * time.Second
+		} else {
+			log.Printf("[Scheduler] Warning: ignoring invalid EXECUTE_ROUTE_TIMEOUT_SECONDS %q", s)
+		}
 	}
 
 	// Create HTTP server
 	mux := http.NewServeMux()
 
 	// Health check
-	mux.HandleFunc("/health", server.handleHealth)
+	mux.Handle("/health", httpTimeout(server.handleHealth, healthRouteTimeout, "health check timed out"))
+
+	// Sandbox management. /sandbox/acquire gets a generous backstop
+	// timeout rather than one sized to enforce back-pressure - that's
+	// handleAcquire's own Wait-Timeout/ErrQueueFull handling's job.
+	mux.Handle("/api/v1/sandbox/acquire", httpTimeout(server.handleAcquire, acquireRouteTimeout, "acquire timed out"))
+	mux.Handle("/api/v1/sandbox/release", httpTimeout(server.handleRelease, releaseRouteTimeout, "release timed out"))
+	mux.Handle("/api/v1/sandbox/stats", httpTimeout(server.handleStats, statsRouteTimeout, "stats timed out"))
+	mux.HandleFunc("/api/v1/sandbox/", server.handleSandboxSubresource)
 
-	// Sandbox management
-	mux.HandleFunc("/api/v1/sandbox/acquire", server.handleAcquire)
-	mux.HandleFunc("/api/v1/sandbox/release", server.handleRelease)
-	mux.HandleFunc("/api/v1/sandbox/stats", server.handleStats)
+	// Code execution. /execute/batch streams NDJSON and /sandbox/attach
+	// hijacks the connection, so neither can be wrapped in
+	// http.TimeoutHandler - see httpTimeout's doc comment.
+	mux.Handle("/api/v1/execute", httpTimeout(server.handleExecute, execRouteTimeout, "execute timed out"))
+	mux.HandleFunc("/api/v1/execute/batch", server.handleExecuteBatch)
+	mux.HandleFunc("/api/v1/execute/batch/", server.handleExecuteBatchCancel)
+	mux.HandleFunc("/api/v1/sandbox/attach", server.handleAttach)
 
-	// Code execution
-	mux.HandleFunc("/api/v1/execute", server.handleExecute)
+	// File operations. /files/archive streams a tar in both directions,
+	// so it's left unwrapped the same way /execute/batch is.
+	mux.Handle("/api/v1/files", httpTimeout(server.handleFiles, filesRouteTimeout, "files request timed out"))
+	mux.HandleFunc("/api/v1/files/archive", server.handleFilesArchive)
 
-	// File operations
-	mux.HandleFunc("/api/v1/files", server.handleFiles)
+	// Lifecycle events - long-lived SSE, left unwrapped.
+	mux.HandleFunc("/api/v1/events", server.handleEvents)
 
 	httpServer := &http.Server{
-		Addr:         ":9090",
-		Handler:      mux,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 5 * time.Minute, // Long timeout for code execution
+		Addr:        ":9090",
+		Handler:     mux,
+		ReadTimeout: 30 * time.Second,
+		// WriteTimeout now only backstops routes httpTimeout doesn't
+		// already bound more tightly (the streaming/hijacked ones above),
+		// which can legitimately run long.
+		WriteTimeout: 5 * time.Minute,
 	}
 
 	// Start server
@@ -92,7 +261,15 @@ func main() {
 
 	httpServer.Shutdown(ctx)
 	pool.Close()
-	runtime.Close()
+	if closer, ok := runtime.(io.Closer); ok {
+		closer.Close()
+	}
+	if eventSpool != nil {
+		eventSpool.Close()
+	}
+	if kafkaEventSink != nil {
+		kafkaEventSink.Close()
+	}
 
 	log.Println("Scheduler stopped")
 }
@@ -107,6 +284,16 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(s.pool.Stats())
 }
 
+type AcquireRequest struct {
+	// FromSnapshot, if set, acquires from that snapshot image's template
+	// sub-pool (see Pool.AcquireFromSnapshot) instead of the pool's default
+	// SandboxConfig.Image - warming a toolchain once via handleSnapshot and
+	// then forking cheap short-lived sandboxes from it. Priority/TenantID/
+	// Wait-Timeout are ignored when this is set: AcquireFromSnapshot never
+	// queues.
+	FromSnapshot string `json:"from_snapshot,omitempty"`
+}
+
 type AcquireResponse struct {
 	SandboxID   string `json:"sandbox_id"`
 	ContainerID string `json:"container_id"`
@@ -114,15 +301,73 @@ type AcquireResponse struct {
 	IP          string `json:"ip,omitempty"`
 }
 
+// handleAcquire acquires a sandbox, optionally joining the pool's bounded
+// waiter queue instead of failing immediately when the pool is full: a
+// Wait-Timeout header (or ?wait_timeout= query param), in seconds, opts
+// the caller into AcquireWithOptions's queueing behavior under ?priority=
+// and ?tenant_id=. Once PoolConfig.MaxQueueSize queued callers are already
+// ahead of it, the request gets 429 with Retry-After instead of waiting
+// its full timeout only to time out anyway. A JSON body with from_snapshot
+// set instead acquires from that snapshot's template sub-pool; the body is
+// otherwise optional.
 func (s *Server) handleAcquire(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	var req AcquireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
 	ctx := r.Context()
-	sb, err := s.pool.Acquire(ctx)
+
+	if req.FromSnapshot != "" {
+		sb, err := s.pool.AcquireFromSnapshot(ctx, req.FromSnapshot)
+		if err != nil {
+			log.Printf("Failed to acquire sandbox from snapshot %q: %v", req.FromSnapshot, err)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AcquireResponse{
+			SandboxID:   sb.ID,
+			ContainerID: sb.ContainerID,
+			Status:      string(sb.Status),
+			IP:          sb.IP,
+		})
+		return
+	}
+
+	opts := sandbox.AcquireOpts{
+		Priority: sandbox.Priority(r.URL.Query().Get("priority")),
+		TenantID: r.URL.Query().Get("tenant_id"),
+	}
+
+	waitTimeout := r.Header.Get("Wait-Timeout")
+	if waitTimeout == "" {
+		waitTimeout = r.URL.Query().Get("wait_timeout")
+	}
+	if waitTimeout != "" {
+		seconds, err := strconv.Atoi(waitTimeout)
+		if err != nil {
+			http.Error(w, "invalid Wait-Timeout", http.StatusBadRequest)
+			return
+		}
+		opts.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	sb, err := s.pool.AcquireWithOptions(ctx, opts)
 	if err != nil {
+		var queueFull *sandbox.ErrQueueFull
+		if errors.As(err, &queueFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(queueFull.RetryAfter.Seconds()+1)))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		log.Printf("Failed to acquire sandbox: %v", err)
 		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		return
@@ -172,6 +417,10 @@ type ExecuteRequest struct {
 	WorkDir   string            `json:"work_dir,omitempty"`
 	Env       map[string]string `json:"env,omitempty"`
 	Timeout   int               `json:"timeout,omitempty"` // seconds
+
+	// TTY requests a pseudo-terminal for handleAttach. Ignored by
+	// handleExecute, which never multiplexes stdout/stderr.
+	TTY bool `json:"tty,omitempty"`
 }
 
 type ExecuteResponse struct {
@@ -183,6 +432,68 @@ type ExecuteResponse struct {
 	Error    string `json:"error,omitempty"`
 }
 
+// Batch execution modes for BatchRequest.Mode. batchModeSequential (the
+// default) runs steps in list order and stops at the first failure;
+// batchModeParallel runs steps concurrently up to Concurrency, scheduling
+// each step once every ID in its DependsOn has resolved.
+const (
+	batchModeSequential = "sequential"
+	batchModeParallel   = "parallel"
+
+	// defaultBatchConcurrency caps batchModeParallel when
+	// BatchRequest.Concurrency is unset.
+	defaultBatchConcurrency = 4
+)
+
+// BatchStepRequest is one step of a POST /api/v1/execute/batch request.
+// StepID names the step for DependsOn references and step-result
+// correlation; it defaults to the step's index (as a string) if empty.
+type BatchStepRequest struct {
+	ExecuteRequest
+	StepID    string   `json:"step_id,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// stepID returns r.StepID, defaulting to index i if unset.
+func (r BatchStepRequest) stepID(i int) string {
+	if r.StepID != "" {
+		return r.StepID
+	}
+	return strconv.Itoa(i)
+}
+
+// BatchRequest is POST /api/v1/execute/batch's body.
+type BatchRequest struct {
+	Steps []BatchStepRequest `json:"steps"`
+
+	// Mode selects batchModeSequential or batchModeParallel; empty
+	// defaults to batchModeSequential.
+	Mode string `json:"mode,omitempty"`
+
+	// Concurrency caps in-flight steps under batchModeParallel. Ignored
+	// under batchModeSequential, which is inherently one step at a time.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// BatchStarted is the first NDJSON line handleExecuteBatch writes, giving
+// the caller BatchID before any step result so it can be cancelled via
+// DELETE /api/v1/execute/batch/{id} while still in flight.
+type BatchStarted struct {
+	BatchID string `json:"batch_id"`
+}
+
+// BatchStepResult is one NDJSON line handleExecuteBatch writes per step,
+// in completion order (which, under batchModeParallel, isn't necessarily
+// Index order).
+type BatchStepResult struct {
+	BatchID string              `json:"batch_id"`
+	StepID  string              `json:"step_id"`
+	Index   int                 `json:"index"`
+	Status  string              `json:"status"` // completed, failed, skipped, cancelled
+	Result  *sandbox.ExecResult `json:"result,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
 func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -200,14 +511,36 @@ func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get sandbox from pool
-	sb, err := s.pool.Get(r.Context(), req.SandboxID)
+	result, err := s.execOne(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Sandbox not found", http.StatusNotFound)
+		log.Printf("Execution error: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ExecuteResponse{
+			ExitCode: -1,
+			Error:    err.Error(),
+		})
 		return
 	}
 
-	// Build exec request
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ExecuteResponse{
+		ExitCode: result.ExitCode,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		Duration: result.Duration.Milliseconds(),
+		TimedOut: result.TimedOut,
+	})
+}
+
+// execOne resolves req.SandboxID against the pool and runs req through
+// s.runtime.Exec, the shared body behind handleExecute and every batch
+// step handleExecuteBatch runs.
+func (s *Server) execOne(ctx context.Context, req ExecuteRequest) (*sandbox.ExecResult, error) {
+	sb, err := s.pool.Get(ctx, req.SandboxID)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox not found: %w", err)
+	}
+
 	execReq := sandbox.ExecRequest{
 		Code:     req.Code,
 		Language: req.Language,
@@ -215,31 +548,423 @@ func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
 		WorkDir:  req.WorkDir,
 		Env:      req.Env,
 	}
+	if req.Timeout > 0 {
+		execReq.Timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	return s.runtime.Exec(ctx, sb.ID, execReq)
+}
+
+// handleExecuteBatch runs a multi-step "install deps -> generate code ->
+// run tests -> collect artifacts" request without N separate HTTP round
+// trips: NDJSON-streaming each step's BatchStepResult as it finishes
+// instead of buffering the whole batch behind the server's single write
+// timeout. The first line is always a BatchStarted carrying batch_id, so
+// the caller can cancel the rest of the batch via
+// DELETE /api/v1/execute/batch/{id} before it's done.
+func (s *Server) handleExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Steps) == 0 {
+		http.Error(w, "steps must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	batchID := uuid.New().String()
+	ctx, cancel := context.WithCancel(r.Context())
+	s.batchesMu.Lock()
+	s.batches[batchID] = cancel
+	s.batchesMu.Unlock()
+	defer func() {
+		cancel()
+		s.batchesMu.Lock()
+		delete(s.batches, batchID)
+		s.batchesMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	var writeMu sync.Mutex
+	writeLine := func(v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		w.Write(data)
+		w.Write([]byte("\n"))
+		flusher.Flush()
+	}
+
+	writeLine(BatchStarted{BatchID: batchID})
+
+	emit := func(res BatchStepResult) {
+		res.BatchID = batchID
+		writeLine(res)
+	}
+
+	if req.Mode == batchModeParallel {
+		s.runBatchParallel(ctx, req.Steps, concurrency, emit)
+	} else {
+		s.runBatchSequential(ctx, req.Steps, emit)
+	}
+}
+
+// runBatchSequential runs steps in list order, fail-fast: once a step
+// doesn't complete cleanly, every remaining step is emitted as skipped
+// rather than run. DependsOn is ignored here, since list order already is
+// the dependency order sequential mode promises.
+func (s *Server) runBatchSequential(ctx context.Context, steps []BatchStepRequest, emit func(BatchStepResult)) {
+	failed := false
+	for i, step := range steps {
+		stepID := step.stepID(i)
+		if failed {
+			emit(BatchStepResult{StepID: stepID, Index: i, Status: "skipped"})
+			continue
+		}
+		if s.runBatchStep(ctx, stepID, i, step, emit) != "completed" {
+			failed = true
+		}
+	}
+}
+
+// runBatchParallel schedules steps in dependency waves: each wave runs,
+// up to concurrency at a time, every not-yet-scheduled step whose
+// DependsOn entries have all resolved, then waits for the whole wave
+// before computing the next one. A step downstream of a failed or
+// skipped dependency is emitted as skipped without running.
+func (s *Server) runBatchParallel(ctx context.Context, steps []BatchStepRequest, concurrency int, emit func(BatchStepResult)) {
+	n := len(steps)
+	ids := make([]string, n)
+	indexByID := make(map[string]int, n)
+	for i, step := range steps {
+		ids[i] = step.stepID(i)
+		indexByID[ids[i]] = i
+	}
+
+	status := make([]string, n)
+	remaining := n
+
+	for remaining > 0 {
+		var ready []int
+		for i, step := range steps {
+			if status[i] != "" {
+				continue
+			}
+
+			resolved, skip := true, false
+			for _, dep := range step.DependsOn {
+				depIdx, ok := indexByID[dep]
+				if !ok {
+					continue
+				}
+				if status[depIdx] == "" {
+					resolved = false
+					break
+				}
+				if status[depIdx] != "completed" {
+					skip = true
+				}
+			}
+			if !resolved {
+				continue
+			}
+			if skip {
+				status[i] = "skipped"
+				remaining--
+				emit(BatchStepResult{StepID: ids[i], Index: i, Status: "skipped"})
+				continue
+			}
+			ready = append(ready, i)
+		}
+
+		if len(ready) == 0 {
+			break
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for _, i := range ready {
+			i := i
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				status[i] = s.runBatchStep(ctx, ids[i], i, steps[i], emit)
+			}()
+		}
+		wg.Wait()
+		remaining -= len(ready)
+	}
+}
+
+// runBatchStep runs one step and emits its BatchStepResult, returning the
+// status ("completed", "failed", or "cancelled") the caller's scheduling
+// loop uses to decide what runs next.
+func (s *Server) runBatchStep(ctx context.Context, stepID string, index int, step BatchStepRequest, emit func(BatchStepResult)) string {
+	select {
+	case <-ctx.Done():
+		emit(BatchStepResult{StepID: stepID, Index: index, Status: "cancelled"})
+		return "cancelled"
+	default:
+	}
+
+	result, err := s.execOne(ctx, step.ExecuteRequest)
+	if err != nil {
+		emit(BatchStepResult{StepID: stepID, Index: index, Status: "failed", Error: err.Error()})
+		return "failed"
+	}
+
+	status := "completed"
+	if result.ExitCode != 0 {
+		status = "failed"
+	}
+	emit(BatchStepResult{StepID: stepID, Index: index, Status: status, Result: result})
+	return status
+}
+
+// handleExecuteBatchCancel cancels an in-flight batch's context. A step
+// already running sees that context cancelled and reports whatever its
+// exec returns; a step not yet started is emitted as "cancelled", and in
+// sequential mode every step after the first one that didn't complete is
+// emitted as "skipped".
+func (s *Server) handleExecuteBatchCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	batchID := strings.TrimPrefix(r.URL.Path, "/api/v1/execute/batch/")
+	if batchID == "" {
+		http.Error(w, "batch id is required", http.StatusBadRequest)
+		return
+	}
+
+	s.batchesMu.Lock()
+	cancel, ok := s.batches[batchID]
+	s.batchesMu.Unlock()
+	if !ok {
+		http.Error(w, "batch not found", http.StatusNotFound)
+		return
+	}
+
+	cancel()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// execStreamer is implemented by Runtime backends that support
+// ExecStream (currently DockerRuntime and its GvisorRuntime/PodmanRuntime
+// wrappers) - checked via interface assertion the same way
+// AttachEventBus is, so Runtime itself doesn't have to grow a method
+// every backend must implement.
+type execStreamer interface {
+	ExecStream(ctx context.Context, id string, req sandbox.ExecRequest) (sandbox.ExecSession, error)
+}
+
+// Frame stream IDs for handleAttach's multiplexed output, matching
+// Docker's stdcopy convention (stdin is 0, but handleAttach never frames
+// stdin - the client's raw bytes flow straight into the process).
+const (
+	attachStreamStdout byte = 1
+	attachStreamStderr byte = 2
+)
+
+// writeAttachFrame writes one stdcopy-style frame to w: a 1-byte stream
+// ID, 3 reserved/zero bytes, a 4-byte big-endian payload length, then the
+// payload itself - so a client reading the hijacked connection can demux
+// stdout from stderr without a side channel.
+func writeAttachFrame(w io.Writer, streamID byte, payload []byte) error {
+	header := make([]byte, 8)
+	header[0] = streamID
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// handleAttach hijacks the HTTP connection and multiplexes a long-lived
+// ExecStream session over it: the client's raw request body bytes are
+// piped to the process's stdin, and its stdout/stderr come back as
+// stdcopy-framed chunks (see writeAttachFrame) so agents can observe
+// output incrementally - and cancel by closing the socket - instead of
+// waiting on handleExecute's single buffered response.
+func (s *Server) handleAttach(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	streamer, ok := s.runtime.(execStreamer)
+	if !ok {
+		http.Error(w, "runtime does not support streaming attach", http.StatusNotImplemented)
+		return
+	}
+
+	sandboxID := r.URL.Query().Get("sandbox_id")
+	if sandboxID == "" {
+		http.Error(w, "sandbox_id is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := s.pool.Get(r.Context(), sandboxID); err != nil {
+		http.Error(w, "Sandbox not found", http.StatusNotFound)
+		return
+	}
 
+	var req ExecuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	execReq := sandbox.ExecRequest{
+		Code:     req.Code,
+		Language: req.Language,
+		Command:  req.Command,
+		WorkDir:  req.WorkDir,
+		Env:      req.Env,
+		TTY:      req.TTY,
+	}
 	if req.Timeout > 0 {
 		execReq.Timeout = time.Duration(req.Timeout) * time.Second
 	}
 
-	// Execute
-	result, err := s.runtime.Exec(r.Context(), sb.ID, execReq)
+	sess, err := streamer.ExecStream(r.Context(), sandboxID, execReq)
 	if err != nil {
-		log.Printf("Execution error: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(ExecuteResponse{
-			ExitCode: -1,
-			Error:    err.Error(),
-		})
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ExecuteResponse{
-		ExitCode: result.ExitCode,
-		Stdout:   result.Stdout,
-		Stderr:   result.Stderr,
-		Duration: result.Duration.Milliseconds(),
-		TimedOut: result.TimedOut,
-	})
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, "failed to hijack connection", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	bufrw.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.cloud-sandbox.multiplexed-stream\r\n\r\n")
+	bufrw.Flush()
+
+	var writeMu sync.Mutex
+	pump := func(streamID byte, src io.Reader) {
+		buf := make([]byte, 32*1024)
+		for {
+			n, readErr := src.Read(buf)
+			if n > 0 {
+				writeMu.Lock()
+				writeErr := writeAttachFrame(conn, streamID, buf[:n])
+				writeMu.Unlock()
+				if writeErr != nil {
+					return
+				}
+			}
+			if readErr != nil {
+				return
+			}
+		}
+	}
+
+	done := make(chan struct{}, 2)
+	go func() { pump(attachStreamStdout, sess.Stdout()); done <- struct{}{} }()
+	go func() { pump(attachStreamStderr, sess.Stderr()); done <- struct{}{} }()
+	go io.Copy(sess.Stdin(), bufrw)
+
+	<-done
+	<-done
+	sess.Wait()
+}
+
+// handleEvents streams sandbox/pool lifecycle events as Server-Sent Events,
+// mirroring the Docker/Podman events API: sandbox.created/acquired/
+// released/destroyed, exec.started/completed, sandbox.oom/timeout, and
+// file.written. A client reconnecting with a Last-Event-ID header (or
+// ?last_event_id= query param) replays everything it missed from
+// s.eventRing's backlog before switching to live delivery, so a brief
+// disconnect never silently drops events.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var lastID uint64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		lastID, _ = strconv.ParseUint(id, 10, 64)
+	} else if id := r.URL.Query().Get("last_event_id"); id != "" {
+		lastID, _ = strconv.ParseUint(id, 10, 64)
+	}
+
+	backlog, live, unsubscribe := s.eventRing.Since(lastID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	writeEntry := func(entry events.RingEntry) bool {
+		data, err := json.Marshal(entry.Event)
+		if err != nil {
+			return true
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.Seq, entry.Event.Type, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	for _, entry := range backlog {
+		if !writeEntry(entry) {
+			return
+		}
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case entry := <-live:
+			if !writeEntry(entry) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 type FileRequest struct {
@@ -265,6 +990,13 @@ func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
 			path = "/workspace"
 		}
 
+		// ?content=1 downloads path's bytes (honoring Range) instead of
+		// listing the directory it's in.
+		if r.URL.Query().Get("content") != "" {
+			s.handleFileDownload(w, r, sandboxID, path)
+			return
+		}
+
 		files, err := s.runtime.ListFiles(ctx, sandboxID, path)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -321,3 +1053,230 @@ func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// handleFileDownload serves path's content for GET /api/v1/files?content=1,
+// honoring a Range header via http.ServeContent the same way a static file
+// server would - an agent pulling one large artifact out of a sandbox can
+// resume or fetch a byte range instead of reading the whole thing.
+func (s *Server) handleFileDownload(w http.ResponseWriter, r *http.Request, sandboxID, path string) {
+	content, err := s.runtime.ReadFile(r.Context(), sandboxID, path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, filepath.Base(path), time.Time{}, bytes.NewReader(content))
+}
+
+// archiveRuntime is implemented by Runtime backends that support
+// PutArchive/GetArchive (currently DockerRuntime) - checked via interface
+// assertion the same way execStreamer is, since not every backend can wrap
+// a Docker-specific archive API.
+type archiveRuntime interface {
+	PutArchive(ctx context.Context, id string, dirPath string, src io.Reader) error
+	GetArchive(ctx context.Context, id string, dirPath string) (io.ReadCloser, error)
+}
+
+// handleFilesArchive implements PUT/GET /api/v1/files/archive, matching
+// Docker's PUT/GET /containers/{id}/archive semantics: PUT extracts a tar
+// stream (optionally gzip/bzip2/xz-compressed) into path, GET streams path
+// back as an uncompressed tar. Both stream the whole body instead of
+// buffering it as handleFiles' JSON-with-base64-content PUT does, so a
+// large directory tree doesn't have to fit in memory twice.
+func (s *Server) handleFilesArchive(w http.ResponseWriter, r *http.Request) {
+	archiver, ok := s.runtime.(archiveRuntime)
+	if !ok {
+		http.Error(w, "runtime does not support archive transfer", http.StatusNotImplemented)
+		return
+	}
+
+	sandboxID := r.URL.Query().Get("sandbox_id")
+	path := r.URL.Query().Get("path")
+	if sandboxID == "" {
+		http.Error(w, "sandbox_id is required", http.StatusBadRequest)
+		return
+	}
+	if path == "" {
+		path = "/workspace"
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if err := archiver.PutArchive(r.Context(), sandboxID, path, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+
+	case http.MethodGet:
+		rc, err := archiver.GetArchive(r.Context(), sandboxID, path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", "application/x-tar")
+		w.WriteHeader(http.StatusOK)
+		io.Copy(w, rc)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// snapshotImagePrefix/forkImagePrefix tag the images handleSnapshot and
+// handleFork commit, so `docker images` and SyncFromDocker both read as
+// "this came from the templating endpoints" rather than a regular pull.
+const (
+	snapshotImagePrefix = "cloud-sandbox/snapshot/"
+	forkImagePrefix     = "cloud-sandbox/fork/"
+)
+
+// committer is implemented by Runtime backends that support Commit
+// (currently DockerRuntime) - checked via interface assertion the same way
+// execStreamer and archiveRuntime are, since committing a filesystem layer
+// to a new image is a Docker-specific capability.
+type committer interface {
+	Commit(ctx context.Context, id string, imageName string) (string, error)
+}
+
+// handleSandboxSubresource dispatches POST /api/v1/sandbox/{id}/snapshot
+// and /fork. It's registered as a prefix handler rather than one route per
+// action because net/http's ServeMux has no path-parameter syntax; the
+// exact routes registered elsewhere (acquire, release, stats, attach) still
+// take priority over this one since ServeMux always prefers the longest
+// matching pattern.
+func (s *Server) handleSandboxSubresource(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/sandbox/")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	sandboxID, action := parts[0], parts[1]
+	switch action {
+	case "snapshot":
+		s.handleSnapshot(w, r, sandboxID)
+	case "fork":
+		s.handleFork(w, r, sandboxID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// SnapshotRequest is POST /api/v1/sandbox/{id}/snapshot's body. Name is
+// optional; left empty, a name is generated from the sandbox ID.
+type SnapshotRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+type SnapshotResponse struct {
+	Image string `json:"image"`
+}
+
+// handleSnapshot commits sandboxID's current container filesystem to a
+// named image via DockerRuntime.Commit, so it can be reused later as
+// AcquireRequest.FromSnapshot - an agent installs a language toolchain
+// once, snapshots it, then warms cheap short-lived sandboxes from the
+// result instead of repeating the install every time.
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request, sandboxID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commit, ok := s.runtime.(committer)
+	if !ok {
+		http.Error(w, "runtime does not support snapshotting", http.StatusNotImplemented)
+		return
+	}
+
+	var req SnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := s.pool.Get(ctx, sandboxID); err != nil {
+		http.Error(w, "Sandbox not found", http.StatusNotFound)
+		return
+	}
+
+	imageName := req.Name
+	if imageName == "" {
+		imageName = fmt.Sprintf("%s%s:latest", snapshotImagePrefix, sandboxID)
+	}
+
+	image, err := commit.Commit(ctx, sandboxID, imageName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SnapshotResponse{Image: image})
+}
+
+// ForkResponse describes the sibling sandbox POST /api/v1/sandbox/{id}/fork
+// created, mirroring AcquireResponse plus ForkedFrom.
+type ForkResponse struct {
+	SandboxID   string `json:"sandbox_id"`
+	ContainerID string `json:"container_id"`
+	Status      string `json:"status"`
+	IP          string `json:"ip,omitempty"`
+	ForkedFrom  string `json:"forked_from"`
+}
+
+// handleFork creates a sibling sandbox sharing parentID's current
+// filesystem: it commits the parent to an ephemeral image (without
+// stopping or otherwise touching the parent sandbox) and acquires a new
+// sandbox from it via Pool.AcquireFromSnapshot, so an agent can explore
+// several candidate patches in parallel from the same starting state.
+func (s *Server) handleFork(w http.ResponseWriter, r *http.Request, parentID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	commit, ok := s.runtime.(committer)
+	if !ok {
+		http.Error(w, "runtime does not support forking", http.StatusNotImplemented)
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := s.pool.Get(ctx, parentID); err != nil {
+		http.Error(w, "Sandbox not found", http.StatusNotFound)
+		return
+	}
+
+	forkImage := fmt.Sprintf("%s%s-%s:latest", forkImagePrefix, parentID, uuid.New().String()[:8])
+	if _, err := commit.Commit(ctx, parentID, forkImage); err != nil {
+		http.Error(w, fmt.Sprintf("failed to commit parent sandbox: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	child, err := s.pool.AcquireFromSnapshot(ctx, forkImage)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if child.Labels == nil {
+		child.Labels = make(map[string]string)
+	}
+	child.Labels["forked_from"] = parentID
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ForkResponse{
+		SandboxID:   child.ID,
+		ContainerID: child.ContainerID,
+		Status:      string(child.Status),
+		IP:          child.IP,
+		ForkedFrom:  parentID,
+	})
+}
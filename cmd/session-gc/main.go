@@ -0,0 +1,68 @@
+// Command session-gc runs ChunkedStorage's mark-and-sweep garbage
+// collection once and exits: it scans every workspace manifest revision
+// still in the database, then deletes any content-addressed chunk none of
+// them reference any more. Run it periodically (e.g. from a cron job or
+// Kubernetes CronJob) alongside the long-lived session-manager server.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session"
+)
+
+func main() {
+	log.Println("Starting Cloud Sandbox workspace chunk GC...")
+
+	pgConfig := session.DefaultPostgresConfig()
+	if host := os.Getenv("POSTGRES_HOST"); host != "" {
+		pgConfig.Host = host
+	}
+	if port := os.Getenv("POSTGRES_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			pgConfig.Port = p
+		}
+	}
+	if user := os.Getenv("POSTGRES_USER"); user != "" {
+		pgConfig.User = user
+	}
+	if password := os.Getenv("POSTGRES_PASSWORD"); password != "" {
+		pgConfig.Password = password
+	}
+	if dbName := os.Getenv("POSTGRES_DB"); dbName != "" {
+		pgConfig.DBName = dbName
+	}
+
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		pgConfig.Host, pgConfig.Port, pgConfig.User, pgConfig.Password, pgConfig.DBName, pgConfig.SSLMode,
+	)
+	conn := &session.DBConnector{Config: session.DBConfig{Driver: "postgres", DSN: dsn}}
+	db, err := conn.GetDB(context.Background())
+	if err != nil {
+		log.Fatalf("[session-gc] Failed to connect to PostgreSQL: %v", err)
+	}
+
+	chunkedConfig := session.DefaultChunkedStorageConfig()
+	if endpoint := os.Getenv("MINIO_ENDPOINT"); endpoint != "" {
+		chunkedConfig.Endpoint = endpoint
+	}
+	chunkedConfig.DB = db
+
+	storage, err := session.NewChunkedStorage(chunkedConfig)
+	if err != nil {
+		log.Fatalf("[session-gc] Failed to initialize chunked storage: %v", err)
+	}
+	defer storage.Close()
+
+	removed, err := storage.GC(context.Background())
+	if err != nil {
+		log.Fatalf("[session-gc] GC failed: %v", err)
+	}
+
+	fmt.Printf("Removed %d orphaned chunks\n", removed)
+}
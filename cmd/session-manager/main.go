@@ -3,34 +3,75 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
 	"github.com/cloud-sandbox/cloud-sandbox/internal/session"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session/grpcserver"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session/pb"
 )
 
 type Server struct {
 	manager *session.DefaultManager
+	svc     pb.SessionServiceServer
 }
 
 func main() {
 	log.Println("Starting Cloud Sandbox Session Manager...")
 
-	// Initialize PostgreSQL store
-	pgConfig := session.DefaultPostgresConfig()
-	if host := os.Getenv("POSTGRES_HOST"); host != "" {
-		pgConfig.Host = host
+	if shutdown, err := metrics.InitTracer(context.Background(), "session-manager"); err != nil {
+		log.Printf("[SessionManager] Warning: failed to init tracing: %v", err)
+	} else if shutdown != nil {
+		defer shutdown(context.Background())
 	}
 
-	store, err := session.NewPostgresStore(pgConfig)
-	if err != nil {
-		log.Printf("[Session Manager] Warning: Failed to connect to PostgreSQL: %v", err)
-		log.Println("[Session Manager] Running without persistent storage (in-memory only)")
-		store = nil
+	// Initialize the session store. STORE_BACKEND selects "postgres"
+	// (default) or one of the embedded, file-based backends -
+	// "sqlite", "mysql", "bolt", "gdbm", "fs" - for single-binary
+	// deployments that don't want to stand up a database server.
+	// STORE_DSN overrides the embedded backends' default path.
+	storeBackend := os.Getenv("STORE_BACKEND")
+	if storeBackend == "" {
+		storeBackend = "postgres"
+	}
+
+	var store session.Store
+	if storeBackend == "postgres" {
+		pgConfig := session.DefaultPostgresConfig()
+		if host := os.Getenv("POSTGRES_HOST"); host != "" {
+			pgConfig.Host = host
+		}
+
+		pgStore, err := session.NewPostgresStore(pgConfig)
+		if err != nil {
+			log.Printf("[Session Manager] Warning: Failed to connect to PostgreSQL: %v", err)
+			log.Println("[Session Manager] Running without persistent storage")
+			store = nil
+		} else {
+			store = pgStore
+		}
+	} else {
+		dsn := os.Getenv("STORE_DSN")
+		embeddedStore, err := session.Open(storeBackend, dsn)
+		if err != nil {
+			log.Fatalf("[Session Manager] Failed to open %s store: %v", storeBackend, err)
+		}
+		store = embeddedStore
+		log.Printf("[Session Manager] Using %s store backend", storeBackend)
 	}
 
 	// Initialize Redis cache
@@ -49,36 +90,223 @@ func main() {
 		cache = redisCache
 	}
 
-	// Initialize MinIO storage
+	// Initialize workspace storage. WORKSPACE_BACKEND selects "minio"
+	// (default), a full-tar.gz snapshot per save, or "chunked", which
+	// splits the workspace into content-defined chunks and only uploads
+	// the ones this bucket doesn't already hold.
 	minioConfig := session.DefaultMinIOConfig()
 	if endpoint := os.Getenv("MINIO_ENDPOINT"); endpoint != "" {
 		minioConfig.Endpoint = endpoint
 	}
 
+	sessionMetrics := metrics.NewMetrics("session_manager")
+
+	workspaceBackend := os.Getenv("WORKSPACE_BACKEND")
+	if workspaceBackend == "" {
+		workspaceBackend = "minio"
+	}
+
 	var workspaceStorage session.WorkspaceStorage
-	minioStorage, err := session.NewMinIOStorage(minioConfig)
-	if err != nil {
-		log.Printf("[Session Manager] Warning: Failed to connect to MinIO: %v", err)
-		log.Println("[Session Manager] Running without workspace storage")
-		workspaceStorage = nil
-	} else {
-		workspaceStorage = minioStorage
+	var minioStorage *session.MinIOStorage
+	switch workspaceBackend {
+	case "chunked":
+		// ChunkedStorage keeps its manifests in Postgres regardless of
+		// which STORE_BACKEND the session store itself uses, the same way
+		// session-gc connects independently to run its sweep.
+		pgConfig := session.DefaultPostgresConfig()
+		if host := os.Getenv("POSTGRES_HOST"); host != "" {
+			pgConfig.Host = host
+		}
+		dsn := fmt.Sprintf(
+			"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			pgConfig.Host, pgConfig.Port, pgConfig.User, pgConfig.Password, pgConfig.DBName, pgConfig.SSLMode,
+		)
+		dbConn := &session.DBConnector{Config: session.DBConfig{Driver: "postgres", DSN: dsn}}
+		db, err := dbConn.GetDB(context.Background())
+		if err != nil {
+			log.Printf("[Session Manager] Warning: Failed to connect to manifest database: %v", err)
+			log.Println("[Session Manager] Running without workspace storage")
+			break
+		}
+
+		chunkedConfig := session.DefaultChunkedStorageConfig()
+		if endpoint := os.Getenv("MINIO_ENDPOINT"); endpoint != "" {
+			chunkedConfig.Endpoint = endpoint
+		}
+		chunkedConfig.DB = db
+		chunkedStorage, err := session.NewChunkedStorage(chunkedConfig)
+		if err != nil {
+			log.Printf("[Session Manager] Warning: Failed to connect to chunked workspace storage: %v", err)
+			log.Println("[Session Manager] Running without workspace storage")
+		} else {
+			chunkedStorage.AttachDedupObserver(workspaceDedupMetricsCallback{sessionMetrics})
+			workspaceStorage = chunkedStorage
+		}
+	default:
+		var err error
+		minioStorage, err = session.NewMinIOStorage(minioConfig)
+		if err != nil {
+			log.Printf("[Session Manager] Warning: Failed to connect to MinIO: %v", err)
+			log.Println("[Session Manager] Running without workspace storage")
+		} else {
+			minioStorage.AttachProgress(workspaceMetricsCallback{sessionMetrics})
+			workspaceStorage = minioStorage
+		}
 	}
 
-	// Use in-memory store if PostgreSQL is not available
-	var sessionStore session.Store
-	if store != nil {
-		sessionStore = store
-	} else {
-		sessionStore = NewInMemoryStore()
+	if store == nil {
+		log.Fatal("[Session Manager] No session store available")
 	}
 
 	// Create session manager
 	managerConfig := session.DefaultManagerConfig()
-	manager := session.NewManager(sessionStore, cache, workspaceStorage, managerConfig)
+	manager := session.NewManager(store, cache, workspaceStorage, managerConfig)
+
+	// Attach a distributed lock so Pause/Resume/BindSandbox/UnbindSandbox
+	// on the same session can't interleave across replicas of this
+	// process. Uses the same Redis deployment as the cache.
+	locker, err := session.NewRedisLocker(redisConfig)
+	if err != nil {
+		log.Printf("[Session Manager] Warning: Failed to connect to Redis for locking: %v", err)
+		log.Println("[Session Manager] Running without distributed session locking")
+	} else {
+		manager.AttachLocker(locker)
+	}
+
+	// Attach cross-region workspace replication. REPLICATION_SECONDARIES is
+	// a comma-separated "name=endpoint" list, replicated to in list order
+	// (lower index = higher priority); REPLICATION_ACCESS_KEY,
+	// REPLICATION_SECRET_KEY, and REPLICATION_BUCKET configure all of them.
+	var replicator *session.Replicator
+	if minioStorage != nil {
+		if secondaries := parseReplicationSecondaries(os.Getenv("REPLICATION_SECONDARIES")); len(secondaries) > 0 {
+			replConfig := session.DefaultReplicationConfig()
+			replConfig.Secondaries = secondaries
+			replicator, err = session.NewReplicator(minioStorage, store, replConfig)
+			if err != nil {
+				log.Printf("[Session Manager] Warning: Failed to set up workspace replication: %v", err)
+			} else {
+				manager.AttachReplicator(replicator)
+				log.Printf("[Session Manager] Replicating workspaces to %d secondaries", len(secondaries))
+			}
+		}
+	}
+
+	// Attach external lifecycle event sinks. WEBHOOK_URL and/or
+	// KAFKA_BROKERS opt into a WebhookPublisher/KafkaPublisher; both can be
+	// set at once, combined with a MultiPublisher. Neither is required -
+	// Watch and /events?since=N work off the always-on in-process fan-out
+	// regardless.
+	var kafkaPublisher *session.KafkaPublisher
+	var publishers []session.EventPublisher
+	if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		webhookConfig := session.DefaultWebhookConfig()
+		webhookConfig.URL = webhookURL
+		webhookConfig.Secret = os.Getenv("WEBHOOK_SECRET")
+		webhookConfig.AuthHeader = os.Getenv("WEBHOOK_AUTH_HEADER")
+		webhookConfig.AuthToken = os.Getenv("WEBHOOK_AUTH_TOKEN")
+
+		webhookPublisher, err := session.NewWebhookPublisher(webhookConfig)
+		if err != nil {
+			log.Printf("[Session Manager] Warning: Failed to set up webhook event sink: %v", err)
+		} else {
+			publishers = append(publishers, webhookPublisher)
+			log.Printf("[Session Manager] Publishing lifecycle events to webhook %s", webhookURL)
+		}
+	}
+	if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
+		kafkaConfig := session.DefaultKafkaConfig()
+		kafkaConfig.Brokers = strings.Split(brokers, ",")
+		if topic := os.Getenv("KAFKA_TOPIC"); topic != "" {
+			kafkaConfig.Topic = topic
+		}
+
+		var err error
+		kafkaPublisher, err = session.NewKafkaPublisher(kafkaConfig)
+		if err != nil {
+			log.Printf("[Session Manager] Warning: Failed to set up Kafka event sink: %v", err)
+		} else {
+			publishers = append(publishers, kafkaPublisher)
+			log.Printf("[Session Manager] Publishing lifecycle events to Kafka topic %s", kafkaConfig.Topic)
+		}
+	}
+	if len(publishers) > 0 {
+		manager.AttachEventPublisher(session.NewMultiPublisher(publishers...))
+	}
+
+	// Wire the cross-cutting events.Bus (sandbox/workspace/rate-limit
+	// lifecycle, distinct from the session-scoped EventPublisher above) into
+	// MinIOStorage. EVENT_BUS_WEBHOOK_URL/EVENT_BUS_KAFKA_BROKERS opt into
+	// their respective sinks, same as WEBHOOK_URL/KAFKA_BROKERS do for
+	// session events; EVENT_BUS_SPOOL_DIR wraps whichever sinks are
+	// configured in a Spool for at-least-once delivery across restarts.
+	// EVENT_BUS_FILTER, if set, restricts every sink to a matching subset.
+	var eventSpool *events.Spool
+	var kafkaEventSink *events.KafkaSink
+	if webhookURL := os.Getenv("EVENT_BUS_WEBHOOK_URL"); webhookURL != "" || os.Getenv("EVENT_BUS_KAFKA_BROKERS") != "" {
+		eventBus := events.NewBus()
+
+		var filter *events.Filter
+		if expr := os.Getenv("EVENT_BUS_FILTER"); expr != "" {
+			parsed, err := events.ParseFilter(expr)
+			if err != nil {
+				log.Printf("[Session Manager] Warning: ignoring invalid EVENT_BUS_FILTER: %v", err)
+			} else {
+				filter = parsed
+			}
+		}
+
+		if webhookURL != "" {
+			webhookConfig := events.DefaultWebhookConfig()
+			webhookConfig.URL = webhookURL
+			webhookConfig.Secret = os.Getenv("EVENT_BUS_WEBHOOK_SECRET")
+			webhookConfig.AuthHeader = os.Getenv("EVENT_BUS_WEBHOOK_AUTH_HEADER")
+			webhookConfig.AuthToken = os.Getenv("EVENT_BUS_WEBHOOK_AUTH_TOKEN")
+
+			webhookSink, err := events.NewWebhookSink(webhookConfig)
+			if err != nil {
+				log.Printf("[Session Manager] Warning: Failed to set up event bus webhook sink: %v", err)
+			} else {
+				var sink events.Sink = webhookSink
+				if spoolDir := os.Getenv("EVENT_BUS_SPOOL_DIR"); spoolDir != "" {
+					spool, err := events.NewSpool(sink, events.SpoolConfig{Dir: filepath.Join(spoolDir, "webhook")})
+					if err != nil {
+						log.Printf("[Session Manager] Warning: Failed to set up event spool for webhook sink: %v", err)
+					} else {
+						sink = spool
+						eventSpool = spool
+					}
+				}
+				eventBus.Subscribe(sink, filter)
+				log.Printf("[Session Manager] Publishing lifecycle events to webhook %s", webhookURL)
+			}
+		}
+
+		if brokers := os.Getenv("EVENT_BUS_KAFKA_BROKERS"); brokers != "" {
+			kafkaConfig := events.DefaultKafkaConfig()
+			kafkaConfig.Brokers = strings.Split(brokers, ",")
+			if topic := os.Getenv("EVENT_BUS_KAFKA_TOPIC"); topic != "" {
+				kafkaConfig.Topic = topic
+			}
+
+			sink, err := events.NewKafkaSink(kafkaConfig)
+			if err != nil {
+				log.Printf("[Session Manager] Warning: Failed to set up event bus Kafka sink: %v", err)
+			} else {
+				kafkaEventSink = sink
+				eventBus.Subscribe(sink, filter)
+				log.Printf("[Session Manager] Publishing lifecycle events to Kafka topic %s", kafkaConfig.Topic)
+			}
+		}
+
+		if minioStorage != nil {
+			minioStorage.AttachEventBus(eventBus)
+		}
+	}
 
 	server := &Server{
 		manager: manager,
+		svc:     grpcserver.New(manager),
 	}
 
 	// Create HTTP server
@@ -87,8 +315,17 @@ func main() {
 	// Health check
 	mux.HandleFunc("/health", server.handleHealth)
 
-	// Session management
-	mux.HandleFunc("/api/v1/sessions", server.handleSessions)
+	// Prometheus metrics, including workspace_save/restore_bytes_total and
+	// workspace_save/restore_duration_seconds from workspaceMetricsCallback.
+	mux.Handle("/metrics", metrics.Handler())
+
+	// Session management. List/create have no path-parameterized actions
+	// and no per-session auth, so they're served by the generated gateway
+	// directly; /api/v1/sessions/{id}... still goes through handleSession
+	// for bearer-token authorization and the actions session.proto doesn't
+	// cover yet (replication, snapshots, fork, events), delegating to the
+	// generated gateway for the ones it does.
+	pb.RegisterSessionServiceHandlerServer(mux, server.svc)
 	mux.HandleFunc("/api/v1/sessions/", server.handleSession)
 
 	httpServer := &http.Server{
@@ -106,6 +343,24 @@ func main() {
 		}
 	}()
 
+	// gRPC listens alongside the REST API above, sharing the same svc so
+	// the two surfaces can't drift: the gRPC-gateway-generated routes call
+	// svc in-process, and this registers the identical svc over the wire.
+	grpcAddr := ":9092"
+	grpcLis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", grpcAddr, err)
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterSessionServiceServer(grpcServer, server.svc)
+
+	go func() {
+		log.Printf("Session Manager gRPC API listening on %s", grpcAddr)
+		if err := grpcServer.Serve(grpcLis); err != nil && err != grpc.ErrServerStopped {
+			log.Fatalf("gRPC server error: %v", err)
+		}
+	}()
+
 	log.Println("Session Manager is running")
 
 	// Wait for shutdown signal
@@ -120,10 +375,11 @@ func main() {
 	defer cancel()
 
 	httpServer.Shutdown(ctx)
+	grpcServer.GracefulStop()
 	manager.Close()
 
-	if store != nil {
-		store.Close()
+	if closer, ok := store.(io.Closer); ok {
+		closer.Close()
 	}
 	if redisCache != nil {
 		redisCache.Close()
@@ -131,6 +387,18 @@ func main() {
 	if minioStorage != nil {
 		minioStorage.Close()
 	}
+	if replicator != nil {
+		replicator.Close()
+	}
+	if kafkaPublisher != nil {
+		kafkaPublisher.Close()
+	}
+	if eventSpool != nil {
+		eventSpool.Close()
+	}
+	if kafkaEventSink != nil {
+		kafkaEventSink.Close()
+	}
 
 	log.Println("Session Manager stopped")
 }
@@ -140,56 +408,6 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
-// handleSessions handles /api/v1/sessions (list/create)
-func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	switch r.Method {
-	case http.MethodGet:
-		// List sessions by user
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			http.Error(w, "user_id is required", http.StatusBadRequest)
-			return
-		}
-
-		sessions, err := s.manager.GetByUser(ctx, userID)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{"sessions": sessions})
-
-	case http.MethodPost:
-		// Create session
-		var req session.CreateSessionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		if req.UserID == "" {
-			http.Error(w, "user_id is required", http.StatusBadRequest)
-			return
-		}
-
-		sess, err := s.manager.Create(ctx, req)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(sess)
-
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
 // handleSession handles /api/v1/sessions/{id}
 func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -209,39 +427,111 @@ func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
 		action = parts[1]
 	}
 
+	if !s.authorizeSession(w, r, sessionID) {
+		return
+	}
+
 	switch action {
 	case "pause":
-		s.handlePause(ctx, w, r, sessionID)
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		pb.HandlePauseSession(w, r, s.svc, sessionID)
 	case "resume":
-		s.handleResume(ctx, w, r, sessionID)
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		pb.HandleResumeSession(w, r, s.svc, sessionID)
 	case "touch":
-		s.handleTouch(ctx, w, r, sessionID)
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		pb.HandleTouchSession(w, r, s.svc, sessionID)
 	case "bind":
-		s.handleBind(ctx, w, r, sessionID)
+		switch r.Method {
+		case http.MethodPost:
+			pb.HandleBindSandbox(w, r, s.svc, sessionID)
+		case http.MethodDelete:
+			pb.HandleUnbindSandbox(w, r, s.svc, sessionID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "restore":
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		pb.HandleRestoreWorkspace(w, r, s.svc, sessionID)
+	case "replication":
+		s.handleReplication(ctx, w, r, sessionID)
+	case "snapshots":
+		s.handleSnapshots(ctx, w, r, sessionID)
+	case "fork":
+		s.handleFork(ctx, w, r, sessionID)
+	case "events":
+		s.handleEvents(ctx, w, r, sessionID)
 	case "":
-		s.handleSessionCRUD(ctx, w, r, sessionID)
+		switch r.Method {
+		case http.MethodGet:
+			pb.HandleGetSession(w, r, s.svc, sessionID)
+		case http.MethodDelete:
+			pb.HandleDeleteSession(w, r, s.svc, sessionID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
 	default:
 		http.Error(w, "Unknown action", http.StatusBadRequest)
 	}
 }
 
-func (s *Server) handleSessionCRUD(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
+// authorizeSession validates the request's bearer token via
+// session.Manager.ValidateSession and confirms it grants access to the
+// session ID in the path, rather than trusting that ID on its own.
+func (s *Server) authorizeSession(w http.ResponseWriter, r *http.Request, sessionID string) bool {
+	token, err := bearerTokenFromRequest(r)
+	if err != nil {
+		http.Error(w, `{"error":"unauthorized","message":"missing bearer token"}`, http.StatusUnauthorized)
+		return false
+	}
+
+	sess, err := s.manager.ValidateSession(r.Context(), token)
+	if err != nil || sess.ID != sessionID {
+		http.Error(w, `{"error":"unauthorized","message":"invalid bearer token"}`, http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
+
+func bearerTokenFromRequest(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(authHeader) <= len(prefix) || authHeader[:len(prefix)] != prefix {
+		return "", fmt.Errorf("missing or malformed authorization header")
+	}
+	return authHeader[len(prefix):], nil
+}
+
+// handleReplication handles GET (replica status) and POST (manual retry)
+// on /api/v1/sessions/{id}/replication.
+func (s *Server) handleReplication(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
 	switch r.Method {
 	case http.MethodGet:
-		// Get session
-		sess, err := s.manager.Get(ctx, sessionID)
+		replicas, err := s.manager.ReplicationStatus(ctx, sessionID)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(sess)
+		json.NewEncoder(w).Encode(map[string]interface{}{"replicas": replicas})
 
-	case http.MethodDelete:
-		// Delete session
-		if err := s.manager.Delete(ctx, sessionID); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	case http.MethodPost:
+		if err := s.manager.RetryReplication(ctx, sessionID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
@@ -253,89 +543,195 @@ func (s *Server) handleSessionCRUD(ctx context.Context, w http.ResponseWriter, r
 	}
 }
 
-func (s *Server) handlePause(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// snapshotRequest is the POST /snapshots body: set Tag to label the
+// session's current workspace snapshot with a memorable name, or set
+// VersionID and SandboxID to restore a prior snapshot into a running
+// sandbox (promoting it without a full Pause/Resume cycle).
+type snapshotRequest struct {
+	Tag       string `json:"tag,omitempty"`
+	VersionID string `json:"version_id,omitempty"`
+	SandboxID string `json:"sandbox_id,omitempty"`
+}
 
-	if err := s.manager.Pause(ctx, sessionID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+// handleSnapshots handles /api/v1/sessions/{id}/snapshots: GET lists the
+// session's workspace version history, POST either tags the current
+// version or restores a prior one into a sandbox.
+func (s *Server) handleSnapshots(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
+	switch r.Method {
+	case http.MethodGet:
+		snapshots, err := s.manager.ListWorkspaceSnapshots(ctx, sessionID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-	sess, _ := s.manager.Get(ctx, sessionID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"snapshots": snapshots})
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sess)
+	case http.MethodPost:
+		var req snapshotRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case req.Tag != "":
+			if err := s.manager.TagWorkspace(ctx, sessionID, req.Tag); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case req.VersionID != "" && req.SandboxID != "":
+			if err := s.manager.RestoreWorkspaceSnapshot(ctx, sessionID, req.VersionID, req.SandboxID); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "either tag, or version_id and sandbox_id, are required", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
-func (s *Server) handleResume(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
+// handleFork handles POST /api/v1/sessions/{id}/fork, creating a new
+// session whose workspace starts as a server-side copy of this session's
+// current snapshot.
+func (s *Server) handleFork(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	sess, err := s.manager.Resume(ctx, sessionID)
+	var req session.CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	forked, err := s.manager.ForkSession(ctx, sessionID, req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sess)
+	json.NewEncoder(w).Encode(forked)
 }
 
-func (s *Server) handleTouch(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
-	if r.Method != http.MethodPost {
+// handleEvents handles GET /api/v1/sessions/{id}/events?since=N, returning
+// sessionID's retained lifecycle events with Seq > since so a subscriber
+// that reconnects after a gap can tell whether it missed anything.
+func (s *Server) handleEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if err := s.manager.Touch(ctx, sessionID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	var since int64
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+			return
+		}
+		since = parsed
 	}
 
+	events := s.manager.EventsSince(sessionID, since)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	json.NewEncoder(w).Encode(map[string]interface{}{"events": events})
 }
 
-type BindRequest struct {
-	SandboxID string `json:"sandbox_id"`
+// workspaceMetricsCallback adapts *metrics.Metrics to session.ProgressCallback,
+// so MinIOStorage's streaming Save/Restore can report throughput without the
+// session package depending on the metrics package's concrete types.
+type workspaceMetricsCallback struct {
+	metrics *metrics.Metrics
 }
 
-func (s *Server) handleBind(ctx context.Context, w http.ResponseWriter, r *http.Request, sessionID string) {
-	switch r.Method {
-	case http.MethodPost:
-		// Bind sandbox
-		var req BindRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
+func (w workspaceMetricsCallback) OnBytes(sessionID, op string, bytesTransferred int64) {
+	switch op {
+	case "save":
+		w.metrics.WorkspaceSaveBytesTotal.Add(float64(bytesTransferred))
+	case "restore":
+		w.metrics.WorkspaceRestoreBytesTotal.Add(float64(bytesTransferred))
+	}
+}
 
-		if err := s.manager.BindSandbox(ctx, sessionID, req.SandboxID); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+func (w workspaceMetricsCallback) OnComplete(sessionID, op string, d time.Duration, err error) {
+	if err != nil {
+		return
+	}
+	switch op {
+	case "save":
+		w.metrics.WorkspaceSaveDuration.Observe(d.Seconds())
+	case "restore":
+		w.metrics.WorkspaceRestoreDuration.Observe(d.Seconds())
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+// workspaceDedupMetricsCallback adapts *metrics.Metrics to
+// session.DedupObserver, so ChunkedStorage's dedup ratio and bytes-saved
+// can be tracked without the session package depending on metrics' concrete
+// types.
+type workspaceDedupMetricsCallback struct {
+	metrics *metrics.Metrics
+}
 
-	case http.MethodDelete:
-		// Unbind sandbox
-		if err := s.manager.UnbindSandbox(ctx, sessionID); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+func (w workspaceDedupMetricsCallback) OnSave(sessionID string, totalBytes, uploadedBytes int64) {
+	w.metrics.RecordWorkspaceDedup(totalBytes, uploadedBytes)
+}
+
+// parseReplicationSecondaries parses REPLICATION_SECONDARIES
+// ("name1=endpoint1,name2=endpoint2,...") into SecondaryConfigs, assigning
+// Priority by list position and sharing REPLICATION_ACCESS_KEY,
+// REPLICATION_SECRET_KEY, REPLICATION_BUCKET, and REPLICATION_USE_SSL
+// across all of them.
+func parseReplicationSecondaries(raw string) []session.SecondaryConfig {
+	if raw == "" {
+		return nil
+	}
+
+	accessKey := os.Getenv("REPLICATION_ACCESS_KEY")
+	secretKey := os.Getenv("REPLICATION_SECRET_KEY")
+	bucket := os.Getenv("REPLICATION_BUCKET")
+	if bucket == "" {
+		bucket = "sandbox-workspaces"
+	}
+	useSSL, _ := strconv.ParseBool(os.Getenv("REPLICATION_USE_SSL"))
+
+	var secondaries []session.SecondaryConfig
+	for i, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+		name, endpoint, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("[Session Manager] Warning: ignoring malformed REPLICATION_SECONDARIES entry %q", entry)
+			continue
+		}
 
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		secondaries = append(secondaries, session.SecondaryConfig{
+			Name:      name,
+			Priority:  i,
+			Endpoint:  endpoint,
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+			Bucket:    bucket,
+			UseSSL:    useSSL,
+		})
 	}
+
+	return secondaries
 }
 
 func splitPath(path string) []string {
@@ -366,70 +762,3 @@ func split(s string, sep rune) []string {
 	}
 	return parts
 }
-
-// InMemoryStore is a simple in-memory implementation of Store for development
-type InMemoryStore struct {
-	sessions map[string]*session.Session
-}
-
-func NewInMemoryStore() *InMemoryStore {
-	return &InMemoryStore{
-		sessions: make(map[string]*session.Session),
-	}
-}
-
-func (s *InMemoryStore) Create(ctx context.Context, sess *session.Session) error {
-	s.sessions[sess.ID] = sess
-	return nil
-}
-
-func (s *InMemoryStore) Get(ctx context.Context, id string) (*session.Session, error) {
-	sess, ok := s.sessions[id]
-	if !ok {
-		return nil, nil
-	}
-	return sess, nil
-}
-
-func (s *InMemoryStore) GetByUser(ctx context.Context, userID string) ([]*session.Session, error) {
-	var result []*session.Session
-	for _, sess := range s.sessions {
-		if sess.UserID == userID {
-			result = append(result, sess)
-		}
-	}
-	return result, nil
-}
-
-func (s *InMemoryStore) Update(ctx context.Context, sess *session.Session) error {
-	s.sessions[sess.ID] = sess
-	return nil
-}
-
-func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
-	delete(s.sessions, id)
-	return nil
-}
-
-func (s *InMemoryStore) ListExpired(ctx context.Context) ([]*session.Session, error) {
-	var result []*session.Session
-	now := time.Now()
-	for _, sess := range s.sessions {
-		if now.After(sess.ExpiresAt) {
-			result = append(result, sess)
-		}
-	}
-	return result, nil
-}
-
-func (s *InMemoryStore) DeleteExpired(ctx context.Context) (int, error) {
-	now := time.Now()
-	count := 0
-	for id, sess := range s.sessions {
-		if now.After(sess.ExpiresAt) {
-			delete(s.sessions, id)
-			count++
-		}
-	}
-	return count, nil
-}
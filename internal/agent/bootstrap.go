@@ -0,0 +1,119 @@
+// Package agent holds types shared between the sandbox agent's server and
+// client sides: the CA that mints each side's mutual-TLS certificate (see
+// ca.go) and the one-time bootstrap token that gates the first RPC on a
+// freshly dialed connection.
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrTokenUsed is returned when a bootstrap token has already been redeemed.
+var ErrTokenUsed = errors.New("agent: bootstrap token already used")
+
+// BootstrapTokenMetadataKey is the gRPC metadata key a client sends its
+// BootstrapToken.Token under (see agentclient.Config.BootstrapToken and
+// TokenGate).
+const BootstrapTokenMetadataKey = "x-bootstrap-token"
+
+// BootstrapToken is the single-use credential the scheduler mints for a
+// newly created sandbox (NewDockerRuntime's CertAuthority.IssueCert mints
+// the matching mTLS certificate alongside it) and hands to it out-of-band
+// via the runtime's environment mechanism. mTLS already authenticates the
+// channel on its own; the token is defense in depth against a leaked copy
+// of the sandbox's launch environment being used to open an independent
+// session once the legitimate scheduler connection already redeemed it -
+// see TokenGate, which enforces the "once" part.
+type BootstrapToken struct {
+	SandboxID string
+	Token     string
+}
+
+// NewBootstrapToken generates a random single-use token for a sandbox.
+func NewBootstrapToken(sandboxID string) (BootstrapToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return BootstrapToken{}, err
+	}
+	return BootstrapToken{SandboxID: sandboxID, Token: hex.EncodeToString(buf)}, nil
+}
+
+// TokenGate is a gRPC server interceptor enforcing that a newly started
+// agent's connection presents its bootstrap token exactly once before
+// serving any RPC. Once redeemed, the token cannot be redeemed again for
+// the rest of the agent process's lifetime (a fresh sandbox always gets a
+// fresh agent and a fresh gate), but every later call must still present
+// the same token: the scheduler's agentclient.Client attaches it to every
+// call on the connection it dials once and reuses for every subsequent
+// Exec/FileRead/FileWrite.
+type TokenGate struct {
+	token string
+
+	mu   sync.Mutex
+	used bool
+}
+
+// NewTokenGate builds a gate enforcing token. An empty token makes every
+// call pass through unchecked, for agents started without a bootstrap
+// token configured (mTLS-only, or local dev).
+func NewTokenGate(token string) *TokenGate {
+	return &TokenGate{token: token}
+}
+
+func (g *TokenGate) authorize(ctx context.Context) error {
+	if g.token == "" {
+		return nil
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	values := md.Get(BootstrapTokenMetadataKey)
+	presented := ok && len(values) == 1 && values[0] == g.token
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case g.used && presented:
+		// The connection that already redeemed the token keeps sending it
+		// on every call (see agentclient's interceptors); that's fine, it's
+		// the same scheduler that opened the session.
+		return nil
+	case g.used:
+		// A call arrived without the token (or with a stale/wrong one)
+		// after it was already redeemed once - reject rather than silently
+		// trusting mTLS alone to have caught an impersonator.
+		return status.Error(codes.Unauthenticated, ErrTokenUsed.Error())
+	case presented:
+		g.used = true
+		return nil
+	default:
+		return status.Error(codes.Unauthenticated, "missing or invalid bootstrap token")
+	}
+}
+
+// UnaryServerInterceptor enforces the bootstrap token on unary RPCs
+// (Signal, FileStat, FileList, ...).
+func (g *TokenGate) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := g.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor enforces the bootstrap token on streaming RPCs
+// (Exec, FileRead, FileWrite, Watch, Metrics).
+func (g *TokenGate) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
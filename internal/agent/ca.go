@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// leafCertTTL is how long an issued server/client certificate is valid.
+// Short-lived on purpose: a sandbox's agent cert only needs to outlive the
+// sandbox itself, which Pool's IdleTimeout bounds to well under a day.
+const leafCertTTL = 24 * time.Hour
+
+// CertAuthority is the in-memory CA the scheduler uses to mint the
+// server certificate each sandbox agent TLS-terminates with and the client
+// certificate the scheduler itself dials with, so the agent's Exec/
+// FileRead/FileWrite control plane authenticates both directions instead
+// of running on insecure.NewCredentials(). One CertAuthority is generated
+// per scheduler process (see NewDockerRuntime) and reused for every
+// sandbox it creates.
+type CertAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// NewCertAuthority generates a fresh self-signed root CA.
+func NewCertAuthority() (*CertAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "cloud-sandbox-agent-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to self-sign CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	return &CertAuthority{cert: cert, key: key}, nil
+}
+
+// CertPEM returns the CA's own certificate, PEM-encoded, for distribution
+// to the agent (as its ClientCAs pool) and the scheduler (as its RootCAs
+// pool) alongside the leaf certs IssueCert mints.
+func (ca *CertAuthority) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+// IssueCert mints a leaf certificate for commonName - a sandbox ID for the
+// agent's server cert, or "scheduler" for the client cert the scheduler
+// dials every agent with - signed by ca. Both certPEM and keyPEM are
+// PEM-encoded.
+func (ca *CertAuthority) IssueCert(commonName string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate leaf key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(leafCertTTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to issue leaf certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal leaf key: %w", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// ClientTLSConfig builds the tls.Config the scheduler dials an agent with:
+// its own client certificate (certPEM/keyPEM, signed by ca so the agent's
+// ClientCAs pool accepts it) as well as ca itself as the RootCAs trusting
+// the agent's server certificate, whose CommonName/DNSNames must match
+// serverName (the sandbox ID IssueCert minted the server cert for).
+func (ca *CertAuthority) ClientTLSConfig(certPEM, keyPEM []byte, serverName string) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client keypair: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+	}, nil
+}
+
+// ServerTLSConfig builds the tls.Config the agent listens with: its own
+// server certificate (certPEM/keyPEM) plus caCertPEM as the ClientCAs pool,
+// requiring and verifying the scheduler's client certificate so only a
+// caller holding a cert this same CA issued can open an Exec/FileRead/
+// FileWrite channel.
+func ServerTLSConfig(certPEM, keyPEM, caCertPEM []byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server keypair: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCertPEM) {
+		return nil, errors.New("failed to parse CA certificate")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate serial: %w", err)
+	}
+	return serial, nil
+}
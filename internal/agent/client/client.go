@@ -0,0 +1,239 @@
+// Package client is the Go client library the scheduler uses to talk to the
+// in-VM sandbox agent over mutually-authenticated gRPC.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent/pb"
+)
+
+// Config holds connection configuration for dialing a sandbox's agent.
+type Config struct {
+	// Addr is host:port of the agent's gRPC listener.
+	Addr string
+
+	// TLSConfig, when set, enables mutual TLS using the scheduler's client
+	// certificate (see sandbox.DockerRuntime's CertAuthority). Nil means
+	// insecure (only acceptable for local dev over a loopback/VPC address).
+	TLSConfig *tls.Config
+
+	// BootstrapToken, when set, is attached to every outgoing call's
+	// metadata so the agent's agent.TokenGate can redeem it on this
+	// connection's first RPC. It has to keep being sent afterward -
+	// TokenGate rejects any later call that lacks it.
+	BootstrapToken string
+}
+
+// Client wraps a gRPC connection to a single sandbox's agent.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  pb.AgentClient
+}
+
+// Dial connects to a sandbox's agent.
+func Dial(ctx context.Context, config Config) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if config.TLSConfig != nil {
+		creds = credentials.NewTLS(config.TLSConfig)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if config.BootstrapToken != "" {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(bootstrapTokenUnaryInterceptor(config.BootstrapToken)),
+			grpc.WithChainStreamInterceptor(bootstrapTokenStreamInterceptor(config.BootstrapToken)),
+		)
+	}
+
+	conn, err := grpc.NewClient(config.Addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial agent at %s: %w", config.Addr, err)
+	}
+
+	return &Client{conn: conn, rpc: pb.NewAgentClient(conn)}, nil
+}
+
+// bootstrapTokenUnaryInterceptor attaches token to every outgoing unary
+// call's metadata under agent.BootstrapTokenMetadataKey.
+func bootstrapTokenUnaryInterceptor(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, agent.BootstrapTokenMetadataKey, token)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// bootstrapTokenStreamInterceptor is bootstrapTokenUnaryInterceptor's
+// streaming-call counterpart (Exec, FileRead, FileWrite, Watch, Metrics).
+func bootstrapTokenStreamInterceptor(token string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, agent.BootstrapTokenMetadataKey, token)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ExecResult is the aggregated result of a non-interactive Exec call.
+type ExecResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int32
+	TimedOut bool
+	Error    string
+}
+
+// Exec runs a command to completion, collecting all output. For
+// interactive/streaming use cases, callers should use ExecStream directly.
+func (c *Client) Exec(ctx context.Context, command []string, workDir string, env map[string]string, timeoutSeconds int64) (*ExecResult, error) {
+	stream, err := c.rpc.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.Send(&pb.ExecRequest{Start: &pb.ExecStart{
+		Command:        command,
+		WorkDir:        workDir,
+		Env:            env,
+		TimeoutSeconds: timeoutSeconds,
+	}}); err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&pb.ExecRequest{CloseStdin: true}); err != nil {
+		return nil, err
+	}
+
+	result := &ExecResult{}
+	for {
+		frame, err := stream.Recv()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(frame.StdoutChunk) > 0 {
+			result.Stdout = append(result.Stdout, frame.StdoutChunk...)
+		}
+		if len(frame.StderrChunk) > 0 {
+			result.Stderr = append(result.Stderr, frame.StderrChunk...)
+		}
+		if frame.Exit != nil {
+			result.ExitCode = frame.Exit.ExitCode
+			result.TimedOut = frame.Exit.TimedOut
+			result.Error = frame.Exit.Error
+			return result, nil
+		}
+	}
+}
+
+// ExecStream opens a raw bidirectional exec stream for interactive use.
+func (c *Client) ExecStream(ctx context.Context) (pb.Agent_ExecClient, error) {
+	return c.rpc.Exec(ctx)
+}
+
+// Signal delivers a signal to a running exec session.
+func (c *Client) Signal(ctx context.Context, execID string, signal int32) (bool, error) {
+	resp, err := c.rpc.Signal(ctx, &pb.SignalRequest{ExecID: execID, Signal: signal})
+	if err != nil {
+		return false, err
+	}
+	return resp.Delivered, nil
+}
+
+// ReadFile reads an entire file from the sandbox.
+func (c *Client) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	stream, err := c.rpc.FileRead(ctx, &pb.FileReadRequest{Path: path})
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF || (chunk != nil && chunk.EOF) {
+			return content, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, chunk.Data...)
+	}
+}
+
+// WriteFile writes content to a file on the sandbox.
+func (c *Client) WriteFile(ctx context.Context, path string, content []byte) error {
+	stream, err := c.rpc.FileWrite(ctx)
+	if err != nil {
+		return err
+	}
+
+	const chunkSize = 256 * 1024
+	for i := 0; i < len(content); i += chunkSize {
+		end := min(i+chunkSize, len(content))
+		if err := stream.Send(&pb.FileChunk{Path: path, Data: content[i:end]}); err != nil {
+			return err
+		}
+	}
+
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Stat returns metadata for a path.
+func (c *Client) Stat(ctx context.Context, path string) (*pb.FileInfo, error) {
+	return c.rpc.FileStat(ctx, &pb.FileStatRequest{Path: path})
+}
+
+// ListFiles lists a directory's entries.
+func (c *Client) ListFiles(ctx context.Context, path string) ([]*pb.FileInfo, error) {
+	resp, err := c.rpc.FileList(ctx, &pb.FileListRequest{Path: path})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+// DeleteFile removes a path via a small exec call, mirroring the old
+// shell-based delete since the agent protocol has no dedicated RPC for it.
+func (c *Client) DeleteFile(ctx context.Context, path string) error {
+	result, err := c.Exec(ctx, []string{"rm", "-rf", path}, "", nil, 30)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("failed to delete %s: %s", path, result.Stderr)
+	}
+	return nil
+}
+
+// Watch subscribes to filesystem change events under a path.
+func (c *Client) Watch(ctx context.Context, path string, recursive bool) (pb.Agent_WatchClient, error) {
+	return c.rpc.Watch(ctx, &pb.WatchRequest{Path: path, Recursive: recursive})
+}
+
+// Metrics subscribes to periodic resource usage samples.
+func (c *Client) Metrics(ctx context.Context, intervalSeconds int64) (pb.Agent_MetricsClient, error) {
+	return c.rpc.Metrics(ctx, &pb.MetricsRequest{IntervalSeconds: intervalSeconds})
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
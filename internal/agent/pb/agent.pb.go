@@ -0,0 +1,115 @@
+// Package pb contains the Go types generated from proto/agent/v1/agent.proto
+// by buf generate. Do not edit by hand; regenerate with `buf generate`.
+package pb
+
+import "time"
+
+// ExecRequest is a single frame sent from client to server on the Exec stream.
+type ExecRequest struct {
+	Start      *ExecStart
+	StdinChunk []byte
+	CloseStdin bool
+}
+
+// ExecStart begins a new exec session.
+type ExecStart struct {
+	Command        []string
+	WorkDir        string
+	Env            map[string]string
+	TimeoutSeconds int64
+}
+
+// ExecFrame is a single frame sent from server to client on the Exec stream.
+type ExecFrame struct {
+	StdoutChunk []byte
+	StderrChunk []byte
+	Exit        *ExecExit
+}
+
+// ExecExit is the terminal frame of an Exec stream.
+type ExecExit struct {
+	ExitCode int32
+	TimedOut bool
+	Error    string
+}
+
+// SignalRequest asks the agent to deliver a signal to a running exec session.
+type SignalRequest struct {
+	ExecID string
+	Signal int32
+}
+
+// SignalResponse reports whether the signal was delivered.
+type SignalResponse struct {
+	Delivered bool
+}
+
+// FileReadRequest requests the contents of a file.
+type FileReadRequest struct {
+	Path string
+}
+
+// FileWriteResponse reports the result of a FileWrite stream.
+type FileWriteResponse struct {
+	BytesWritten int64
+}
+
+// FileChunk is one chunk of a file transferred in either direction.
+type FileChunk struct {
+	Path string
+	Data []byte
+	EOF  bool
+}
+
+// FileStatRequest requests metadata for a single path.
+type FileStatRequest struct {
+	Path string
+}
+
+// FileInfo describes a single file or directory entry.
+type FileInfo struct {
+	Name    string
+	Path    string
+	Size    int64
+	IsDir   bool
+	Mode    uint32
+	ModTime time.Time
+}
+
+// FileListRequest requests the entries of a directory.
+type FileListRequest struct {
+	Path string
+}
+
+// FileListResponse is the response to FileList.
+type FileListResponse struct {
+	Entries []*FileInfo
+}
+
+// WatchRequest subscribes to filesystem change events under a path.
+type WatchRequest struct {
+	Path      string
+	Recursive bool
+}
+
+// WatchEvent is a single filesystem change notification.
+type WatchEvent struct {
+	Path string
+	Op   string
+	Time time.Time
+}
+
+// MetricsRequest starts a periodic resource-usage stream.
+type MetricsRequest struct {
+	IntervalSeconds int64
+}
+
+// MetricsSample is a single cgroup resource usage sample.
+type MetricsSample struct {
+	CPUUsagePercent  float64
+	MemoryUsageBytes int64
+	MemoryLimitBytes int64
+	IOReadBytes      int64
+	IOWriteBytes     int64
+	SampledAt        time.Time
+}
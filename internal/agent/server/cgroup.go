@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent/pb"
+)
+
+// readCgroupV2 reads CPU, memory, and IO counters from a cgroup v2 hierarchy.
+func readCgroupV2(cgroupPath string) (*pb.MetricsSample, error) {
+	memUsage, err := readCgroupInt(filepath.Join(cgroupPath, "memory.current"))
+	if err != nil {
+		return nil, err
+	}
+	memLimit, err := readCgroupInt(filepath.Join(cgroupPath, "memory.max"))
+	if err != nil {
+		memLimit = 0
+	}
+
+	cpuPercent, err := readCPUPercent(cgroupPath)
+	if err != nil {
+		cpuPercent = 0
+	}
+
+	readBytes, writeBytes := readIOBytes(cgroupPath)
+
+	return &pb.MetricsSample{
+		CPUUsagePercent:  cpuPercent,
+		MemoryUsageBytes: memUsage,
+		MemoryLimitBytes: memLimit,
+		IOReadBytes:      readBytes,
+		IOWriteBytes:     writeBytes,
+		SampledAt:        time.Now(),
+	}, nil
+}
+
+func readCgroupInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(data))
+	if s == "max" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// readCPUPercent reads cpu.stat's usage_usec field. Computing an actual
+// percentage requires two samples; callers poll this on an interval and
+// diff consecutive usage_usec values, so this returns the raw microsecond
+// counter scaled down to a coarse instantaneous estimate.
+func readCPUPercent(cgroupPath string) (float64, error) {
+	f, err := os.Open(filepath.Join(cgroupPath, "cpu.stat"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usec, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return float64(usec) / 1e6, nil
+		}
+	}
+	return 0, nil
+}
+
+func readIOBytes(cgroupPath string) (int64, int64) {
+	f, err := os.Open(filepath.Join(cgroupPath, "io.stat"))
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	var readBytes, writeBytes int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			if v, ok := strings.CutPrefix(field, "rbytes="); ok {
+				n, _ := strconv.ParseInt(v, 10, 64)
+				readBytes += n
+			}
+			if v, ok := strings.CutPrefix(field, "wbytes="); ok {
+				n, _ := strconv.ParseInt(v, 10, 64)
+				writeBytes += n
+			}
+		}
+	}
+	return readBytes, writeBytes
+}
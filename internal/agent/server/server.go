@@ -0,0 +1,379 @@
+// Package server implements the in-VM sandbox agent: the gRPC service that
+// runs inside every sandbox and gives the scheduler a real exec/file/watch/
+// metrics control plane instead of shelling out via `docker exec`.
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent/pb"
+)
+
+// Config holds agent server configuration.
+type Config struct {
+	// WorkDir is the default working directory for new exec sessions.
+	WorkDir string
+
+	// CgroupPath is the cgroup v2 path to read resource samples from.
+	CgroupPath string
+}
+
+// Server implements pb.AgentServer inside the sandbox.
+type Server struct {
+	pb.UnimplementedAgentServer
+
+	config Config
+
+	mu    sync.Mutex
+	execs map[string]*exec.Cmd
+}
+
+// New creates a new agent server.
+func New(config Config) *Server {
+	if config.WorkDir == "" {
+		config.WorkDir = "/workspace"
+	}
+	if config.CgroupPath == "" {
+		config.CgroupPath = "/sys/fs/cgroup"
+	}
+	return &Server{
+		config: config,
+		execs:  make(map[string]*exec.Cmd),
+	}
+}
+
+// Exec handles a bidirectional exec session: the first client frame must be
+// an ExecStart, after which stdin chunks are streamed in and
+// stdout/stderr/exit frames are streamed out.
+func (s *Server) Exec(stream pb.Agent_ExecServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if first.Start == nil {
+		return fmt.Errorf("exec: first frame must be ExecStart")
+	}
+	start := first.Start
+
+	workDir := start.WorkDir
+	if workDir == "" {
+		workDir = s.config.WorkDir
+	}
+
+	ctx := stream.Context()
+	if start.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(start.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, start.Command[0], start.Command[1:]...)
+	cmd.Dir = workDir
+	for k, v := range start.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	stdinPipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return stream.Send(&pb.ExecFrame{Exit: &pb.ExecExit{ExitCode: -1, Error: err.Error()}})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go streamOutput(&wg, stdoutPipe, func(b []byte) error {
+		return stream.Send(&pb.ExecFrame{StdoutChunk: b})
+	})
+	go streamOutput(&wg, stderrPipe, func(b []byte) error {
+		return stream.Send(&pb.ExecFrame{StderrChunk: b})
+	})
+
+	// Forward subsequent client frames to stdin until it is closed.
+	go func() {
+		defer stdinPipe.Close()
+		for {
+			frame, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			if frame.CloseStdin {
+				return
+			}
+			if len(frame.StdinChunk) > 0 {
+				if _, err := stdinPipe.Write(frame.StdinChunk); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+	waitErr := cmd.Wait()
+
+	exit := &pb.ExecExit{}
+	if ctx.Err() == context.DeadlineExceeded {
+		exit.TimedOut = true
+	}
+	if waitErr != nil {
+		if exitErr, ok := waitErr.(*exec.ExitError); ok {
+			exit.ExitCode = int32(exitErr.ExitCode())
+		} else {
+			exit.ExitCode = -1
+			exit.Error = waitErr.Error()
+		}
+	}
+
+	return stream.Send(&pb.ExecFrame{Exit: exit})
+}
+
+func streamOutput(wg *sync.WaitGroup, r io.Reader, send func([]byte) error) {
+	defer wg.Done()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			if sendErr := send(chunk); sendErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Signal delivers a Unix signal to a running exec session.
+func (s *Server) Signal(ctx context.Context, req *pb.SignalRequest) (*pb.SignalResponse, error) {
+	s.mu.Lock()
+	cmd, ok := s.execs[req.ExecID]
+	s.mu.Unlock()
+	if !ok || cmd.Process == nil {
+		return &pb.SignalResponse{Delivered: false}, nil
+	}
+	if err := cmd.Process.Signal(syscall.Signal(req.Signal)); err != nil {
+		return nil, err
+	}
+	return &pb.SignalResponse{Delivered: true}, nil
+}
+
+// FileRead streams a file's contents in chunks.
+func (s *Server) FileRead(req *pb.FileReadRequest, stream pb.Agent_FileReadServer) error {
+	f, err := os.Open(req.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 256*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			if sendErr := stream.Send(&pb.FileChunk{Path: req.Path, Data: chunk}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return stream.Send(&pb.FileChunk{Path: req.Path, EOF: true})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// FileWrite receives a file's contents in chunks and writes it to disk.
+func (s *Server) FileWrite(stream pb.Agent_FileWriteServer) error {
+	var f *os.File
+	var written int64
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			if f != nil {
+				f.Close()
+			}
+			return stream.SendAndClose(&pb.FileWriteResponse{BytesWritten: written})
+		}
+		if err != nil {
+			if f != nil {
+				f.Close()
+			}
+			return err
+		}
+
+		if f == nil {
+			if err := os.MkdirAll(filepath.Dir(chunk.Path), 0755); err != nil {
+				return err
+			}
+			f, err = os.Create(chunk.Path)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(chunk.Data) > 0 {
+			n, err := f.Write(chunk.Data)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			written += int64(n)
+		}
+
+		if chunk.EOF {
+			f.Close()
+			return stream.SendAndClose(&pb.FileWriteResponse{BytesWritten: written})
+		}
+	}
+}
+
+// FileStat returns metadata for a single path.
+func (s *Server) FileStat(ctx context.Context, req *pb.FileStatRequest) (*pb.FileInfo, error) {
+	info, err := os.Stat(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.FileInfo{
+		Name:    info.Name(),
+		Path:    req.Path,
+		Size:    info.Size(),
+		IsDir:   info.IsDir(),
+		Mode:    uint32(info.Mode()),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// FileList lists a directory's entries using os.ReadDir, replacing the old
+// `ls -la` output scraping that ran over docker exec.
+func (s *Server) FileList(ctx context.Context, req *pb.FileListRequest) (*pb.FileListResponse, error) {
+	entries, err := os.ReadDir(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.FileListResponse{Entries: make([]*pb.FileInfo, 0, len(entries))}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		resp.Entries = append(resp.Entries, &pb.FileInfo{
+			Name:    e.Name(),
+			Path:    filepath.Join(req.Path, e.Name()),
+			Size:    info.Size(),
+			IsDir:   e.IsDir(),
+			Mode:    uint32(info.Mode()),
+			ModTime: info.ModTime(),
+		})
+	}
+	return resp, nil
+}
+
+// Watch streams fsnotify events for a path until the client disconnects.
+func (s *Server) Watch(req *pb.WatchRequest, stream pb.Agent_WatchServer) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := addWatchPaths(watcher, req.Path, req.Recursive); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&pb.WatchEvent{
+				Path: event.Name,
+				Op:   event.Op.String(),
+				Time: time.Now(),
+			}); err != nil {
+				return err
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func addWatchPaths(watcher *fsnotify.Watcher, root string, recursive bool) error {
+	if !recursive {
+		return watcher.Add(root)
+	}
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// Metrics streams periodic cgroup CPU/memory/IO samples until the client
+// disconnects. A non-positive requested interval falls back to 5 seconds.
+func (s *Server) Metrics(req *pb.MetricsRequest, stream pb.Agent_MetricsServer) error {
+	interval := time.Duration(req.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := stream.Context()
+	for {
+		sample, err := s.readCgroupSample()
+		if err == nil {
+			if sendErr := stream.Send(sample); sendErr != nil {
+				return sendErr
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) readCgroupSample() (*pb.MetricsSample, error) {
+	return readCgroupV2(s.config.CgroupPath)
+}
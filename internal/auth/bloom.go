@@ -0,0 +1,38 @@
+package auth
+
+import "hash/fnv"
+
+// bloomFilter is a small fixed-size bloom filter used to keep the common
+// case of RevocationSet.IsRevoked cheap: a miss here is authoritative
+// ("definitely not revoked") and never touches Redis. A hit may be a
+// false positive, so callers must confirm it against the real set.
+type bloomFilter struct {
+	bits   []bool
+	hashes int
+}
+
+func newBloomFilter(size, hashes int) *bloomFilter {
+	return &bloomFilter{bits: make([]bool, size), hashes: hashes}
+}
+
+func (b *bloomFilter) add(data []byte) {
+	for i := 0; i < b.hashes; i++ {
+		b.bits[b.index(data, i)] = true
+	}
+}
+
+func (b *bloomFilter) mightContain(data []byte) bool {
+	for i := 0; i < b.hashes; i++ {
+		if !b.bits[b.index(data, i)] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomFilter) index(data []byte, seed int) int {
+	h := fnv.New64a()
+	h.Write(data)
+	h.Write([]byte{byte(seed)})
+	return int(h.Sum64() % uint64(len(b.bits)))
+}
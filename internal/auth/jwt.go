@@ -1,15 +1,32 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// signingMethodFor maps an asymmetric Algorithm name to its jwt.SigningMethod.
+func signingMethodFor(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case "ES256":
+		return jwt.SigningMethodES256
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodRS256
+	}
+}
+
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token expired")
@@ -25,9 +42,15 @@ type Claims struct {
 
 // JWTAuth handles JWT authentication
 type JWTAuth struct {
+	algorithm     string // "HS256", "RS256", "ES256", or "EdDSA"
 	secretKey     []byte
+	keys          *KeySet
 	tokenExpiry   time.Duration
 	refreshExpiry time.Duration
+
+	// revocation, when attached via AttachRevocationSet, makes Middleware
+	// reject tokens whose jti has been revoked.
+	revocation *RevocationSet
 }
 
 // Config holds JWT configuration
@@ -35,6 +58,22 @@ type Config struct {
 	SecretKey     string
 	TokenExpiry   time.Duration
 	RefreshExpiry time.Duration
+
+	// Algorithm selects the signing method: "HS256" (default, shares
+	// SecretKey with every verifier) or one of the asymmetric methods
+	// "RS256", "ES256", "EdDSA" (verifiers fetch public keys from the
+	// gateway's JWKS endpoint instead).
+	Algorithm  string
+	RSAKeyBits int // defaults to 2048 when Algorithm is "RS256" and no PrivateKeyPath is set
+
+	// PrivateKeyPath and PublicKeysDir load an operator-managed key set
+	// from disk instead of generating an ephemeral one at startup, for
+	// any asymmetric Algorithm. PrivateKeyPath is a PKCS8 PEM file;
+	// PublicKeysDir holds additional PKIX PEM public keys (e.g. from a
+	// previous deployment) that ValidateToken should still accept. Both
+	// are ignored when Algorithm is "HS256".
+	PrivateKeyPath string
+	PublicKeysDir  string
 }
 
 // DefaultConfig returns default JWT configuration
@@ -43,25 +82,99 @@ func DefaultConfig() Config {
 		SecretKey:     "your-secret-key-change-in-production",
 		TokenExpiry:   24 * time.Hour,
 		RefreshExpiry: 7 * 24 * time.Hour,
+		Algorithm:     "HS256",
 	}
 }
 
 // NewJWTAuth creates a new JWT authenticator
 func NewJWTAuth(config Config) *JWTAuth {
-	return &JWTAuth{
+	a := &JWTAuth{
+		algorithm:     config.Algorithm,
 		secretKey:     []byte(config.SecretKey),
 		tokenExpiry:   config.TokenExpiry,
 		refreshExpiry: config.RefreshExpiry,
 	}
+	if a.algorithm == "" {
+		a.algorithm = "HS256"
+	}
+
+	if a.algorithm != "HS256" {
+		var keys *KeySet
+		var err error
+
+		if config.PrivateKeyPath != "" {
+			keys, err = LoadKeySetFromFiles(config.PrivateKeyPath, config.PublicKeysDir)
+		} else {
+			keys, err = NewKeySet(a.algorithm, config.RSAKeyBits)
+		}
+		if err != nil {
+			// Key generation only fails if the system's entropy source is
+			// broken (which every other part of the process depends on
+			// too) or an operator-supplied key file is bad, which is also
+			// unrecoverable at startup; there's nothing safer to fall
+			// back to.
+			panic(fmt.Sprintf("auth: failed to initialize signing key: %v", err))
+		}
+		a.keys = keys
+	}
+
+	return a
+}
+
+// AttachRevocationSet wires a distributed revocation list into Middleware
+// so it rejects any token whose jti has been revoked, and enables
+// RevokeToken. Safe to call at most once; a nil set (the zero value) is a
+// no-op, matching JWTAuth's behavior before this was introduced.
+func (a *JWTAuth) AttachRevocationSet(revocation *RevocationSet) {
+	a.revocation = revocation
+}
+
+// RevokeToken revokes a token by jti before its natural expiry, making
+// Middleware reject it on every subsequent request even though
+// ValidateToken would otherwise still accept it. Requires
+// AttachRevocationSet to have been called first.
+func (a *JWTAuth) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if a.revocation == nil {
+		return fmt.Errorf("auth: no revocation set attached")
+	}
+	return a.revocation.Revoke(ctx, jti, ttl)
+}
+
+// Keys returns the asymmetric signing key set, or nil in HS256 mode.
+func (a *JWTAuth) Keys() *KeySet {
+	return a.keys
+}
+
+// JWKS renders the current public keys as a JWK set. It is empty in
+// HS256 mode, since there's no public key to publish.
+func (a *JWTAuth) JWKS() []JWK {
+	if a.keys == nil {
+		return nil
+	}
+	return a.keys.JWKS()
+}
+
+func newJTI() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 // GenerateToken generates a new JWT token
 func (a *JWTAuth) GenerateToken(userID, role string) (string, error) {
 	now := time.Now()
+	jti, err := newJTI()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
 	claims := Claims{
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -70,27 +183,38 @@ func (a *JWTAuth) GenerateToken(userID, role string) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(a.secretKey)
-}
-
-// GenerateRefreshToken generates a new refresh token
-func (a *JWTAuth) GenerateRefreshToken(userID string) (string, error) {
-	now := time.Now()
-	claims := jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(now.Add(a.refreshExpiry)),
-		IssuedAt:  jwt.NewNumericDate(now),
-		Subject:   userID,
-		Issuer:    "cloud-sandbox-refresh",
+	if a.algorithm != "HS256" {
+		key := a.keys.Current()
+		token := jwt.NewWithClaims(signingMethodFor(a.algorithm), claims)
+		token.Header["kid"] = key.KID
+		return token.SignedString(key.PrivateKey)
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(a.secretKey)
 }
 
+// RefreshExpiry returns the configured refresh-token lifetime, so callers
+// building a RefreshStore can size its TTL to match.
+func (a *JWTAuth) RefreshExpiry() time.Duration {
+	return a.refreshExpiry
+}
+
 // ValidateToken validates a JWT token and returns claims
 func (a *JWTAuth) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if a.algorithm != "HS256" {
+			if token.Method.Alg() != a.algorithm {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			kid, _ := token.Header["kid"].(string)
+			key := a.keys.Find(kid)
+			if key == nil {
+				return nil, fmt.Errorf("unknown signing key: %s", kid)
+			}
+			return key, nil
+		}
+
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
@@ -127,8 +251,14 @@ func ExtractTokenFromRequest(r *http.Request) (string, error) {
 	return parts[1], nil
 }
 
-// Middleware returns an HTTP middleware for JWT authentication
+// Middleware returns an HTTP middleware for JWT authentication. next runs
+// behind otelhttp, so the span it starts picks up trace context
+// propagated from the caller (or starts a new trace if there is none),
+// keeping this service's spans in the same trace as whatever called it
+// and whatever it calls in turn.
 func (a *JWTAuth) Middleware(next http.Handler) http.Handler {
+	instrumented := otelhttp.NewHandler(next, "auth.JWTAuth.Middleware")
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token, err := ExtractTokenFromRequest(r)
 		if err != nil {
@@ -146,9 +276,18 @@ func (a *JWTAuth) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if a.revocation != nil {
+			if revoked, err := a.revocation.IsRevoked(r.Context(), claims.ID); err != nil {
+				log.Printf("[Auth] revocation check failed: %v", err)
+			} else if revoked {
+				http.Error(w, `{"error":"unauthorized","message":"token revoked"}`, http.StatusUnauthorized)
+				return
+			}
+		}
+
 		// Add claims to request context
 		ctx := SetClaimsContext(r.Context(), claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		instrumented.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyPair is one versioned asymmetric signing key. PrivateKey is nil for
+// a verify-only key loaded from PublicKeysDir (keys other gateway
+// instances or a previous deployment hold the private half of).
+type KeyPair struct {
+	KID        string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	CreatedAt  time.Time
+}
+
+// KeySet holds the signing key currently in use plus however many
+// previous keys are still needed to validate tokens signed before the
+// last rotation. Downstream services verify against whichever key a
+// token's "kid" header names, so rotation never invalidates tokens that
+// haven't expired yet.
+type KeySet struct {
+	mu        sync.RWMutex
+	algorithm string // "RS256", "ES256", or "EdDSA"
+	bits      int    // RSA key size; unused for ES256/EdDSA
+	current   *KeyPair
+	previous  []*KeyPair
+}
+
+// NewKeySet generates the initial signing key for algorithm ("RS256",
+// "ES256", or "EdDSA"). bits only applies to RS256.
+func NewKeySet(algorithm string, bits int) (*KeySet, error) {
+	kp, err := generateKeyPair(algorithm, bits)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{algorithm: algorithm, bits: bits, current: kp}, nil
+}
+
+// LoadKeySetFromFiles builds a KeySet from an operator-managed private
+// key plus a directory of public keys, instead of generating an ephemeral
+// key at startup. This is what lets a deployment pin signing keys across
+// restarts and share verify-only public keys with services that should
+// never see a private key. Every file is PEM-encoded; the private key is
+// PKCS8, public keys are PKIX. Each public key's kid is its file name
+// without the .pem extension; the private key's kid is "current".
+func LoadKeySetFromFiles(privateKeyPath, publicKeysDir string) (*KeySet, error) {
+	signer, err := loadPrivateKey(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load private key: %w", err)
+	}
+
+	algorithm, err := algorithmForKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	current := &KeyPair{
+		KID:        "current",
+		PrivateKey: signer,
+		PublicKey:  signer.Public(),
+		CreatedAt:  time.Now(),
+	}
+
+	ks := &KeySet{algorithm: algorithm, current: current}
+
+	if publicKeysDir != "" {
+		previous, err := loadPublicKeys(publicKeysDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load public keys: %w", err)
+		}
+		ks.previous = previous
+	}
+
+	return ks, nil
+}
+
+func loadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS8 private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("key in %s is not a signing key", path)
+	}
+	return signer, nil
+}
+
+func loadPublicKeys(dir string) ([]*KeyPair, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*KeyPair, 0, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM block found in %s", path)
+		}
+
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key in %s: %w", path, err)
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		keys = append(keys, &KeyPair{KID: kid, PublicKey: pub, CreatedAt: time.Now()})
+	}
+	return keys, nil
+}
+
+func algorithmForKey(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		return "ES256", nil
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func generateKeyPair(algorithm string, bits int) (*KeyPair, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch algorithm {
+	case "ES256":
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ECDSA key: %w", err)
+		}
+	case "EdDSA":
+		_, priv, genErr := ed25519.GenerateKey(rand.Reader)
+		if genErr != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", genErr)
+		}
+		signer = priv
+	default:
+		if bits == 0 {
+			bits = 2048
+		}
+		signer, err = rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+	}
+
+	return &KeyPair{
+		KID:        fmt.Sprintf("%d", time.Now().UnixNano()),
+		PrivateKey: signer,
+		PublicKey:  signer.Public(),
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// Current returns the key new tokens should be signed with.
+func (ks *KeySet) Current() *KeyPair {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.current
+}
+
+// Find returns the public key for kid, or nil if it isn't the current key
+// or one of the retained previous keys.
+func (ks *KeySet) Find(kid string) crypto.PublicKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.current.KID == kid {
+		return ks.current.PublicKey
+	}
+	for _, kp := range ks.previous {
+		if kp.KID == kid {
+			return kp.PublicKey
+		}
+	}
+	return nil
+}
+
+// Rotate generates a new signing key and demotes the current one to
+// "previous", trimming the retained list to maxPrevious entries. Keys
+// loaded via LoadKeySetFromFiles are operator-managed, not rotated
+// automatically: StartRotation is only meant to run against a KeySet
+// built with NewKeySet.
+func (ks *KeySet) Rotate(maxPrevious int) error {
+	kp, err := generateKeyPair(ks.algorithm, ks.bits)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.previous = append([]*KeyPair{ks.current}, ks.previous...)
+	if len(ks.previous) > maxPrevious {
+		ks.previous = ks.previous[:maxPrevious]
+	}
+	ks.current = kp
+	return nil
+}
+
+// StartRotation rotates the key set on a timer until ctx is cancelled.
+// Run it in its own goroutine: go keys.StartRotation(ctx, interval, 2).
+func (ks *KeySet) StartRotation(ctx context.Context, interval time.Duration, maxPrevious int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Rotate(maxPrevious); err != nil {
+				log.Printf("[Auth] key rotation failed: %v", err)
+			}
+		}
+	}
+}
+
+// JWK is a single RFC 7517 JSON Web Key. Fields only meaningful for one
+// key type (N/E for RSA, Crv/X/Y for EC, Crv/X for OKP/Ed25519) are left
+// empty on the others.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS renders the current and previous public keys as an RFC 7517 JWK
+// Set, so the scheduler and session manager can verify gateway-issued
+// tokens without ever seeing a private key or shared secret.
+func (ks *KeySet) JWKS() []JWK {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keys := make([]JWK, 0, 1+len(ks.previous))
+	keys = append(keys, jwkFromKeyPair(ks.current))
+	for _, kp := range ks.previous {
+		keys = append(keys, jwkFromKeyPair(kp))
+	}
+	return keys
+}
+
+func jwkFromKeyPair(kp *KeyPair) JWK {
+	base := JWK{Use: "sig", Kid: kp.KID}
+
+	switch pub := kp.PublicKey.(type) {
+	case *rsa.PublicKey:
+		base.Kty = "RSA"
+		base.Alg = "RS256"
+		base.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		base.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		base.Kty = "EC"
+		base.Alg = "ES256"
+		base.Crv = "P-256"
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		base.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		base.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		base.Kty = "OKP"
+		base.Alg = "EdDSA"
+		base.Crv = "Ed25519"
+		base.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return base
+}
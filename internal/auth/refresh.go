@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrRefreshTokenRevoked is returned when a refresh token's family has
+	// been revoked, either explicitly or because of a detected replay.
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	// ErrRefreshTokenReused is returned when a refresh token is redeemed a
+	// second time. Since each token is one-time-use, this can only happen
+	// if it was stolen; the whole family is revoked in response.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+const refreshKeyPrefix = "refresh:"
+
+// refreshRecord is what a RedisRefreshStore stores per outstanding token.
+type refreshRecord struct {
+	UserID   string `json:"user_id"`
+	FamilyID string `json:"family_id"`
+	Used     bool   `json:"used"`
+}
+
+// RefreshStore tracks refresh-token families. A family is the chain of
+// tokens produced by repeatedly rotating one original login; redeeming
+// any token in the chain a second time means it was copied, so the whole
+// family is revoked rather than just the one token.
+type RefreshStore interface {
+	// NewFamily starts a fresh token family for userID and returns its
+	// first refresh token.
+	NewFamily(ctx context.Context, userID string) (token string, err error)
+	// Rotate redeems token and returns the next token in its family. If
+	// token has already been redeemed once, the family is revoked and
+	// ErrRefreshTokenReused is returned.
+	Rotate(ctx context.Context, token string) (userID, next string, err error)
+	// Revoke invalidates every token in token's family.
+	Revoke(ctx context.Context, token string) error
+}
+
+// RedisRefreshStore implements RefreshStore in Redis, keyed by token with
+// a TTL matching the refresh token's own expiry so abandoned families
+// clean themselves up.
+type RedisRefreshStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisRefreshStore creates a RedisRefreshStore. ttl should match the
+// JWTAuth refresh-token expiry it is paired with.
+func NewRedisRefreshStore(client *redis.Client, ttl time.Duration) *RedisRefreshStore {
+	return &RedisRefreshStore{client: client, ttl: ttl}
+}
+
+func refreshTokenKey(token string) string {
+	return refreshKeyPrefix + token
+}
+
+func familyRevokedKey(familyID string) string {
+	return refreshKeyPrefix + "family:" + familyID + ":revoked"
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// NewFamily starts a fresh token family for userID.
+func (s *RedisRefreshStore) NewFamily(ctx context.Context, userID string) (string, error) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh family id: %w", err)
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	if err := s.put(ctx, token, refreshRecord{UserID: userID, FamilyID: familyID}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// rotateScript redeems a token and advances it to the next one in its
+// family atomically, so two concurrent redemptions of the same stolen
+// token can't both observe Used==false: Redis runs the whole GET-check-SET
+// as a single step, serializing them. The second caller deterministically
+// sees used=true and revokes the family instead of racing past the check.
+//
+// KEYS[1] = the token's own key
+// KEYS[2] = the next token's key
+// ARGV[1] = TTL in seconds
+var rotateScript = redis.NewScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return {'not_found'}
+end
+local record = cjson.decode(data)
+local familyKey = 'refresh:family:' .. record.family_id .. ':revoked'
+if redis.call('EXISTS', familyKey) == 1 then
+	return {'revoked'}
+end
+if record.used then
+	redis.call('SET', familyKey, '1', 'EX', ARGV[1])
+	return {'reused'}
+end
+record.used = true
+redis.call('SET', KEYS[1], cjson.encode(record), 'EX', ARGV[1])
+local nextRecord = cjson.encode({user_id = record.user_id, family_id = record.family_id, used = false})
+redis.call('SET', KEYS[2], nextRecord, 'EX', ARGV[1])
+return {'ok', record.user_id}
+`)
+
+// Rotate redeems token for the next one in its family.
+func (s *RedisRefreshStore) Rotate(ctx context.Context, token string) (string, string, error) {
+	next, err := randomHex(32)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	res, err := rotateScript.Run(ctx, s.client,
+		[]string{refreshTokenKey(token), refreshTokenKey(next)},
+		int64(s.ttl/time.Second),
+	).Slice()
+	if err != nil {
+		return "", "", err
+	}
+
+	switch res[0] {
+	case "not_found":
+		return "", "", ErrInvalidToken
+	case "revoked":
+		return "", "", ErrRefreshTokenRevoked
+	case "reused":
+		// This token was already redeemed once before: someone is
+		// replaying a stale refresh token, so the whole family is
+		// compromised.
+		return "", "", ErrRefreshTokenReused
+	case "ok":
+		userID, _ := res[1].(string)
+		return userID, next, nil
+	default:
+		return "", "", fmt.Errorf("unexpected rotate script result: %v", res)
+	}
+}
+
+// Revoke invalidates token's entire family.
+func (s *RedisRefreshStore) Revoke(ctx context.Context, token string) error {
+	record, err := s.get(ctx, token)
+	if err != nil {
+		return err
+	}
+	return s.revokeFamily(ctx, record.FamilyID)
+}
+
+func (s *RedisRefreshStore) revokeFamily(ctx context.Context, familyID string) error {
+	return s.client.Set(ctx, familyRevokedKey(familyID), "1", s.ttl).Err()
+}
+
+func (s *RedisRefreshStore) put(ctx context.Context, token string, record refreshRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh record: %w", err)
+	}
+	return s.client.Set(ctx, refreshTokenKey(token), data, s.ttl).Err()
+}
+
+func (s *RedisRefreshStore) get(ctx context.Context, token string) (refreshRecord, error) {
+	data, err := s.client.Get(ctx, refreshTokenKey(token)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return refreshRecord{}, ErrInvalidToken
+		}
+		return refreshRecord{}, err
+	}
+	var record refreshRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return refreshRecord{}, fmt.Errorf("failed to unmarshal refresh record: %w", err)
+	}
+	return record, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const revokedKeyPrefix = "revoked-jti:"
+
+// RevocationSet tracks revoked access-token IDs (the JWT "jti" claim).
+// Gateway.authenticate calls IsRevoked on every request, so the check is
+// fronted by an in-memory bloom filter: most tokens were never revoked,
+// and the filter answers that case without a Redis round trip.
+type RevocationSet struct {
+	client *redis.Client
+	mu     sync.Mutex
+	bloom  *bloomFilter
+}
+
+// NewRevocationSet creates a RevocationSet backed by client. Call Warm
+// once at startup (and periodically thereafter) so the bloom filter
+// reflects tokens revoked before this process started or by another
+// gateway instance.
+func NewRevocationSet(client *redis.Client) *RevocationSet {
+	return &RevocationSet{
+		client: client,
+		bloom:  newBloomFilter(1<<16, 4),
+	}
+}
+
+func revocationKey(jti string) string {
+	return revokedKeyPrefix + jti
+}
+
+// Revoke marks jti as revoked until ttl elapses. ttl should be the
+// token's remaining lifetime: there's no reason to remember a jti past
+// the point its own expiry would have rejected it anyway.
+func (r *RevocationSet) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	if err := r.client.Set(ctx, revocationKey(jti), "1", ttl).Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.bloom.add([]byte(jti))
+	r.mu.Unlock()
+	return nil
+}
+
+// IsRevoked reports whether jti has been revoked.
+func (r *RevocationSet) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	maybe := r.bloom.mightContain([]byte(jti))
+	r.mu.Unlock()
+	if !maybe {
+		return false, nil
+	}
+
+	n, err := r.client.Exists(ctx, revocationKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Warm rebuilds the bloom filter from every revocation currently in
+// Redis. Run it at startup and on an interval so a restarted or
+// newly-added gateway instance learns about revocations it didn't
+// personally record.
+func (r *RevocationSet) Warm(ctx context.Context) error {
+	fresh := newBloomFilter(1<<16, 4)
+
+	iter := r.client.Scan(ctx, 0, revokedKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		jti := strings.TrimPrefix(iter.Val(), revokedKeyPrefix)
+		fresh.add([]byte(jti))
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.bloom = fresh
+	r.mu.Unlock()
+	return nil
+}
+
+// StartResync calls Warm on an interval until ctx is cancelled. Failures
+// are logged, not fatal: the existing bloom filter (possibly stale) is
+// left in place and retried on the next tick.
+func (r *RevocationSet) StartResync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Warm(ctx); err != nil {
+				log.Printf("[Auth] revocation resync failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package egress implements the allowlist-based egress filter sandboxes
+// are routed through when sandbox.Config.AllowedHosts is set: a Policy
+// decides which destination host/port pairs a sandbox may reach, and
+// Proxy is the HTTP(S) forward proxy that enforces it. cmd/egress-proxy
+// wires both into a standalone binary DockerRuntime runs as a per-runtime
+// sidecar container; see sandbox/egress.go for how it's launched and
+// wired to sandboxes via HTTP_PROXY/HTTPS_PROXY.
+package egress
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultPorts is the destination port allowlist a Policy falls back to
+// when none is configured: the two ports an HTTP(S) forward proxy can
+// actually terminate.
+var DefaultPorts = []int{80, 443}
+
+// Policy decides whether a sandbox may connect to a given destination
+// host and port. Host entries are matched in the order documented on
+// sandbox.Config.AllowedHosts: exact hostname, leading-wildcard pattern,
+// or CIDR (matched against the resolved destination IP).
+type Policy struct {
+	exactHosts    map[string]bool
+	wildcardHosts []string // each entry's "*." prefix already stripped, so "*.example.com" is stored as "example.com"
+	cidrs         []*net.IPNet
+	ports         map[int]bool
+}
+
+// NewPolicy builds a Policy from sandbox.Config's AllowedHosts/
+// AllowedPorts values. An empty ports slice falls back to DefaultPorts.
+func NewPolicy(hosts []string, ports []int) (*Policy, error) {
+	p := &Policy{
+		exactHosts: make(map[string]bool),
+		ports:      make(map[int]bool),
+	}
+
+	for _, h := range hosts {
+		switch {
+		case strings.Contains(h, "/"):
+			_, ipnet, err := net.ParseCIDR(h)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR entry %q: %w", h, err)
+			}
+			p.cidrs = append(p.cidrs, ipnet)
+		case strings.HasPrefix(h, "*."):
+			p.wildcardHosts = append(p.wildcardHosts, strings.TrimPrefix(h, "*."))
+		default:
+			p.exactHosts[strings.ToLower(h)] = true
+		}
+	}
+
+	if len(ports) == 0 {
+		ports = DefaultPorts
+	}
+	for _, port := range ports {
+		p.ports[port] = true
+	}
+
+	return p, nil
+}
+
+// AllowsPort reports whether port is in the policy's port allowlist.
+func (p *Policy) AllowsPort(port int) bool {
+	return p.ports[port]
+}
+
+// AllowsHost reports whether host (a bare hostname or IP, no port) may be
+// connected to. ips, if non-empty, are host's resolved addresses and are
+// checked against the policy's CIDR entries in addition to host's own
+// exact/wildcard match.
+func (p *Policy) AllowsHost(host string, ips ...net.IP) bool {
+	lower := strings.ToLower(host)
+
+	if p.exactHosts[lower] {
+		return true
+	}
+	for _, suffix := range p.wildcardHosts {
+		if lower == suffix || strings.HasSuffix(lower, "."+suffix) {
+			return true
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	}
+	for _, ip := range ips {
+		for _, ipnet := range p.cidrs {
+			if ipnet.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsEmpty reports whether the policy has no host rules at all, meaning
+// "allow nothing" rather than "allow everything" - callers building an
+// egress proxy around an empty Policy should refuse to start, since a
+// proxy with nothing allowed is a misconfiguration rather than an open
+// one.
+func (p *Policy) IsEmpty() bool {
+	return len(p.exactHosts) == 0 && len(p.wildcardHosts) == 0 && len(p.cidrs) == 0
+}
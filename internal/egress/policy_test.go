@@ -0,0 +1,64 @@
+package egress
+
+import "testing"
+
+func TestPolicy_AllowsHost(t *testing.T) {
+	p, err := NewPolicy([]string{
+		"api.github.com",
+		"*.githubusercontent.com",
+		"10.0.0.0/8",
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+
+	cases := []struct {
+		host string
+		want bool
+	}{
+		{"api.github.com", true},
+		{"API.GitHub.com", true},
+		{"raw.githubusercontent.com", true},
+		{"githubusercontent.com", true},
+		{"evil.com", false},
+		{"10.1.2.3", true},
+		{"192.168.1.1", false},
+	}
+
+	for _, c := range cases {
+		if got := p.AllowsHost(c.host); got != c.want {
+			t.Errorf("AllowsHost(%q) = %v, want %v", c.host, got, c.want)
+		}
+	}
+}
+
+func TestPolicy_AllowsPort(t *testing.T) {
+	p, err := NewPolicy([]string{"example.com"}, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+
+	if !p.AllowsPort(80) || !p.AllowsPort(443) {
+		t.Error("expected default ports 80 and 443 to be allowed")
+	}
+	if p.AllowsPort(22) {
+		t.Error("expected port 22 to be blocked under the default allowlist")
+	}
+
+	custom, err := NewPolicy([]string{"example.com"}, []int{8080})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+	if !custom.AllowsPort(8080) {
+		t.Error("expected custom port 8080 to be allowed")
+	}
+	if custom.AllowsPort(443) {
+		t.Error("expected port 443 to be blocked once a custom port list is set")
+	}
+}
+
+func TestNewPolicy_InvalidCIDR(t *testing.T) {
+	if _, err := NewPolicy([]string{"10.0.0.0/abc"}, nil); err == nil {
+		t.Error("expected an error for a malformed CIDR entry")
+	}
+}
@@ -0,0 +1,186 @@
+package egress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Proxy is an HTTP(S) forward proxy that only permits connections
+// Policy.AllowsHost/AllowsPort approve: plain HTTP requests are
+// round-tripped directly, and HTTPS is tunneled via CONNECT so the proxy
+// never has to terminate TLS to enforce the allowlist. Every blocked
+// attempt is logged as a single structured JSON line so an agent (or its
+// operator) can see which host tripped the filter.
+type Proxy struct {
+	Policy *Policy
+
+	// DialTimeout bounds how long Proxy waits to establish the outbound
+	// connection (both for CONNECT tunnels and plain HTTP). Zero means
+	// 10 seconds.
+	DialTimeout time.Duration
+}
+
+// NewProxy builds a Proxy enforcing policy.
+func NewProxy(policy *Policy) *Proxy {
+	return &Proxy{Policy: policy}
+}
+
+// blockedLog is the structured line Proxy logs to stdout for every
+// connection it refuses, so the sandbox's operator (or the agent itself,
+// tailing the sidecar's logs) can see exactly what was blocked and why.
+type blockedLog struct {
+	Time   string `json:"time"`
+	Event  string `json:"event"`
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	Reason string `json:"reason"`
+}
+
+func logBlocked(host string, port int, reason string) {
+	entry := blockedLog{
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Event:  "egress.blocked",
+		Host:   host,
+		Port:   port,
+		Reason: reason,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[EgressProxy] failed to marshal block log: %v", err)
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(b))
+}
+
+// ServeHTTP implements the forward proxy: CONNECT requests become a raw
+// TCP tunnel once the destination clears the policy, everything else is
+// round-tripped as a normal (absolute-URI) HTTP request.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.handleConnect(w, r)
+		return
+	}
+	p.handleHTTP(w, r)
+}
+
+func (p *Proxy) dialTimeout() time.Duration {
+	if p.DialTimeout > 0 {
+		return p.DialTimeout
+	}
+	return 10 * time.Second
+}
+
+// checkDestination splits host:port apart and verifies it against
+// Policy, logging and writing status on rejection. It returns the bare
+// host, the port, and whether the caller may proceed.
+func (p *Proxy) checkDestination(w http.ResponseWriter, hostport string, statusOnBlock int) (host string, port int, ok bool) {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		http.Error(w, "invalid destination", http.StatusBadRequest)
+		return "", 0, false
+	}
+	port, err = strconv.Atoi(portStr)
+	if err != nil {
+		http.Error(w, "invalid destination port", http.StatusBadRequest)
+		return "", 0, false
+	}
+
+	if !p.Policy.AllowsPort(port) {
+		logBlocked(host, port, "port not in allowlist")
+		http.Error(w, "egress blocked: port not allowed", statusOnBlock)
+		return "", 0, false
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip == nil {
+		if addrs, err := net.LookupIP(host); err == nil {
+			ips = addrs
+		}
+	}
+	if !p.Policy.AllowsHost(host, ips...) {
+		logBlocked(host, port, "host not in allowlist")
+		http.Error(w, "egress blocked: host not allowed", statusOnBlock)
+		return "", 0, false
+	}
+
+	return host, port, true
+}
+
+// handleConnect tunnels HTTPS (or any raw TCP) traffic once the
+// destination clears the policy, by dialing it and splicing the two
+// connections together.
+func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	host, port, ok := p.checkDestination(w, r.Host, http.StatusForbidden)
+	if !ok {
+		return
+	}
+
+	dest, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), p.dialTimeout())
+	if err != nil {
+		http.Error(w, "failed to reach destination", http.StatusBadGateway)
+		return
+	}
+	defer dest.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, "failed to hijack connection", http.StatusInternalServerError)
+		return
+	}
+	defer client.Close()
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() { io.Copy(dest, client); done <- struct{}{} }()
+	go func() { io.Copy(client, dest); done <- struct{}{} }()
+	<-done
+}
+
+// handleHTTP round-trips a plain HTTP request once its destination
+// clears the policy.
+func (p *Proxy) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	port := r.URL.Port()
+	if port == "" {
+		port = "80"
+	}
+
+	_, _, ok := p.checkDestination(w, net.JoinHostPort(host, port), http.StatusForbidden)
+	if !ok {
+		return
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.RequestURI = ""
+
+	client := &http.Client{Timeout: p.dialTimeout() * 3}
+	resp, err := client.Do(outReq)
+	if err != nil {
+		http.Error(w, "failed to reach destination", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
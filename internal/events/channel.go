@@ -0,0 +1,34 @@
+package events
+
+import "context"
+
+// ChannelSink delivers events to an in-process Go channel, for tests and
+// in-process subscribers that don't need a network hop.
+type ChannelSink struct {
+	ch chan Event
+}
+
+// NewChannelSink creates a ChannelSink buffering up to capacity events
+// before Publish starts blocking the caller.
+func NewChannelSink(capacity int) *ChannelSink {
+	if capacity <= 0 {
+		capacity = 16
+	}
+	return &ChannelSink{ch: make(chan Event, capacity)}
+}
+
+// Publish sends event on the channel, blocking until there's room or ctx
+// is done.
+func (c *ChannelSink) Publish(ctx context.Context, event Event) error {
+	select {
+	case c.ch <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Events returns the channel events are delivered on.
+func (c *ChannelSink) Events() <-chan Event {
+	return c.ch
+}
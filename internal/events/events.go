@@ -0,0 +1,144 @@
+// Package events is the cross-cutting lifecycle event bus for Cloud
+// Sandbox: sandbox runtime, workspace storage, and rate-limiting all
+// publish through it rather than each owning their own notification
+// mechanism, mirroring MinIO's bucket-notification subsystem - any
+// component can subscribe a webhook, Kafka topic, or in-process channel to
+// a filtered slice of events without the publishers knowing sinks exist.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Type identifies a lifecycle event.
+type Type string
+
+const (
+	TypeSandboxCreated    Type = "sandbox.created"
+	TypeSandboxAcquired   Type = "sandbox.acquired"
+	TypeSandboxReleased   Type = "sandbox.released"
+	TypeSandboxDestroyed  Type = "sandbox.destroyed"
+	TypeSandboxOOM        Type = "sandbox.oom"
+	TypeSandboxTimeout    Type = "sandbox.timeout"
+	TypeExecStarted       Type = "exec.started"
+	TypeExecCompleted     Type = "exec.completed"
+	TypeFileWritten       Type = "file.written"
+	TypeWorkspaceSaved    Type = "workspace.saved"
+	TypeWorkspaceRestored Type = "workspace.restored"
+	TypeWorkspaceDeleted  Type = "workspace.deleted"
+	TypeRatelimitExceeded Type = "ratelimit.exceeded"
+)
+
+// Event is a single structured lifecycle notification. Data carries
+// type-specific detail (e.g. exit code for exec.completed, bytes for
+// workspace.saved) so the common fields stay fixed across every Type.
+type Event struct {
+	Type       Type                   `json:"type"`
+	UserID     string                 `json:"user_id,omitempty"`
+	SandboxID  string                 `json:"sandbox_id,omitempty"`
+	SessionID  string                 `json:"session_id,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sink delivers events to some destination: an HTTP webhook, a Kafka
+// topic, an in-process channel for tests. Publish should return promptly;
+// the network sinks (WebhookSink, KafkaSink) make one delivery attempt
+// each, and retrying comes from wrapping them in a Spool.
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// subscription pairs a Sink with the Filter gating which events reach it.
+type subscription struct {
+	sink   Sink
+	filter *Filter
+}
+
+// Bus fans events out to every subscribed Sink whose Filter matches,
+// continuing past an individual sink's failure so one broken subscriber
+// (a webhook endpoint that's down) never drops delivery to the others.
+// Sinks are called one after another, so a slow sink does delay the ones
+// after it.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers sink to receive every event matching filter. A nil
+// filter matches everything. Returns an unsubscribe func.
+func (b *Bus) Subscribe(sink Sink, filter *Filter) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := subscription{sink: sink, filter: filter}
+	b.subs = append(b.subs, sub)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.sink == sub.sink && s.filter == sub.filter {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Publish delivers event to every subscription whose filter matches. Each
+// sink is called synchronously and in order; a publisher that needs
+// fire-and-forget semantics should call Publish from a goroutine itself
+// (as the sandbox/workspace/rate-limit integration points do).
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	subs := make([]subscription, len(b.subs))
+	copy(subs, b.subs)
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.filter != nil && !sub.filter.Matches(event) {
+			continue
+		}
+		if err := sub.sink.Publish(ctx, event); err != nil {
+			log.Printf("[Events] Sink delivery failed for %s: %v", event.Type, err)
+		}
+	}
+}
+
+// PublishAsync is Publish run on its own goroutine, for call sites that
+// must not block their own critical path (sandbox Create/Destroy,
+// MinIOStorage Save/Restore, the rate-limit middleware) on a slow sink.
+func (b *Bus) PublishAsync(event Event) {
+	go b.Publish(context.Background(), event)
+}
+
+// eventField looks up one of Event's addressable fields by the dotted name
+// the filter DSL uses (event.type, user.id, sandbox.id, session.id).
+func eventField(event Event, name string) (string, error) {
+	switch name {
+	case "event.type":
+		return string(event.Type), nil
+	case "user.id":
+		return event.UserID, nil
+	case "sandbox.id":
+		return event.SandboxID, nil
+	case "session.id":
+		return event.SessionID, nil
+	default:
+		return "", fmt.Errorf("unknown filter field %q", name)
+	}
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Filter is a parsed filter expression of the form
+//
+//	event.type = "workspace.saved" AND user.id = "u-123"
+//
+// ANDed equality clauses over Event's dotted field names (event.type,
+// user.id, sandbox.id, session.id). This is deliberately small - just
+// enough for an operator to scope a subscription - rather than a general
+// query language.
+type Filter struct {
+	clauses []clause
+}
+
+type clause struct {
+	field string
+	value string
+}
+
+// ParseFilter parses expr into a Filter. An empty expr matches every
+// event.
+func ParseFilter(expr string) (*Filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return &Filter{}, nil
+	}
+
+	parts := strings.Split(expr, " AND ")
+	clauses := make([]clause, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		eq := strings.Index(part, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid filter clause %q: missing '='", part)
+		}
+
+		field := strings.TrimSpace(part[:eq])
+		value, err := unquote(strings.TrimSpace(part[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter clause %q: %w", part, err)
+		}
+
+		if _, err := eventField(Event{}, field); err != nil {
+			return nil, err
+		}
+
+		clauses = append(clauses, clause{field: field, value: value})
+	}
+
+	return &Filter{clauses: clauses}, nil
+}
+
+// unquote strips a double-quoted string literal's surrounding quotes.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string, got %s", strconv.Quote(s))
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// Matches reports whether event satisfies every clause in f.
+func (f *Filter) Matches(event Event) bool {
+	if f == nil {
+		return true
+	}
+	for _, c := range f.clauses {
+		value, err := eventField(event, c.field)
+		if err != nil || value != c.value {
+			return false
+		}
+	}
+	return true
+}
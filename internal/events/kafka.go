@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures KafkaSink.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+}
+
+// DefaultKafkaConfig returns default Kafka configuration. Brokers is left
+// empty; callers must set it.
+func DefaultKafkaConfig() KafkaConfig {
+	return KafkaConfig{
+		Topic: "cloud-sandbox-events",
+	}
+}
+
+// KafkaSink durably streams events to a Kafka topic, keyed by SandboxID (or
+// SessionID, if the event has no sandbox) so a consumer's partition
+// assignment keeps one sandbox's or session's events in order.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink creates a KafkaSink writing to config.Topic on
+// config.Brokers.
+func NewKafkaSink(config KafkaConfig) (*KafkaSink, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one Kafka broker is required")
+	}
+	if config.Topic == "" {
+		return nil, fmt.Errorf("Kafka topic is required")
+	}
+
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		},
+	}, nil
+}
+
+// Publish writes event to the configured topic.
+func (k *KafkaSink) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	key := event.SandboxID
+	if key == "" {
+		key = event.SessionID
+	}
+
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(key),
+		Value: value,
+	})
+}
+
+// Close flushes and closes the underlying Kafka writer.
+func (k *KafkaSink) Close() error {
+	return k.writer.Close()
+}
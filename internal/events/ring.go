@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// RingEntry pairs an Event with the monotonically increasing Seq RingBuffer
+// assigned it, so a subscriber can report Seq back as an SSE Last-Event-ID
+// and resume exactly where it left off.
+type RingEntry struct {
+	Seq   uint64
+	Event Event
+}
+
+// RingBuffer is a Sink that keeps the most recent Capacity published events
+// in memory instead of forwarding them anywhere, so a reconnecting
+// subscriber (the scheduler's /api/v1/events SSE handler) can replay
+// everything it missed instead of only seeing events published while it
+// happened to be connected.
+type RingBuffer struct {
+	mu       sync.Mutex
+	capacity int
+	seq      uint64
+	entries  []RingEntry
+	subs     []chan RingEntry
+}
+
+// NewRingBuffer creates a RingBuffer retaining the most recent capacity
+// events. capacity <= 0 defaults to 256.
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity <= 0 {
+		capacity = 256
+	}
+	return &RingBuffer{capacity: capacity}
+}
+
+// Publish appends event to the ring, trimming the oldest entry once past
+// capacity, and fans it out to every live Since subscriber. It never
+// blocks a slow subscriber: a subscriber channel that's full simply misses
+// the live push and must catch up through its next reconnect's backlog.
+func (r *RingBuffer) Publish(ctx context.Context, event Event) error {
+	r.mu.Lock()
+	r.seq++
+	entry := RingEntry{Seq: r.seq, Event: event}
+	r.entries = append(r.entries, entry)
+	if len(r.entries) > r.capacity {
+		r.entries = append([]RingEntry(nil), r.entries[len(r.entries)-r.capacity:]...)
+	}
+	subs := make([]chan RingEntry, len(r.subs))
+	copy(subs, r.subs)
+	r.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+	return nil
+}
+
+// Since returns every buffered entry with Seq greater than after (0 replays
+// the whole backlog, for a subscriber with no Last-Event-ID yet) along with
+// a channel that receives every entry published from this point on.
+// unsubscribe must be called once the caller stops reading ch.
+func (r *RingBuffer) Since(after uint64) (backlog []RingEntry, ch <-chan RingEntry, unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.Seq > after {
+			backlog = append(backlog, e)
+		}
+	}
+
+	live := make(chan RingEntry, 64)
+	r.subs = append(r.subs, live)
+	unsubscribe = func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, s := range r.subs {
+			if s == live {
+				r.subs = append(r.subs[:i:i], r.subs[i+1:]...)
+				break
+			}
+		}
+	}
+	return backlog, live, unsubscribe
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultSpoolMaxPending bounds how many undelivered events Spool keeps on
+// disk before it starts dropping the oldest to make room for new ones.
+const defaultSpoolMaxPending = 10000
+
+// defaultSpoolRetryInterval is how often Spool retries delivering its
+// oldest pending event when DrainInterval is unset.
+const defaultSpoolRetryInterval = 5 * time.Second
+
+// SpoolConfig configures Spool.
+type SpoolConfig struct {
+	// Dir is where pending events are persisted as one file per event.
+	// Created if it doesn't exist.
+	Dir string
+
+	// MaxPending bounds the on-disk queue depth. Zero means
+	// defaultSpoolMaxPending.
+	MaxPending int
+
+	// DrainInterval is how often the background worker retries delivering
+	// pending events. Zero means defaultSpoolRetryInterval.
+	DrainInterval time.Duration
+}
+
+// Spool wraps a Sink with a bounded on-disk queue, giving at-least-once
+// delivery across process restarts: Publish persists the event before
+// returning, and a background worker retries delivering the oldest pending
+// event until the wrapped sink accepts it, only then removing it from
+// disk. This is the durability MinIO's webhook/Kafka bucket-notification
+// sinks get from their own internal queueing; plain WebhookSink and
+// KafkaSink don't retry on their own; wrap them in a Spool to.
+type Spool struct {
+	sink          Sink
+	dir           string
+	maxPending    int
+	drainInterval time.Duration
+
+	mu sync.Mutex
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewSpool creates a Spool delivering to sink through config.Dir.
+func NewSpool(sink Sink, config SpoolConfig) (*Spool, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("spool directory is required")
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = defaultSpoolMaxPending
+	}
+	if config.DrainInterval <= 0 {
+		config.DrainInterval = defaultSpoolRetryInterval
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{
+		sink:          sink,
+		dir:           config.Dir,
+		maxPending:    config.MaxPending,
+		drainInterval: config.DrainInterval,
+		stopCh:        make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.loop()
+
+	return s, nil
+}
+
+// Publish persists event under s.dir and returns immediately; delivery to
+// the wrapped sink happens asynchronously via the background drain loop,
+// retried until it succeeds.
+func (s *Spool) Publish(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	entries, err := s.pendingLocked()
+	if err != nil {
+		return fmt.Errorf("failed to list pending events: %w", err)
+	}
+	if len(entries) >= s.maxPending {
+		oldest := entries[0]
+		log.Printf("[Spool] Pending queue full (%d), dropping oldest event %s", s.maxPending, oldest.Name())
+		os.Remove(filepath.Join(s.dir, oldest.Name()))
+	}
+
+	name := fmt.Sprintf("%020d.json", time.Now().UnixNano())
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to spool event: %w", err)
+	}
+	return nil
+}
+
+// pendingLocked returns the spool's pending event files, oldest first. The
+// caller must hold s.mu.
+func (s *Spool) pendingLocked() ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// loop periodically drains the spool until Close is called.
+func (s *Spool) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.drainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.drain()
+		}
+	}
+}
+
+// drain delivers pending events to the wrapped sink in order, stopping at
+// the first failure so delivery order is preserved and the failing event
+// is retried (rather than skipped) on the next tick.
+func (s *Spool) drain() {
+	s.mu.Lock()
+	entries, err := s.pendingLocked()
+	s.mu.Unlock()
+	if err != nil {
+		log.Printf("[Spool] Failed to list pending events: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[Spool] Failed to read pending event %s: %v", entry.Name(), err)
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			log.Printf("[Spool] Dropping unreadable pending event %s: %v", entry.Name(), err)
+			os.Remove(path)
+			continue
+		}
+
+		if err := s.sink.Publish(context.Background(), event); err != nil {
+			log.Printf("[Spool] Delivery failed for pending event %s, will retry: %v", entry.Name(), err)
+			return
+		}
+		os.Remove(path)
+	}
+}
+
+// Close stops the background drain loop. Any events still pending on disk
+// are delivered by the next process to open the same Dir.
+func (s *Spool) Close() error {
+	close(s.stopCh)
+	s.wg.Wait()
+	return nil
+}
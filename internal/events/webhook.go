@@ -0,0 +1,94 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookConfig configures WebhookSink.
+type WebhookConfig struct {
+	URL string
+
+	// Secret, if set, signs each delivery's body with HMAC-SHA256; the
+	// signature is sent in the X-Signature header as "sha256=<hex>".
+	Secret string
+
+	// AuthHeader and AuthToken, if both set, add a static
+	// "<AuthHeader>: <AuthToken>" header, matching collectors (Splunk
+	// HEC among them) that gate ingestion on a bearer/auth token rather
+	// than, or in addition to, HMAC verification.
+	AuthHeader string
+	AuthToken  string
+
+	Timeout time.Duration
+}
+
+// DefaultWebhookConfig returns default webhook configuration. URL is left
+// empty; callers must set it.
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Timeout: 5 * time.Second,
+	}
+}
+
+// WebhookSink delivers events as signed HTTP POSTs.
+type WebhookSink struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookSink creates a WebhookSink posting to config.URL.
+func NewWebhookSink(config WebhookConfig) (*WebhookSink, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	return &WebhookSink{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Publish POSTs event as JSON to config.URL, signing the body with
+// HMAC-SHA256 over config.Secret when set.
+func (w *WebhookSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if w.config.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.config.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	if w.config.AuthHeader != "" && w.config.AuthToken != "" {
+		req.Header.Set(w.config.AuthHeader, w.config.AuthToken)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -37,6 +38,34 @@ type Metrics struct {
 	// File operation metrics
 	FileOperationsTotal *prometheus.CounterVec
 	FileOperationErrors *prometheus.CounterVec
+
+	// Rate limit metrics
+	RatelimitAllowedTotal *prometheus.CounterVec
+	RatelimitDeniedTotal  *prometheus.CounterVec
+	RatelimitWaitSeconds  prometheus.Histogram
+
+	// Sandbox pool waiter-queue metrics
+	SandboxWaiters           *prometheus.GaugeVec
+	SandboxWaitDuration      *prometheus.HistogramVec
+	SandboxWaitTimeoutsTotal *prometheus.CounterVec
+
+	// Image/layer cache metrics
+	ImageCacheHitsTotal      prometheus.Counter
+	ImageCacheMissesTotal    prometheus.Counter
+	ImageCacheEvictionsTotal prometheus.Counter
+	ImageCacheBytes          prometheus.Gauge
+	ImageCacheEntries        prometheus.Gauge
+
+	// Workspace save/restore metrics (streamed to/from WorkspaceStorage)
+	WorkspaceSaveBytesTotal    prometheus.Counter
+	WorkspaceSaveDuration      prometheus.Histogram
+	WorkspaceRestoreBytesTotal prometheus.Counter
+	WorkspaceRestoreDuration   prometheus.Histogram
+
+	// Content-defined-chunking dedup metrics (ChunkedStorage.Save)
+	WorkspaceDedupBytesTotal         prometheus.Counter
+	WorkspaceDedupUploadedBytesTotal prometheus.Counter
+	WorkspaceDedupRatio              prometheus.Gauge
 }
 
 // NewMetrics creates and registers all Prometheus metrics
@@ -152,6 +181,110 @@ func NewMetrics(namespace string) *Metrics {
 			Name:      "file_operation_errors_total",
 			Help:      "Total number of file operation errors",
 		}, []string{"operation", "error_type"}),
+
+		// Rate limit metrics
+		RatelimitAllowedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_allowed_total",
+			Help:      "Total number of requests allowed by the rate limiter",
+		}, []string{"scope"}),
+		RatelimitDeniedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_denied_total",
+			Help:      "Total number of requests denied by the rate limiter",
+		}, []string{"scope"}),
+		RatelimitWaitSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ratelimit_wait_seconds",
+			Help:      "Retry-After seconds returned to denied requests",
+			Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}),
+
+		// Sandbox pool waiter-queue metrics
+		SandboxWaiters: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sandbox_waiters",
+			Help:      "Number of callers queued waiting for a sandbox, by priority class",
+		}, []string{"priority"}),
+		SandboxWaitDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sandbox_wait_duration_seconds",
+			Help:      "Time a caller spent queued in AcquireWithOptions before getting a sandbox",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"priority"}),
+		SandboxWaitTimeoutsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sandbox_wait_timeouts_total",
+			Help:      "Total number of queued acquires that gave up before a sandbox became available",
+		}, []string{"priority"}),
+
+		// Image/layer cache metrics
+		ImageCacheHitsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "image_cache_hits_total",
+			Help:      "Total number of dependency layer cache lookups that found a cached layer",
+		}),
+		ImageCacheMissesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "image_cache_misses_total",
+			Help:      "Total number of dependency layer cache lookups that found nothing cached",
+		}),
+		ImageCacheEvictionsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "image_cache_evictions_total",
+			Help:      "Total number of dependency layers evicted by GC to stay under quota",
+		}),
+		ImageCacheBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "image_cache_bytes",
+			Help:      "Total on-disk size of committed dependency layers",
+		}),
+		ImageCacheEntries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "image_cache_entries",
+			Help:      "Number of dependency layers currently committed to the cache",
+		}),
+
+		// Workspace save/restore metrics
+		WorkspaceSaveBytesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_save_bytes_total",
+			Help:      "Total bytes streamed from sandboxes into workspace storage",
+		}),
+		WorkspaceSaveDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "workspace_save_duration_seconds",
+			Help:      "Time to stream a sandbox's workspace into storage",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		}),
+		WorkspaceRestoreBytesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_restore_bytes_total",
+			Help:      "Total bytes streamed from workspace storage into sandboxes",
+		}),
+		WorkspaceRestoreDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "workspace_restore_duration_seconds",
+			Help:      "Time to stream a workspace from storage into a sandbox",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+		}),
+
+		// Content-defined-chunking dedup metrics
+		WorkspaceDedupBytesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_dedup_bytes_total",
+			Help:      "Total original bytes across every file ChunkedStorage.Save has chunked",
+		}),
+		WorkspaceDedupUploadedBytesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_dedup_uploaded_bytes_total",
+			Help:      "Total bytes ChunkedStorage.Save actually uploaded (chunks not already present)",
+		}),
+		WorkspaceDedupRatio: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workspace_dedup_ratio",
+			Help:      "Fraction of bytes skipped as already-present chunks on the most recent ChunkedStorage.Save",
+		}),
 	}
 
 	// Register all metrics
@@ -175,6 +308,24 @@ func NewMetrics(namespace string) *Metrics {
 		m.HTTPResponseSize,
 		m.FileOperationsTotal,
 		m.FileOperationErrors,
+		m.RatelimitAllowedTotal,
+		m.RatelimitDeniedTotal,
+		m.RatelimitWaitSeconds,
+		m.SandboxWaiters,
+		m.SandboxWaitDuration,
+		m.SandboxWaitTimeoutsTotal,
+		m.ImageCacheHitsTotal,
+		m.ImageCacheMissesTotal,
+		m.ImageCacheEvictionsTotal,
+		m.ImageCacheBytes,
+		m.ImageCacheEntries,
+		m.WorkspaceSaveBytesTotal,
+		m.WorkspaceSaveDuration,
+		m.WorkspaceRestoreBytesTotal,
+		m.WorkspaceRestoreDuration,
+		m.WorkspaceDedupBytesTotal,
+		m.WorkspaceDedupUploadedBytesTotal,
+		m.WorkspaceDedupRatio,
 	)
 
 	return m
@@ -185,23 +336,46 @@ func Handler() http.Handler {
 	return promhttp.Handler()
 }
 
-// RecordExecution records an execution metric
-func (m *Metrics) RecordExecution(language string, success bool, durationSeconds float64) {
+// RecordExecution records an execution metric. ctx carries the request's
+// trace context, if any, so the observation can link back to the trace
+// that produced it via a Prometheus exemplar.
+func (m *Metrics) RecordExecution(ctx context.Context, language string, success bool, durationSeconds float64) {
 	status := "success"
 	if !success {
 		status = "failure"
 	}
 	m.ExecutionsTotal.WithLabelValues(language, status).Inc()
-	m.ExecutionDuration.Observe(durationSeconds)
+	observeWithExemplar(ctx, m.ExecutionDuration, durationSeconds)
 }
 
-// RecordHTTPRequest records an HTTP request metric
-func (m *Metrics) RecordHTTPRequest(method, path, status string, durationSeconds float64, responseSize int) {
+// RecordHTTPRequest records an HTTP request metric. ctx carries the
+// request's trace context, if any, so the observation can link back to
+// the trace that produced it via a Prometheus exemplar.
+func (m *Metrics) RecordHTTPRequest(ctx context.Context, method, path, status string, durationSeconds float64, responseSize int) {
 	m.HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
-	m.HTTPRequestDuration.WithLabelValues(method, path).Observe(durationSeconds)
+	observeWithExemplar(ctx, m.HTTPRequestDuration.WithLabelValues(method, path), durationSeconds)
 	m.HTTPResponseSize.WithLabelValues(method, path).Observe(float64(responseSize))
 }
 
+// observeWithExemplar records v on obs, attaching ctx's trace ID as an
+// exemplar when one is present so a latency spike in Grafana can jump
+// straight to the trace that caused it. Falls back to a plain Observe
+// when ctx carries no sampled span or obs doesn't support exemplars.
+func observeWithExemplar(ctx context.Context, obs prometheus.Observer, v float64) {
+	traceID := traceIDFromContext(ctx)
+	if traceID == "" {
+		obs.Observe(v)
+		return
+	}
+
+	exemplarObs, ok := obs.(prometheus.ExemplarObserver)
+	if !ok {
+		obs.Observe(v)
+		return
+	}
+	exemplarObs.ObserveWithExemplar(v, prometheus.Labels{"trace_id": traceID})
+}
+
 // RecordFileOperation records a file operation metric
 func (m *Metrics) RecordFileOperation(operation string, success bool, errType string) {
 	m.FileOperationsTotal.WithLabelValues(operation).Inc()
@@ -210,11 +384,58 @@ func (m *Metrics) RecordFileOperation(operation string, success bool, errType st
 	}
 }
 
-// UpdateSandboxStats updates sandbox pool metrics
-func (m *Metrics) UpdateSandboxStats(total, active, idle int) {
+// UpdateSandboxStats updates sandbox pool metrics. waiters maps each
+// priority class to the number of callers currently queued in it.
+func (m *Metrics) UpdateSandboxStats(total, active, idle int, waiters map[string]int) {
 	m.SandboxesTotal.Set(float64(total))
 	m.SandboxesActive.Set(float64(active))
 	m.SandboxesIdle.Set(float64(idle))
+	for priority, count := range waiters {
+		m.SandboxWaiters.WithLabelValues(priority).Set(float64(count))
+	}
+}
+
+// RecordSandboxWait records how long a caller waited in the priority
+// waiter queue, whether or not it was satisfied, and additionally counts
+// a timeout when timedOut is set.
+func (m *Metrics) RecordSandboxWait(priority string, waitSeconds float64, timedOut bool) {
+	m.SandboxWaitDuration.WithLabelValues(priority).Observe(waitSeconds)
+	if timedOut {
+		m.SandboxWaitTimeoutsTotal.WithLabelValues(priority).Inc()
+	}
+}
+
+// RecordImageCacheLookup records whether a dependency layer cache lookup
+// hit or missed.
+func (m *Metrics) RecordImageCacheLookup(hit bool) {
+	if hit {
+		m.ImageCacheHitsTotal.Inc()
+	} else {
+		m.ImageCacheMissesTotal.Inc()
+	}
+}
+
+// RecordImageCacheEviction records a GC eviction of a cached layer.
+func (m *Metrics) RecordImageCacheEviction() {
+	m.ImageCacheEvictionsTotal.Inc()
+}
+
+// UpdateImageCacheStats updates the layer cache's size gauges.
+func (m *Metrics) UpdateImageCacheStats(totalBytes int64, entries int) {
+	m.ImageCacheBytes.Set(float64(totalBytes))
+	m.ImageCacheEntries.Set(float64(entries))
+}
+
+// RecordWorkspaceDedup records one ChunkedStorage.Save call's dedup
+// effectiveness: totalBytes is the workspace's uncompressed size and
+// uploadedBytes is how much of that actually had to be uploaded because no
+// existing chunk already covered it.
+func (m *Metrics) RecordWorkspaceDedup(totalBytes, uploadedBytes int64) {
+	m.WorkspaceDedupBytesTotal.Add(float64(totalBytes))
+	m.WorkspaceDedupUploadedBytesTotal.Add(float64(uploadedBytes))
+	if totalBytes > 0 {
+		m.WorkspaceDedupRatio.Set(1 - float64(uploadedBytes)/float64(totalBytes))
+	}
 }
 
 // UpdateSessionStats updates session metrics
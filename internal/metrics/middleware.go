@@ -5,6 +5,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // responseWriter wraps http.ResponseWriter to capture status code and size
@@ -25,9 +28,18 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
-// HTTPMiddleware returns a middleware that records HTTP metrics
+// HTTPMiddleware returns a middleware that records HTTP metrics and, via
+// Tracer, opens a span covering the request so RecordHTTPRequest's
+// exemplar and any downstream span (Pool.Acquire, RedisCache.Get, ...)
+// land in the same trace.
 func (m *Metrics) HTTPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := normalizePath(r.URL.Path)
+
+		ctx, span := Tracer.Start(r.Context(), "HTTP "+r.Method+" "+path)
+		defer span.End()
+		r = r.WithContext(ctx)
+
 		start := time.Now()
 
 		// Wrap response writer to capture status and size
@@ -41,10 +53,14 @@ func (m *Metrics) HTTPMiddleware(next http.Handler) http.Handler {
 
 		// Record metrics
 		duration := time.Since(start).Seconds()
-		path := normalizePath(r.URL.Path)
 		status := strconv.Itoa(wrapped.statusCode)
 
-		m.RecordHTTPRequest(r.Method, path, status, duration, wrapped.size)
+		span.SetAttributes(attribute.String("http.method", r.Method), attribute.Int("http.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= 500 {
+			span.SetStatus(codes.Error, status)
+		}
+
+		m.RecordHTTPRequest(ctx, r.Method, path, status, duration, wrapped.size)
 	})
 }
 
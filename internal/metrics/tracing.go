@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies this module's instrumentation scope to whatever
+// backend the OTLP exporter forwards spans to.
+const tracerName = "github.com/cloud-sandbox/cloud-sandbox"
+
+// Tracer is the tracer every instrumented package starts spans from.
+// Before InitTracer runs it's the OTel no-op tracer, so Pool, RedisCache,
+// and JWTAuth can start spans unconditionally without a nil check; the
+// spans just go nowhere until a provider is installed.
+var Tracer trace.Tracer = otel.Tracer(tracerName)
+
+// InitTracer points Tracer and the global trace.TracerProvider at an
+// OTLP/gRPC exporter, so every span end-to-end (JWTAuth.Middleware's
+// server span down through Pool.Acquire and RedisCache.Get) lands in one
+// trace. The exporter target comes from OTEL_EXPORTER_OTLP_ENDPOINT; if
+// it's unset, Tracer is left as the no-op tracer and InitTracer returns a
+// nil shutdown func, so tracing is opt-in per deployment. Call the
+// returned shutdown func (if non-nil) before the process exits to flush
+// buffered spans.
+func InitTracer(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	Tracer = provider.Tracer(tracerName)
+	return provider.Shutdown, nil
+}
+
+// SpanFromContext returns the current span, or a no-op span if ctx
+// carries none.
+func SpanFromContext(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
+}
+
+// traceIDFromContext returns ctx's trace ID as a hex string for use as a
+// Prometheus exemplar label, or "" if ctx carries no sampled span.
+func traceIDFromContext(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() || !spanCtx.IsSampled() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
+
+// StringAttr is a convenience alias so callers instrumenting spans in
+// other packages don't need their own otel/attribute import just to tag a
+// span with an ID.
+func StringAttr(key, value string) attribute.KeyValue {
+	return attribute.String(key, value)
+}
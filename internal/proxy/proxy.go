@@ -0,0 +1,120 @@
+// Package proxy builds the reverse proxies the gateway uses to forward
+// requests to backend services (scheduler, session manager, ...). It wraps
+// httputil.ReverseProxy with the RFC 7230 hop-by-hop header handling and
+// user-context injection the gateway needs, so callers don't have to
+// rebuild requests or buffer bodies by hand.
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+)
+
+// hopHeaders are the headers defined as hop-by-hop in RFC 7230 section 6.1.
+// They describe the connection to the immediate peer and must not be
+// forwarded by a proxy.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"TE",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Config controls how a backend reverse proxy is constructed.
+type Config struct {
+	// Target is the backend's base URL, e.g. "http://localhost:9090".
+	Target string
+	// ResponseHeaderTimeout bounds how long the proxy waits for the
+	// backend's response headers. Zero means no timeout.
+	ResponseHeaderTimeout time.Duration
+}
+
+// New builds a reverse proxy for a single backend. The returned proxy
+// injects X-User-ID (from the request's JWT claims) and X-Forwarded-*
+// headers, strips hop-by-hop headers on the way in and out, and
+// harmonizes CORS headers on the response so the gateway's own CORS
+// middleware remains the single source of truth.
+func New(config Config) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse(config.Target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy target %q: %w", config.Target, err)
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(target)
+
+	baseDirector := rp.Director
+	rp.Director = func(req *http.Request) {
+		baseDirector(req)
+
+		removeHopHeaders(req.Header)
+
+		req.Header.Set("X-Forwarded-For", req.RemoteAddr)
+		req.Header.Set("X-Forwarded-Host", req.Host)
+		req.Header.Set("X-Forwarded-Proto", schemeOf(req))
+
+		if claims, ok := auth.GetClaimsFromContext(req.Context()); ok {
+			req.Header.Set("X-User-ID", claims.UserID)
+		}
+	}
+
+	rp.ModifyResponse = func(resp *http.Response) error {
+		removeHopHeaders(resp.Header)
+
+		// The gateway's CORS middleware already sets Access-Control-*
+		// headers on the way out; drop any the backend added so the
+		// client never sees duplicates.
+		for key := range resp.Header {
+			if strings.HasPrefix(key, "Access-Control-") {
+				resp.Header.Del(key)
+			}
+		}
+		return nil
+	}
+
+	rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("[Proxy] backend %s unreachable: %v", config.Target, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(w, `{"error":"service_unavailable","message":"backend service unavailable: %s"}`, config.Target)
+	}
+
+	if config.ResponseHeaderTimeout > 0 {
+		rp.Transport = &http.Transport{
+			ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		}
+	}
+
+	return rp, nil
+}
+
+// removeHopHeaders deletes the standard hop-by-hop headers plus any extra
+// headers named in the request/response's own Connection header, per RFC
+// 7230 section 6.1.
+func removeHopHeaders(header http.Header) {
+	if connection := header.Get("Connection"); connection != "" {
+		for _, name := range strings.Split(connection, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+func schemeOf(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HijackAndPipe upgrades the client connection for a WebSocket request and
+// pipes bytes bidirectionally to targetURL. http.Server's normal
+// ReverseProxy can't do this: once a connection is hijacked, it is no
+// longer an http.ResponseWriter/Request pair, so the handshake and the
+// data that follows it have to be relayed a byte at a time ourselves.
+func HijackAndPipe(w http.ResponseWriter, r *http.Request, targetURL string) error {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy target %q: %w", targetURL, err)
+	}
+
+	backendConn, err := net.DialTimeout("tcp", target.Host, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to dial backend %s: %w", target.Host, err)
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		backendConn.Close()
+		return fmt.Errorf("response writer does not support hijacking")
+	}
+
+	clientConn, clientBuf, err := hijacker.Hijack()
+	if err != nil {
+		backendConn.Close()
+		return fmt.Errorf("failed to hijack client connection: %w", err)
+	}
+
+	if err := r.Write(backendConn); err != nil {
+		clientConn.Close()
+		backendConn.Close()
+		return fmt.Errorf("failed to forward upgrade request to backend: %w", err)
+	}
+
+	pipe(clientConn, clientBuf, backendConn)
+	return nil
+}
+
+// pipe copies bytes in both directions until either side closes. Any data
+// already buffered by the client's bufio.ReadWriter (left over from reading
+// the request line/headers) is flushed to the backend first so nothing is
+// lost.
+func pipe(clientConn net.Conn, clientBuf *bufio.ReadWriter, backendConn net.Conn) {
+	defer clientConn.Close()
+	defer backendConn.Close()
+
+	if clientBuf.Reader.Buffered() > 0 {
+		if _, err := io.CopyN(backendConn, clientBuf.Reader, int64(clientBuf.Reader.Buffered())); err != nil {
+			return
+		}
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(backendConn, clientConn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(clientConn, backendConn)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// IsWebSocketUpgrade reports whether r is requesting a WebSocket upgrade.
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
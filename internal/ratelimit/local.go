@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LocalLimiter is an in-memory Limiter backed by an LRU of per-key
+// buckets. It's the right choice for a single sandbox-server replica; use
+// NewRedisLimiter when several replicas need to share the same limits.
+type LocalLimiter struct {
+	config Config
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front = most recently used
+}
+
+type localEntry struct {
+	key    string
+	bucket bucketState
+}
+
+// bucketState holds the fields either algorithm needs. Token bucket uses
+// remaining/lastUpdate directly; GCRA stores its theoretical arrival time
+// in lastUpdate and ignores remaining until Take recomputes it.
+type bucketState struct {
+	remaining  float64
+	lastUpdate time.Time
+}
+
+// NewLocalLimiter creates an in-memory Limiter.
+func NewLocalLimiter(config Config) *LocalLimiter {
+	if config.Rate <= 0 || config.Burst <= 0 {
+		config = DefaultConfig()
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = 10000
+	}
+
+	return &LocalLimiter{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Take implements Limiter.
+func (l *LocalLimiter) Take(_ context.Context, scope, key string, cost float64) (Result, error) {
+	bucketKey := scope + ":" + key
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[bucketKey]
+	now := time.Now()
+
+	var state bucketState
+	if ok {
+		state = elem.Value.(*localEntry).bucket
+		l.order.MoveToFront(elem)
+	} else {
+		state = bucketState{remaining: l.config.Burst, lastUpdate: now}
+	}
+
+	var result Result
+	switch l.config.Algorithm {
+	case GCRA:
+		result, state = l.takeGCRA(state, now, cost)
+	default:
+		result, state = l.takeTokenBucket(state, now, cost)
+	}
+
+	if ok {
+		elem.Value.(*localEntry).bucket = state
+	} else {
+		l.entries[bucketKey] = l.order.PushFront(&localEntry{key: bucketKey, bucket: state})
+		l.evictIfNeeded()
+	}
+
+	return result, nil
+}
+
+// takeTokenBucket refills the bucket for the elapsed time, then subtracts
+// cost: remaining = min(burst, remaining + elapsed*rate) - cost.
+func (l *LocalLimiter) takeTokenBucket(state bucketState, now time.Time, cost float64) (Result, bucketState) {
+	elapsed := now.Sub(state.lastUpdate).Seconds()
+	remaining := state.remaining + elapsed*l.config.Rate
+	if remaining > l.config.Burst {
+		remaining = l.config.Burst
+	}
+	remaining -= cost
+	state = bucketState{remaining: remaining, lastUpdate: now}
+
+	resetAt := now.Add(durationFromDeficit(l.config.Burst-remaining, l.config.Rate))
+	if remaining < 0 {
+		return Result{Allowed: false, Limit: l.config.Burst, Remaining: 0, RetryAfter: durationFromDeficit(-remaining, l.config.Rate), ResetAt: resetAt}, state
+	}
+	return Result{Allowed: true, Limit: l.config.Burst, Remaining: remaining, ResetAt: resetAt}, state
+}
+
+// takeGCRA treats state.lastUpdate as the bucket's theoretical arrival
+// time (TAT) and spaces requests by the emission interval 1/rate, only
+// allowing Burst requests to land ahead of schedule.
+func (l *LocalLimiter) takeGCRA(state bucketState, now time.Time, cost float64) (Result, bucketState) {
+	emissionInterval := time.Duration(float64(time.Second) / l.config.Rate)
+	delayTolerance := time.Duration(float64(emissionInterval) * l.config.Burst)
+
+	tat := state.lastUpdate
+	if tat.Before(now) {
+		tat = now
+	}
+
+	increment := time.Duration(float64(emissionInterval) * cost)
+	newTAT := tat.Add(increment)
+
+	allowAt := now.Add(delayTolerance)
+	if newTAT.After(allowAt) {
+		retryAfter := newTAT.Add(-delayTolerance).Sub(now)
+		return Result{Allowed: false, Limit: l.config.Burst, Remaining: 0, RetryAfter: retryAfter, ResetAt: tat}, bucketState{lastUpdate: tat}
+	}
+
+	remaining := delayTolerance - newTAT.Sub(now)
+	return Result{
+		Allowed:   true,
+		Limit:     l.config.Burst,
+		Remaining: remaining.Seconds() / emissionInterval.Seconds(),
+		ResetAt:   newTAT,
+	}, bucketState{lastUpdate: newTAT}
+}
+
+func durationFromDeficit(deficit, rate float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(deficit / rate * float64(time.Second))
+}
+
+// evictIfNeeded removes the least-recently-used bucket once the cache
+// exceeds CacheSize. Must be called with l.mu held.
+func (l *LocalLimiter) evictIfNeeded() {
+	for len(l.entries) > l.config.CacheSize {
+		oldest := l.order.Back()
+		if oldest == nil {
+			return
+		}
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*localEntry).key)
+	}
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
+)
+
+// MiddlewareConfig controls the HTTP rate-limit middleware.
+type MiddlewareConfig struct {
+	// Limiter is used for every request whose tier (see Tiers) has no
+	// more specific Limiter of its own.
+	Limiter Limiter
+
+	// Tiers maps a claims.Role tier name ("free", "pro", "enterprise") to
+	// the Limiter its requests are metered against, letting different
+	// tiers run different rate/burst Configs against the same route.
+	// A role absent from Tiers (including anonymous requests) falls back
+	// to Limiter.
+	Tiers map[string]Limiter
+
+	// Expensive lists the request paths (sandbox creation, workspace
+	// restore, ...) that also consume from ExpensiveLimiter, a second
+	// bucket dimension shared across every route in the set. This keeps
+	// a burst of expensive operations from starving cheap ones like
+	// /health, and vice versa, even though both share the same
+	// Limiter/Tiers bucket per-route.
+	Expensive        map[string]bool
+	ExpensiveLimiter Limiter
+
+	Metrics *metrics.Metrics // optional; metrics are skipped if nil
+	Cost    float64          // tokens charged per request, defaults to 1
+
+	// Events, if set, receives a ratelimit.exceeded event for every
+	// rejected request.
+	Events *events.Bus
+}
+
+// keyAndTier derives the rate-limit key and tier from the request's auth
+// claims, set by auth.JWTAuth.Middleware earlier in the chain - "user:<id>"
+// and the claim's Role, or "ip:<addr>" and no tier for unauthenticated
+// requests.
+func keyAndTier(r *http.Request) (key, tier string) {
+	if claims, ok := auth.GetClaimsFromContext(r.Context()); ok && claims.UserID != "" {
+		return "user:" + claims.UserID, claims.Role
+	}
+	return "ip:" + r.RemoteAddr, ""
+}
+
+// Middleware rate-limits requests by the authenticated user ID from
+// Claims (falling back to the remote address for unauthenticated routes),
+// scoped per-endpoint so a heavy user on one route doesn't throttle their
+// own traffic on another.
+func Middleware(config MiddlewareConfig) func(http.Handler) http.Handler {
+	cost := config.Cost
+	if cost <= 0 {
+		cost = 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, tier := keyAndTier(r)
+			scope := "http:" + r.URL.Path
+
+			limiter := config.Limiter
+			if tierLimiter, ok := config.Tiers[tier]; ok {
+				limiter = tierLimiter
+			}
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := limiter.Take(r.Context(), scope, key, cost)
+			if err != nil {
+				http.Error(w, `{"error":"internal_error","message":"rate limiter unavailable"}`, http.StatusInternalServerError)
+				return
+			}
+
+			if result.Allowed && config.ExpensiveLimiter != nil && config.Expensive[r.URL.Path] {
+				expResult, err := config.ExpensiveLimiter.Take(r.Context(), "expensive", key, cost)
+				if err == nil {
+					result = expResult
+				}
+			}
+
+			if config.Metrics != nil {
+				if result.Allowed {
+					config.Metrics.RatelimitAllowedTotal.WithLabelValues(scope).Inc()
+				} else {
+					config.Metrics.RatelimitDeniedTotal.WithLabelValues(scope).Inc()
+					config.Metrics.RatelimitWaitSeconds.Observe(result.RetryAfter.Seconds())
+				}
+			}
+
+			w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%.0f", result.Limit))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+
+			if !result.Allowed {
+				if config.Events != nil {
+					config.Events.PublishAsync(events.Event{
+						Type:   events.TypeRatelimitExceeded,
+						UserID: key,
+						Data:   map[string]interface{}{"scope": scope},
+					})
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds()+1)))
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error":"rate_limit_exceeded","message":"too many requests"}`))
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%.0f", result.Remaining))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session"
+)
+
+// takeScript atomically refills and debits a token bucket stored as a
+// Redis hash, then sets the key to self-evict once it's been idle for
+// burst/rate seconds (an idle bucket is a full one, so there's nothing
+// left to remember past that point).
+//
+// KEYS[1] = bucket key
+// ARGV[1] = burst
+// ARGV[2] = rate (tokens/sec)
+// ARGV[3] = cost
+// ARGV[4] = now (unix seconds, float)
+// returns {allowed(0/1), remaining, seconds until the bucket is full again}
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local remaining = tonumber(redis.call("HGET", KEYS[1], "remaining"))
+local lastUpdate = tonumber(redis.call("HGET", KEYS[1], "last_update"))
+if remaining == nil then
+	remaining = burst
+	lastUpdate = now
+end
+
+local elapsed = now - lastUpdate
+if elapsed < 0 then
+	elapsed = 0
+end
+remaining = math.min(burst, remaining + elapsed * rate) - cost
+
+redis.call("HSET", KEYS[1], "remaining", remaining, "last_update", now)
+local ttlMs = math.ceil((burst / rate) * 1000)
+redis.call("PEXPIRE", KEYS[1], ttlMs)
+
+local resetIn = (burst - remaining) / rate
+if remaining < 0 then
+	return {0, tostring(remaining), tostring(resetIn)}
+end
+return {1, tostring(remaining), tostring(resetIn)}
+`)
+
+// gcraScript is the Redis-shared equivalent of LocalLimiter.takeGCRA: it
+// stores the bucket's theoretical arrival time (TAT) instead of a token
+// count, spacing requests by the emission interval 1/rate and only letting
+// Burst of them land ahead of schedule.
+//
+// KEYS[1] = bucket key
+// ARGV[1] = burst
+// ARGV[2] = rate (tokens/sec)
+// ARGV[3] = cost
+// ARGV[4] = now (unix seconds, float)
+// returns {allowed(0/1), remaining, seconds until the bucket is full again}
+var gcraScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local emissionInterval = 1 / rate
+local delayTolerance = emissionInterval * burst
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if tat == nil or tat < now then
+	tat = now
+end
+
+local newTat = tat + emissionInterval * cost
+local allowAt = now + delayTolerance
+local ttlMs = math.ceil(delayTolerance * 1000)
+
+if newTat > allowAt then
+	local resetIn = tat - now
+	redis.call("SET", KEYS[1], tat, "PX", ttlMs)
+	return {0, tostring(0), tostring(resetIn)}
+end
+
+redis.call("SET", KEYS[1], newTat, "PX", ttlMs)
+local remaining = (delayTolerance - (newTat - now)) / emissionInterval
+local resetIn = newTat - now
+return {1, tostring(remaining), tostring(resetIn)}
+`)
+
+// RedisLimiter is a distributed Limiter: every replica executes the same
+// Lua script against a shared Redis instance, so they all see and debit
+// the same bucket, atomically, in one round trip. Config.Algorithm
+// selects which script runs, the same way it selects between
+// LocalLimiter's two code paths.
+type RedisLimiter struct {
+	client *redis.Client
+	config Config
+}
+
+// NewRedisLimiter creates a distributed Limiter on top of the same Redis
+// configuration session.RedisCache uses, so a rate-limit-by-Redis
+// deployment doesn't need a second connection pool's worth of config.
+func NewRedisLimiter(redisConfig session.RedisConfig, config Config) (*RedisLimiter, error) {
+	if config.Rate <= 0 || config.Burst <= 0 {
+		config = DefaultConfig()
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisConfig.Addr,
+		Password: redisConfig.Password,
+		DB:       redisConfig.DB,
+		PoolSize: redisConfig.PoolSize,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisLimiter{client: client, config: config}, nil
+}
+
+func bucketKey(scope, key string) string {
+	return fmt.Sprintf("rl:%s:%s", scope, key)
+}
+
+// Take implements Limiter.
+func (l *RedisLimiter) Take(ctx context.Context, scope, key string, cost float64) (Result, error) {
+	script := takeScript
+	if l.config.Algorithm == GCRA {
+		script = gcraScript
+	}
+
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+
+	res, err := script.Run(ctx, l.client, []string{bucketKey(scope, key)},
+		l.config.Burst, l.config.Rate, cost, now).Result()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: redis script failed: %w", err)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 3 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected script result: %v", res)
+	}
+
+	allowed := values[0].(int64) == 1
+	var remaining, resetIn float64
+	fmt.Sscanf(values[1].(string), "%g", &remaining)
+	fmt.Sscanf(values[2].(string), "%g", &resetIn)
+	if resetIn < 0 {
+		resetIn = 0
+	}
+	resetAt := time.Now().Add(time.Duration(resetIn * float64(time.Second)))
+
+	if allowed {
+		return Result{Allowed: true, Limit: l.config.Burst, Remaining: remaining, ResetAt: resetAt}, nil
+	}
+
+	// Under GCRA a request fits (burst-cost) emission intervals before the
+	// bucket is full again, matching LocalLimiter.takeGCRA.
+	retryAfter := time.Duration(resetIn*float64(time.Second)) - durationFromDeficit(l.config.Burst-cost, l.config.Rate)
+	if l.config.Algorithm != GCRA {
+		retryAfter = durationFromDeficit(-remaining, l.config.Rate)
+	}
+	return Result{Allowed: false, Limit: l.config.Burst, Remaining: 0, RetryAfter: retryAfter, ResetAt: resetAt}, nil
+}
+
+// Close closes the underlying Redis client.
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
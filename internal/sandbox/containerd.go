@@ -0,0 +1,704 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cclient "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/pkg/cio"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+)
+
+const (
+	// defaultContainerdSocket is containerd's standard gRPC socket.
+	defaultContainerdSocket = "/run/containerd/containerd.sock"
+
+	// defaultContainerdNamespace isolates cloud-sandbox's containers from
+	// any other client sharing the same containerd daemon - e.g. a
+	// kubelet's CRI plugin on the same node.
+	defaultContainerdNamespace = "cloud-sandbox"
+
+	// defaultSnapshotter is the overlayfs-backed snapshotter containerd
+	// ships by default, layering image and container rootfs the same way
+	// Docker's overlay2 graph driver does.
+	defaultSnapshotter = "overlayfs"
+)
+
+// ContainerdRuntime implements Runtime by talking directly to containerd
+// over its gRPC socket, bypassing the Docker daemon entirely - for
+// deployments that don't want dockerd in their trusted computing base.
+// Image pulls, snapshotter-backed rootfs, and process lifecycle all go
+// through containerd's own client.Client instead of the Docker Engine
+// API DockerRuntime uses, so (unlike GvisorRuntime/PodmanRuntime) this
+// does not embed DockerRuntime.
+type ContainerdRuntime struct {
+	client    *cclient.Client
+	namespace string
+	config    Config
+
+	mu         sync.RWMutex
+	sandboxes  map[string]*Sandbox
+	containers map[string]cclient.Container
+	tasks      map[string]cclient.Task
+
+	// events, if attached via AttachEventBus, receives sandbox.created,
+	// sandbox.destroyed, and exec.completed notifications, matching
+	// DockerRuntime.
+	events *events.Bus
+}
+
+// AttachEventBus wires the cross-cutting events.Bus into Create, Destroy,
+// and Exec. A nil bus (the default) leaves those lifecycle transitions
+// unpublished.
+func (r *ContainerdRuntime) AttachEventBus(bus *events.Bus) {
+	r.events = bus
+}
+
+// NewContainerdRuntime dials containerd at config.RuntimeSocket (default
+// defaultContainerdSocket) and scopes every call to
+// config.ContainerdNamespace (default defaultContainerdNamespace).
+func NewContainerdRuntime(config Config) (*ContainerdRuntime, error) {
+	socket := config.RuntimeSocket
+	if socket == "" {
+		socket = defaultContainerdSocket
+	}
+	namespace := config.ContainerdNamespace
+	if namespace == "" {
+		namespace = defaultContainerdNamespace
+	}
+
+	cli, err := cclient.New(socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to containerd: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(namespaces.WithNamespace(context.Background(), namespace), 5*time.Second)
+	defer cancel()
+	if _, err := cli.Version(ctx); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to verify containerd connection: %w", err)
+	}
+
+	return &ContainerdRuntime{
+		client:     cli,
+		namespace:  namespace,
+		config:     config,
+		sandboxes:  make(map[string]*Sandbox),
+		containers: make(map[string]cclient.Container),
+		tasks:      make(map[string]cclient.Task),
+	}, nil
+}
+
+// withNamespace scopes ctx to r's containerd namespace. Every call into
+// r.client must pass through this first.
+func (r *ContainerdRuntime) withNamespace(ctx context.Context) context.Context {
+	return namespaces.WithNamespace(ctx, r.namespace)
+}
+
+// Create pulls config.Image into r's namespace (if not already cached),
+// snapshots it with the overlayfs snapshotter, and starts a long-lived
+// "sleep infinity" task inside it - the same shape as
+// DockerRuntime.Create's container, just built from containerd's own
+// client instead of the Docker Engine API.
+func (r *ContainerdRuntime) Create(ctx context.Context, config Config) (*Sandbox, error) {
+	if config.Image == "" {
+		config.Image = r.config.Image
+	}
+	if config.CPUCount == 0 {
+		config.CPUCount = r.config.CPUCount
+	}
+	if config.MemoryMB == 0 {
+		config.MemoryMB = r.config.MemoryMB
+	}
+	if config.WorkDir == "" {
+		config.WorkDir = r.config.WorkDir
+	}
+
+	ctx = r.withNamespace(ctx)
+	sandboxID := generateSandboxID()
+	containerID := "sandbox-" + sandboxID
+
+	image, err := r.client.Pull(ctx, config.Image, cclient.WithPullUnpack, cclient.WithPullSnapshotter(defaultSnapshotter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull image: %w", err)
+	}
+
+	limits := newContainerResourceLimits(config)
+	quota, period := limits.cfsQuotaPeriod()
+
+	specOpts := []oci.SpecOpts{
+		oci.WithImageConfig(image),
+		oci.WithProcessArgs("sleep", "infinity"),
+		oci.WithMemoryLimit(uint64(limits.MemoryBytes)),
+		oci.WithCPUCFS(quota, period),
+		oci.WithMounts([]specs.Mount{
+			{
+				Destination: config.WorkDir,
+				Type:        "tmpfs",
+				Source:      "tmpfs",
+				Options:     []string{"size=" + strconv.FormatInt(limits.TmpfsSizeBytes, 10)},
+			},
+		}),
+	}
+	if !config.NetworkEnabled {
+		specOpts = append(specOpts, oci.WithLinuxNamespace(specs.LinuxNamespace{Type: specs.NetworkNamespace}))
+	}
+
+	labels := map[string]string{
+		labelSandboxID: sandboxID,
+		labelManaged:   "true",
+	}
+
+	cont, err := r.client.NewContainer(ctx, containerID,
+		cclient.WithImage(image),
+		cclient.WithSnapshotter(defaultSnapshotter),
+		cclient.WithNewSnapshot(containerID, image),
+		cclient.WithNewSpec(specOpts...),
+		cclient.WithContainerLabels(labels),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	task, err := cont.NewTask(ctx, cio.NewCreator(cio.WithStdio))
+	if err != nil {
+		cont.Delete(ctx, cclient.WithSnapshotCleanup)
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	if err := task.Start(ctx); err != nil {
+		task.Delete(ctx)
+		cont.Delete(ctx, cclient.WithSnapshotCleanup)
+		return nil, fmt.Errorf("failed to start task: %w", err)
+	}
+
+	sandbox := &Sandbox{
+		ID:           sandboxID,
+		Status:       StatusIdle,
+		ContainerID:  containerID,
+		Image:        config.Image,
+		CreatedAt:    time.Now(),
+		LastActiveAt: time.Now(),
+		Labels:       labels,
+	}
+
+	r.mu.Lock()
+	r.sandboxes[sandboxID] = sandbox
+	r.containers[sandboxID] = cont
+	r.tasks[sandboxID] = task
+	r.mu.Unlock()
+
+	if r.events != nil {
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxCreated,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: sandboxID,
+		})
+	}
+
+	return sandbox, nil
+}
+
+// Start starts a new task for a sandbox whose previous task has already
+// exited (e.g. after Stop), reusing the existing container/snapshot.
+func (r *ContainerdRuntime) Start(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	cont, ok := r.containers[id]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("sandbox not found: %s", id)
+	}
+
+	task, err := cont.NewTask(ctx, cio.NewCreator(cio.WithStdio))
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+	if err := task.Start(ctx); err != nil {
+		task.Delete(ctx)
+		return fmt.Errorf("failed to start task: %w", err)
+	}
+
+	r.mu.Lock()
+	r.tasks[id] = task
+	sandbox.Status = StatusIdle
+	sandbox.LastActiveAt = time.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Stop kills the sandbox's task (SIGKILL, matching the CFS's hard stop
+// semantics - there is no graceful-timeout exec in the tasks service the
+// way Docker's ContainerStop offers), waits for it to exit, and deletes
+// the exited task record so the container can later get a fresh one via
+// Start.
+func (r *ContainerdRuntime) Stop(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+
+	task, err := r.taskFor(id)
+	if err != nil {
+		return err
+	}
+
+	exitCh, err := task.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait on task: %w", err)
+	}
+	if err := task.Kill(ctx, 9); err != nil {
+		return fmt.Errorf("failed to kill task: %w", err)
+	}
+	<-exitCh
+	if _, err := task.Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete exited task: %w", err)
+	}
+
+	r.mu.Lock()
+	delete(r.tasks, id)
+	r.sandboxes[id].Status = StatusStopped
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Destroy kills and deletes the sandbox's task, then deletes its
+// container and snapshot.
+func (r *ContainerdRuntime) Destroy(ctx context.Context, id string) error {
+	ctx = r.withNamespace(ctx)
+	if _, err := r.Get(ctx, id); err != nil {
+		return err
+	}
+
+	if task, err := r.taskFor(id); err == nil {
+		exitCh, waitErr := task.Wait(ctx)
+		if waitErr == nil {
+			task.Kill(ctx, 9)
+			<-exitCh
+		}
+		task.Delete(ctx)
+	}
+
+	r.mu.RLock()
+	cont, ok := r.containers[id]
+	r.mu.RUnlock()
+	if ok {
+		if err := cont.Delete(ctx, cclient.WithSnapshotCleanup); err != nil {
+			return fmt.Errorf("failed to delete container: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	delete(r.sandboxes, id)
+	delete(r.containers, id)
+	delete(r.tasks, id)
+	r.mu.Unlock()
+
+	if r.events != nil {
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxDestroyed,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: id,
+		})
+	}
+
+	return nil
+}
+
+// Checkpoint freezes the sandbox's task into a containerd checkpoint
+// image tagged name, the containerd-native equivalent of
+// DockerRuntime.Checkpoint's CheckpointCreate call.
+func (r *ContainerdRuntime) Checkpoint(ctx context.Context, id, name string) error {
+	ctx = r.withNamespace(ctx)
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	task, err := r.taskFor(id)
+	if err != nil {
+		return err
+	}
+
+	checkpointRef := r.checkpointRef(id, name)
+	if _, err := task.Checkpoint(ctx, cclient.WithCheckpointTask, cclient.WithCheckpointImage(checkpointRef)); err != nil {
+		return fmt.Errorf("failed to checkpoint sandbox: %w", err)
+	}
+
+	r.mu.Lock()
+	sandbox.Status = StatusCheckpointed
+	sandbox.CheckpointName = name
+	sandbox.CheckpointedAt = time.Now()
+	r.mu.Unlock()
+
+	log.Printf("[Containerd] Checkpointed sandbox %s as %q", id, name)
+	return nil
+}
+
+// Restore resumes the sandbox's task from the checkpoint image name was
+// saved under by Checkpoint.
+func (r *ContainerdRuntime) Restore(ctx context.Context, id, name string) error {
+	ctx = r.withNamespace(ctx)
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	cont, ok := r.containers[id]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("sandbox not found: %s", id)
+	}
+
+	checkpointImage, err := r.client.GetImage(ctx, r.checkpointRef(id, name))
+	if err != nil {
+		return fmt.Errorf("failed to look up checkpoint %q: %w", name, err)
+	}
+
+	task, err := cont.NewTask(ctx, cio.NewCreator(cio.WithStdio), cclient.WithTaskCheckpoint(checkpointImage))
+	if err != nil {
+		return fmt.Errorf("failed to restore task from checkpoint: %w", err)
+	}
+	if err := task.Start(ctx); err != nil {
+		task.Delete(ctx)
+		return fmt.Errorf("failed to start restored task: %w", err)
+	}
+
+	r.mu.Lock()
+	r.tasks[id] = task
+	sandbox.Status = StatusIdle
+	sandbox.LastActiveAt = time.Now()
+	sandbox.CheckpointName = ""
+	r.mu.Unlock()
+
+	log.Printf("[Containerd] Restored sandbox %s from checkpoint %q", id, name)
+	return nil
+}
+
+// checkpointRef builds the image reference Checkpoint tags a sandbox's
+// checkpoint under and Restore looks it up by: namespaced implicitly by
+// r.namespace, scoped explicitly by sandbox id so two sandboxes can reuse
+// the same checkpoint name without colliding.
+func (r *ContainerdRuntime) checkpointRef(id, name string) string {
+	return "cloud-sandbox/checkpoint/" + id + ":" + name
+}
+
+// Get returns sandbox by ID
+func (r *ContainerdRuntime) Get(ctx context.Context, id string) (*Sandbox, error) {
+	r.mu.RLock()
+	sandbox, ok := r.sandboxes[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("sandbox not found: %s", id)
+	}
+
+	return sandbox, nil
+}
+
+// List returns all sandboxes
+func (r *ContainerdRuntime) List(ctx context.Context) ([]*Sandbox, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Sandbox, 0, len(r.sandboxes))
+	for _, sb := range r.sandboxes {
+		result = append(result, sb)
+	}
+
+	return result, nil
+}
+
+// taskFor returns the running task backing sandbox id.
+func (r *ContainerdRuntime) taskFor(id string) (cclient.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("sandbox %s has no running task", id)
+	}
+	return task, nil
+}
+
+// Exec executes code in a sandbox via the tasks service's Exec, the
+// containerd-native equivalent of DockerRuntime.Exec's ContainerExecCreate/
+// Attach pair.
+func (r *ContainerdRuntime) Exec(ctx context.Context, id string, req ExecRequest) (*ExecResult, error) {
+	ctx = r.withNamespace(ctx)
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := r.taskFor(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	sandbox.Status = StatusActive
+	sandbox.LastActiveAt = time.Now()
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		sandbox.Status = StatusIdle
+		r.mu.Unlock()
+	}()
+
+	var cmd []string
+	if len(req.Command) > 0 {
+		cmd = req.Command
+	} else {
+		cmd = r.buildCommand(req.Language, req.Code)
+	}
+
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = r.config.MaxExecutionTime
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	workDir := req.WorkDir
+	if workDir == "" {
+		workDir = r.config.WorkDir
+	}
+
+	spec, err := buildExecProcessSpec(ctx, task, cmd, workDir, req.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build exec spec: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	execID := "exec-" + generateSandboxID()
+	process, err := task.Exec(execCtx, execID, spec, cio.NewCreator(cio.WithStreams(req.Stdin, &limitedWriter{w: &stdout, limit: r.config.MaxOutputSize}, &limitedWriter{w: &stderr, limit: r.config.MaxOutputSize})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec: %w", err)
+	}
+	defer process.Delete(context.Background())
+
+	exitCh, err := process.Wait(execCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait on exec: %w", err)
+	}
+	if err := process.Start(execCtx); err != nil {
+		return nil, fmt.Errorf("failed to start exec: %w", err)
+	}
+
+	startTime := time.Now()
+	var timedOut bool
+	var exitCode int
+	select {
+	case <-execCtx.Done():
+		timedOut = true
+		process.Kill(context.Background(), 9)
+		<-exitCh
+	case status := <-exitCh:
+		exitCode = int(status.ExitCode())
+	}
+
+	result := &ExecResult{
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(startTime),
+		TimedOut: timedOut,
+	}
+
+	if r.events != nil {
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeExecCompleted,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: id,
+			Data: map[string]interface{}{
+				"exit_code": result.ExitCode,
+				"duration":  result.Duration.String(),
+				"timed_out": result.TimedOut,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// buildCommand mirrors DockerRuntime.buildCommand - the language-to-
+// interpreter mapping doesn't depend on which container engine runs it.
+func (r *ContainerdRuntime) buildCommand(language, code string) []string {
+	switch strings.ToLower(language) {
+	case "python", "python3":
+		return []string{"python3", "-c", code}
+	case "node", "javascript", "js":
+		return []string{"node", "-e", code}
+	case "bash", "sh", "shell":
+		return []string{"bash", "-c", code}
+	case "ruby":
+		return []string{"ruby", "-e", code}
+	case "go", "golang":
+		return []string{"bash", "-c", fmt.Sprintf("echo '%s' > /tmp/main.go && go run /tmp/main.go", escapeShell(code))}
+	default:
+		return []string{"bash", "-c", code}
+	}
+}
+
+// execCommand runs cmd to completion inside the sandbox's existing task
+// and returns its stdout, erroring (with its stderr) on a non-zero exit. File I/O
+// below layers on top of this the way Docker's fallback path layers
+// tar-archive copies on top of ContainerExecCreate/Attach: containerd
+// has no copy-to/copy-from-container RPC of its own, and the optional
+// CRI "ContainerdFS" extension is only present on CRI-enabled builds, so
+// this runs a helper process (tar) inside the sandbox's own task instead.
+func (r *ContainerdRuntime) execCommand(ctx context.Context, id string, cmd []string, stdin io.Reader) ([]byte, error) {
+	result, err := r.Exec(ctx, id, ExecRequest{Command: cmd, Stdin: stdin})
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("%s: %s", strings.Join(cmd, " "), result.Stderr)
+	}
+	return []byte(result.Stdout), nil
+}
+
+// WriteFile writes content to a file in the sandbox by streaming a tar
+// archive of it (built by the shared createTar helper, whose entry names
+// are the file's path and its parent directories relative to "/") into
+// `tar -x` running at the sandbox's root inside its task.
+func (r *ContainerdRuntime) WriteFile(ctx context.Context, id string, path string, content []byte) error {
+	if _, err := r.execCommand(ctx, id, []string{"mkdir", "-p", dirname(path)}, nil); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if _, err := r.execCommand(ctx, id, []string{"tar", "-xf", "-", "-C", "/"}, createTar(path, content)); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads a file from the sandbox by running `tar -c` inside the
+// sandbox's task and unwrapping the resulting archive with the shared
+// extractTar helper.
+func (r *ContainerdRuntime) ReadFile(ctx context.Context, id string, path string) ([]byte, error) {
+	out, err := r.execCommand(ctx, id, []string{"tar", "-cf", "-", "-C", dirname(path), basename(path)}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	content, err := extractTar(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tar archive: %w", err)
+	}
+	return content, nil
+}
+
+// ListFiles lists files in a directory by running `find -maxdepth 1`
+// inside the sandbox's task and parsing its printf-formatted output,
+// since containerd has no ContainerStatPath-style RPC to walk instead.
+func (r *ContainerdRuntime) ListFiles(ctx context.Context, id string, path string) ([]FileInfo, error) {
+	out, err := r.execCommand(ctx, id, []string{
+		"find", path, "-mindepth", "1", "-maxdepth", "1",
+		"-printf", "%f\t%s\t%y\t%T@\n",
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var files []FileInfo
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[1], 10, 64)
+		modUnix, _ := strconv.ParseFloat(fields[3], 64)
+		files = append(files, FileInfo{
+			Name:    fields[0],
+			Path:    path + "/" + fields[0],
+			Size:    size,
+			IsDir:   fields[2] == "d",
+			ModTime: time.Unix(int64(modUnix), 0),
+		})
+	}
+	return files, nil
+}
+
+// DeleteFile deletes a file or directory by running `rm -rf` inside the
+// sandbox's task.
+func (r *ContainerdRuntime) DeleteFile(ctx context.Context, id string, path string) error {
+	if _, err := r.execCommand(ctx, id, []string{"rm", "-rf", path}, nil); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the containerd client connection.
+func (r *ContainerdRuntime) Close() error {
+	return r.client.Close()
+}
+
+// dirname and basename are thin wrappers kept local to this file so its
+// tar-over-exec helpers above read as self-contained shell-path logic
+// rather than reaching back into filepath's platform-dependent behavior
+// for what is always a sandbox-side POSIX path.
+func dirname(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		if i == 0 {
+			return "/"
+		}
+		return path[:i]
+	}
+	return "."
+}
+
+func basename(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// buildExecProcessSpec builds the OCI process spec for an Exec'd command,
+// inheriting the task's own rootfs/mounts but running cmd as a new
+// process with its own working directory and environment, the
+// containerd equivalent of Docker's container.ExecOptions.
+func buildExecProcessSpec(ctx context.Context, task cclient.Task, cmd []string, workDir string, env map[string]string) (*specs.Process, error) {
+	spec, err := task.Spec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task spec: %w", err)
+	}
+
+	process := *spec.Process
+	process.Args = cmd
+	process.Cwd = workDir
+	process.Terminal = false
+
+	if len(env) > 0 {
+		envs := make([]string, 0, len(env))
+		for k, v := range env {
+			envs = append(envs, k+"="+v)
+		}
+		process.Env = envs
+	}
+
+	return &process, nil
+}
@@ -0,0 +1,142 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// skipIfNoContainerd skips the test if no containerd socket is reachable,
+// mirroring skipIfNoDocker.
+func skipIfNoContainerd(t *testing.T) {
+	if os.Getenv("SKIP_CONTAINERD_TESTS") == "1" {
+		t.Skip("Skipping containerd tests")
+	}
+	if _, err := os.Stat(defaultContainerdSocket); err != nil {
+		t.Skipf("containerd socket not found at %s", defaultContainerdSocket)
+	}
+}
+
+func TestContainerdRuntime_Create(t *testing.T) {
+	skipIfNoContainerd(t)
+
+	config := DefaultConfig()
+	config.Image = "docker.io/library/alpine:latest"
+
+	runtime, err := NewContainerdRuntime(config)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	ctx := context.Background()
+
+	sb, err := runtime.Create(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer runtime.Destroy(ctx, sb.ID)
+
+	if sb.ID == "" {
+		t.Error("Sandbox ID should not be empty")
+	}
+	if sb.Status != StatusIdle {
+		t.Errorf("Expected status %s, got %s", StatusIdle, sb.Status)
+	}
+}
+
+func TestContainerdRuntime_Exec(t *testing.T) {
+	skipIfNoContainerd(t)
+
+	config := DefaultConfig()
+	config.Image = "docker.io/library/alpine:latest"
+
+	runtime, err := NewContainerdRuntime(config)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	ctx := context.Background()
+
+	sb, err := runtime.Create(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer runtime.Destroy(ctx, sb.ID)
+
+	result, err := runtime.Exec(ctx, sb.ID, ExecRequest{
+		Command: []string{"echo", "hello world"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute: %v", err)
+	}
+
+	if result.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", result.ExitCode)
+	}
+	if strings.TrimSpace(result.Stdout) != "hello world" {
+		t.Errorf("Expected 'hello world', got %q", result.Stdout)
+	}
+}
+
+func TestContainerdRuntime_FileOperations(t *testing.T) {
+	skipIfNoContainerd(t)
+
+	config := DefaultConfig()
+	config.Image = "docker.io/library/alpine:latest"
+
+	runtime, err := NewContainerdRuntime(config)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	defer runtime.Close()
+
+	ctx := context.Background()
+
+	sb, err := runtime.Create(ctx, config)
+	if err != nil {
+		t.Fatalf("Failed to create sandbox: %v", err)
+	}
+	defer runtime.Destroy(ctx, sb.ID)
+
+	testContent := []byte("Hello, Sandbox!")
+	testPath := "/workspace/test.txt"
+
+	if err := runtime.WriteFile(ctx, sb.ID, testPath, testContent); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	content, err := runtime.ReadFile(ctx, sb.ID, testPath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != string(testContent) {
+		t.Errorf("Content mismatch: expected %q, got %q", testContent, content)
+	}
+
+	if err := runtime.DeleteFile(ctx, sb.ID, testPath); err != nil {
+		t.Fatalf("Failed to delete file: %v", err)
+	}
+}
+
+func TestContainerResourceLimits_CFSQuotaPeriod(t *testing.T) {
+	cases := []struct {
+		nanoCPUs   int64
+		wantQuota  int64
+		wantPeriod uint64
+	}{
+		{nanoCPUs: 0, wantQuota: 0, wantPeriod: defaultCFSPeriod},
+		{nanoCPUs: 1e9, wantQuota: 100000, wantPeriod: defaultCFSPeriod},
+		{nanoCPUs: 2_500_000_000, wantQuota: 250000, wantPeriod: defaultCFSPeriod},
+	}
+
+	for _, c := range cases {
+		limits := containerResourceLimits{NanoCPUs: c.nanoCPUs}
+		quota, period := limits.cfsQuotaPeriod()
+		if quota != c.wantQuota || period != c.wantPeriod {
+			t.Errorf("cfsQuotaPeriod(%d) = (%d, %d), want (%d, %d)", c.nanoCPUs, quota, period, c.wantQuota, c.wantPeriod)
+		}
+	}
+}
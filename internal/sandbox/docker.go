@@ -1,16 +1,20 @@
 package sandbox
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
+	"log"
+	"net"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/docker/docker/api/types/checkpoint"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
@@ -18,12 +22,22 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/google/uuid"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/agent"
+	agentclient "github.com/cloud-sandbox/cloud-sandbox/internal/agent/client"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/sandbox/imagecache"
 )
 
 const (
 	labelPrefix    = "cloud-sandbox"
 	labelSandboxID = labelPrefix + ".sandbox-id"
 	labelManaged   = labelPrefix + ".managed"
+	labelLayerHash = labelPrefix + ".layer-hash"
+
+	// agentPort is the fixed port the in-VM sandbox agent listens on.
+	agentPort = "7000"
 )
 
 // DockerRuntime implements Runtime interface using Docker
@@ -31,13 +45,85 @@ type DockerRuntime struct {
 	client *client.Client
 	config Config
 
+	// ociRuntime, if set, is requested via HostConfig.Runtime for every
+	// container this creates (e.g. "runsc" for GvisorRuntime). Empty uses
+	// whatever OCI runtime the daemon defaults to (runc, or crun under
+	// Podman).
+	ociRuntime string
+
 	mu        sync.RWMutex
 	sandboxes map[string]*Sandbox
+
+	agentMu sync.Mutex
+	agents  map[string]*agentclient.Client
+
+	// ca mints the mTLS certificate pair (and backs the one-time bootstrap
+	// token) every sandbox's agent is started with - see Create and
+	// agentFor. Generated once per DockerRuntime in newDockerRuntime.
+	ca                          *agent.CertAuthority
+	clientCertPEM, clientKeyPEM []byte
+
+	// bootstrapTokens holds each live sandbox's one-time token (see
+	// agent.TokenGate), keyed by sandbox ID, from Create until Destroy.
+	bootstrapTokens map[string]string
+
+	// images, if attached, lets Create bind-mount a prebuilt dependency
+	// layer into a new sandbox instead of installing from scratch.
+	// layerReleases tracks the release func for each sandbox currently
+	// holding a layer reference, so Destroy can give it back.
+	images        *imagecache.Cache
+	layerReleases map[string]func()
+
+	// events, if attached via AttachEventBus, receives sandbox.created,
+	// sandbox.destroyed, and exec.completed notifications.
+	events *events.Bus
+
+	// egressMu guards the lazily-created egress network/proxy sidecar
+	// (see egress.go's ensureEgressProxy) that sandboxes with
+	// Config.AllowedHosts set are routed through.
+	egressMu        sync.Mutex
+	egressNetworkID string
+	egressProxyAddr string
+}
+
+// layerMountTarget is where a cache hit's dependency layer is bind-mounted
+// inside the sandbox. The in-VM agent is responsible for linking whatever
+// it finds there (node_modules, a venv, ...) into the workspace.
+const layerMountTarget = "/opt/cloud-sandbox/layer"
+
+// AttachImageCache wires a content-addressable layer cache into the
+// runtime so Create can skip dependency installs for sandboxes whose
+// DependencyManifests hash to an already-cached layer. Safe to call at
+// most once; a nil cache (the zero value) is a no-op.
+func (r *DockerRuntime) AttachImageCache(cache *imagecache.Cache) {
+	r.images = cache
+}
+
+// AttachEventBus wires the cross-cutting events.Bus into Create, Destroy,
+// and Exec, so operators can subscribe a webhook/Kafka sink to
+// sandbox.created, sandbox.destroyed, and exec.completed. A nil bus (the
+// default) leaves those lifecycle transitions unpublished.
+func (r *DockerRuntime) AttachEventBus(bus *events.Bus) {
+	r.events = bus
 }
 
 // NewDockerRuntime creates a new Docker-based sandbox runtime
 func NewDockerRuntime(config Config) (*DockerRuntime, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	return newDockerRuntime(config, config.RuntimeSocket, "")
+}
+
+// newDockerRuntime is the shared constructor behind DockerRuntime,
+// GvisorRuntime, and PodmanRuntime: all three talk to a Docker-API
+// socket, and differ only in which socket (docker vs. Podman's
+// Docker-API-compatible one) and which OCI runtime they request per
+// container (runc vs. runsc).
+func newDockerRuntime(config Config, socket, ociRuntime string) (*DockerRuntime, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if socket != "" {
+		opts = append(opts, client.WithHost(socket))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create docker client: %w", err)
 	}
@@ -50,13 +136,69 @@ func NewDockerRuntime(config Config) (*DockerRuntime, error) {
 		return nil, fmt.Errorf("failed to connect to docker: %w", err)
 	}
 
+	// Mint a CA and this runtime's own client certificate once, up front,
+	// rather than per sandbox - Create only ever needs to issue a leaf
+	// server certificate off of it. This is the root of trust every agent's
+	// mutual-TLS listener and bootstrap token (see Create, agentFor) chains
+	// back to.
+	ca, err := agent.NewCertAuthority()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent CA: %w", err)
+	}
+	clientCertPEM, clientKeyPEM, err := ca.IssueCert("scheduler")
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue scheduler client certificate: %w", err)
+	}
+
 	return &DockerRuntime{
-		client:    cli,
-		config:    config,
-		sandboxes: make(map[string]*Sandbox),
+		client:          cli,
+		config:          config,
+		ociRuntime:      ociRuntime,
+		sandboxes:       make(map[string]*Sandbox),
+		agents:          make(map[string]*agentclient.Client),
+		layerReleases:   make(map[string]func()),
+		ca:              ca,
+		clientCertPEM:   clientCertPEM,
+		clientKeyPEM:    clientKeyPEM,
+		bootstrapTokens: make(map[string]string),
 	}, nil
 }
 
+// agentFor lazily dials the sandbox's in-VM agent. Sandboxes that have not
+// yet brought up the agent (or whose image predates it) fall back to the
+// shell-based exec path below.
+func (r *DockerRuntime) agentFor(ctx context.Context, sandbox *Sandbox) (*agentclient.Client, error) {
+	r.agentMu.Lock()
+	defer r.agentMu.Unlock()
+
+	if c, ok := r.agents[sandbox.ID]; ok {
+		return c, nil
+	}
+	if sandbox.IP == "" {
+		return nil, fmt.Errorf("sandbox %s has no IP to reach its agent", sandbox.ID)
+	}
+
+	tlsConfig, err := r.ca.ClientTLSConfig(r.clientCertPEM, r.clientKeyPEM, sandbox.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agent client TLS config: %w", err)
+	}
+
+	r.mu.Lock()
+	token := r.bootstrapTokens[sandbox.ID]
+	r.mu.Unlock()
+
+	c, err := agentclient.Dial(ctx, agentclient.Config{
+		Addr:           net.JoinHostPort(sandbox.IP, agentPort),
+		TLSConfig:      tlsConfig,
+		BootstrapToken: token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.agents[sandbox.ID] = c
+	return c, nil
+}
+
 // Create creates a new sandbox container
 func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, error) {
 	// Merge with default config
@@ -80,25 +222,63 @@ func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, er
 		return nil, fmt.Errorf("failed to ensure image: %w", err)
 	}
 
+	// Mint this sandbox's agent server certificate and one-time bootstrap
+	// token up front so both can be handed to the container via its
+	// environment before it ever starts - see agentFor and
+	// cmd/sandbox-agent, which require them for mutual TLS.
+	agentCertPEM, agentKeyPEM, err := r.ca.IssueCert(sandboxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue agent certificate: %w", err)
+	}
+	bootstrapToken, err := agent.NewBootstrapToken(sandboxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate bootstrap token: %w", err)
+	}
+
+	// Look up a prebuilt dependency layer for this sandbox's manifests,
+	// if an image cache is attached and the caller asked for one.
+	var layerHash, layerPath string
+	var releaseLayer func()
+	if r.images != nil && len(config.DependencyManifests) > 0 {
+		hash, err := imagecache.ManifestHash(config.DependencyManifests...)
+		if err != nil {
+			log.Printf("[DockerRuntime] Failed to hash dependency manifests, skipping image cache: %v", err)
+		} else if path, release, ok := r.images.Lookup(hash); ok {
+			layerHash, layerPath, releaseLayer = hash, path, release
+		}
+	}
+
+	labels := map[string]string{
+		labelSandboxID: sandboxID,
+		labelManaged:   "true",
+	}
+	if layerHash != "" {
+		labels[labelLayerHash] = layerHash
+	}
+
 	// Create container config
 	containerConfig := &container.Config{
 		Image:      config.Image,
 		WorkingDir: config.WorkDir,
 		Tty:        false,
 		OpenStdin:  true,
-		Labels: map[string]string{
-			labelSandboxID: sandboxID,
-			labelManaged:   "true",
-		},
+		Labels:     labels,
 		// Keep container running
 		Cmd: []string{"sleep", "infinity"},
+		Env: []string{
+			"AGENT_TLS_CERT=" + string(agentCertPEM),
+			"AGENT_TLS_KEY=" + string(agentKeyPEM),
+			"AGENT_CA_CERT=" + string(r.ca.CertPEM()),
+			"AGENT_BOOTSTRAP_TOKEN=" + bootstrapToken.Token,
+		},
 	}
 
 	// Host config with resource limits
+	limits := newContainerResourceLimits(config)
 	hostConfig := &container.HostConfig{
 		Resources: container.Resources{
-			Memory:   config.MemoryMB * 1024 * 1024,
-			NanoCPUs: int64(config.CPUCount) * 1e9,
+			Memory:   limits.MemoryBytes,
+			NanoCPUs: limits.NanoCPUs,
 		},
 		// Security options
 		SecurityOpt: []string{"no-new-privileges"},
@@ -108,7 +288,7 @@ func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, er
 				Type:   mount.TypeTmpfs,
 				Target: config.WorkDir,
 				TmpfsOptions: &mount.TmpfsOptions{
-					SizeBytes: config.DiskSizeMB * 1024 * 1024,
+					SizeBytes: limits.TmpfsSizeBytes,
 				},
 			},
 		},
@@ -119,11 +299,57 @@ func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, er
 	// Disable network if requested
 	if !config.NetworkEnabled {
 		hostConfig.NetworkMode = "none"
+	} else if len(config.AllowedHosts) > 0 {
+		// Route the sandbox through the egress-proxy sidecar instead of
+		// giving it full bridge networking: it only gets the internal
+		// egress network (no route to the outside world except through
+		// the proxy), and HTTP_PROXY/HTTPS_PROXY point it there.
+		egressNetworkID, proxyURL, err := r.ensureEgressProxy(ctx, config)
+		if err != nil {
+			if releaseLayer != nil {
+				releaseLayer()
+			}
+			return nil, fmt.Errorf("failed to set up egress proxy: %w", err)
+		}
+
+		hostConfig.NetworkMode = container.NetworkMode(egressNetworkID)
+		containerConfig.Env = append(containerConfig.Env,
+			"HTTP_PROXY="+proxyURL,
+			"HTTPS_PROXY="+proxyURL,
+			"NO_PROXY=localhost,127.0.0.1",
+		)
+	}
+
+	if len(config.DNSServers) > 0 {
+		hostConfig.DNS = config.DNSServers
+	}
+
+	if r.ociRuntime != "" {
+		hostConfig.Runtime = r.ociRuntime
+	}
+
+	if err := applySecurity(hostConfig, config.Security); err != nil {
+		if releaseLayer != nil {
+			releaseLayer()
+		}
+		return nil, fmt.Errorf("invalid security profile: %w", err)
+	}
+
+	if layerPath != "" {
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   layerPath,
+			Target:   layerMountTarget,
+			ReadOnly: true,
+		})
 	}
 
 	// Create container
 	resp, err := r.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "sandbox-"+sandboxID)
 	if err != nil {
+		if releaseLayer != nil {
+			releaseLayer()
+		}
 		return nil, fmt.Errorf("failed to create container: %w", err)
 	}
 
@@ -131,6 +357,9 @@ func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, er
 	if err := r.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		// Clean up on failure
 		r.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		if releaseLayer != nil {
+			releaseLayer()
+		}
 		return nil, fmt.Errorf("failed to start container: %w", err)
 	}
 
@@ -138,6 +367,9 @@ func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, er
 	info, err := r.client.ContainerInspect(ctx, resp.ID)
 	if err != nil {
 		r.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+		if releaseLayer != nil {
+			releaseLayer()
+		}
 		return nil, fmt.Errorf("failed to inspect container: %w", err)
 	}
 
@@ -162,8 +394,20 @@ func (r *DockerRuntime) Create(ctx context.Context, config Config) (*Sandbox, er
 
 	r.mu.Lock()
 	r.sandboxes[sandboxID] = sandbox
+	if releaseLayer != nil {
+		r.layerReleases[sandboxID] = releaseLayer
+	}
+	r.bootstrapTokens[sandboxID] = bootstrapToken.Token
 	r.mu.Unlock()
 
+	if r.events != nil {
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxCreated,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: sandboxID,
+		})
+	}
+
 	return sandbox, nil
 }
 
@@ -212,6 +456,11 @@ func (r *DockerRuntime) Destroy(ctx context.Context, id string) error {
 		return err
 	}
 
+	oomKilled := false
+	if info, inspectErr := r.client.ContainerInspect(ctx, sandbox.ContainerID); inspectErr == nil {
+		oomKilled = info.State != nil && info.State.OOMKilled
+	}
+
 	if err := r.client.ContainerRemove(ctx, sandbox.ContainerID, container.RemoveOptions{
 		Force:         true,
 		RemoveVolumes: true,
@@ -221,11 +470,118 @@ func (r *DockerRuntime) Destroy(ctx context.Context, id string) error {
 
 	r.mu.Lock()
 	delete(r.sandboxes, id)
+	release, hadLayer := r.layerReleases[id]
+	delete(r.layerReleases, id)
+	delete(r.bootstrapTokens, id)
+	r.mu.Unlock()
+	if hadLayer {
+		release()
+	}
+
+	r.agentMu.Lock()
+	if agent, ok := r.agents[id]; ok {
+		agent.Close()
+		delete(r.agents, id)
+	}
+	r.agentMu.Unlock()
+
+	if r.events != nil {
+		if oomKilled {
+			r.events.PublishAsync(events.Event{
+				Type:      events.TypeSandboxOOM,
+				UserID:    auth.GetUserIDFromContext(ctx),
+				SandboxID: id,
+			})
+		}
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxDestroyed,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: id,
+		})
+	}
+
+	return nil
+}
+
+// Checkpoint freezes sandbox id's container via Docker's checkpoint API
+// (which wraps CRIU), stopping it so it stops consuming RAM/CPU while
+// still idle. name becomes the checkpoint's identifier for a later
+// Restore.
+func (r *DockerRuntime) Checkpoint(ctx context.Context, id, name string) error {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.CheckpointCreate(ctx, sandbox.ContainerID, checkpoint.CreateOptions{
+		CheckpointID:  name,
+		CheckpointDir: r.config.CheckpointDir,
+		Exit:          true,
+	}); err != nil {
+		return fmt.Errorf("failed to checkpoint sandbox: %w", err)
+	}
+
+	r.mu.Lock()
+	sandbox.Status = StatusCheckpointed
+	sandbox.CheckpointName = name
+	sandbox.CheckpointedAt = time.Now()
 	r.mu.Unlock()
 
+	log.Printf("[Docker] Checkpointed sandbox %s as %q", id, name)
 	return nil
 }
 
+// Restore resumes sandbox id's container from the checkpoint name,
+// previously saved by Checkpoint, instead of starting it from scratch.
+func (r *DockerRuntime) Restore(ctx context.Context, id, name string) error {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.ContainerStart(ctx, sandbox.ContainerID, container.StartOptions{
+		CheckpointID:  name,
+		CheckpointDir: r.config.CheckpointDir,
+	}); err != nil {
+		return fmt.Errorf("failed to restore sandbox from checkpoint: %w", err)
+	}
+
+	r.mu.Lock()
+	sandbox.Status = StatusIdle
+	sandbox.LastActiveAt = time.Now()
+	sandbox.CheckpointName = ""
+	r.mu.Unlock()
+
+	log.Printf("[Docker] Restored sandbox %s from checkpoint %q", id, name)
+	return nil
+}
+
+// Commit saves sandbox id's current container filesystem as a new image
+// via Docker's commit API, imageName becoming its tag. handleSnapshot and
+// handleFork both build on this: a snapshot tags it for later reuse via
+// Pool.AcquireFromSnapshot, while a fork commits the parent on the fly so
+// the child sandbox starts from an identical filesystem without touching
+// the parent's own container. Unlike Checkpoint/Restore, which freeze and
+// resume CRIU process state, this only ever captures the filesystem layer
+// - the resulting image boots fresh, like any other Config.Image.
+func (r *DockerRuntime) Commit(ctx context.Context, id string, imageName string) (string, error) {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := r.client.ContainerCommit(ctx, sandbox.ContainerID, container.CommitOptions{
+		Reference: imageName,
+		Pause:     true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to commit sandbox: %w", err)
+	}
+
+	log.Printf("[Docker] Committed sandbox %s as image %q (%s)", id, imageName, resp.ID)
+	return imageName, nil
+}
+
 // Get returns sandbox by ID
 func (r *DockerRuntime) Get(ctx context.Context, id string) (*Sandbox, error) {
 	r.mu.RLock()
@@ -316,6 +672,17 @@ func (r *DockerRuntime) Exec(ctx context.Context, id string, req ExecRequest) (*
 		return nil, fmt.Errorf("failed to create exec: %w", err)
 	}
 
+	if r.events != nil {
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeExecStarted,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: id,
+			Data: map[string]interface{}{
+				"command": cmd,
+			},
+		})
+	}
+
 	// Attach to exec
 	attachResp, err := r.client.ContainerExecAttach(execCtx, execResp.ID, container.ExecAttachOptions{})
 	if err != nil {
@@ -362,20 +729,80 @@ func (r *DockerRuntime) Exec(ctx context.Context, id string, req ExecRequest) (*
 		return nil, fmt.Errorf("failed to inspect exec: %w", err)
 	}
 
-	return &ExecResult{
+	result := &ExecResult{
 		ExitCode: inspectResp.ExitCode,
 		Stdout:   stdout.String(),
 		Stderr:   stderr.String(),
 		Duration: duration,
 		TimedOut: timedOut,
-	}, nil
+	}
+
+	if r.events != nil {
+		r.events.PublishAsync(events.Event{
+			Type:      events.TypeExecCompleted,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: id,
+			Data: map[string]interface{}{
+				"exit_code": result.ExitCode,
+				"duration":  result.Duration.String(),
+				"timed_out": result.TimedOut,
+			},
+		})
+		if result.TimedOut {
+			r.events.PublishAsync(events.Event{
+				Type:      events.TypeSandboxTimeout,
+				UserID:    auth.GetUserIDFromContext(ctx),
+				SandboxID: id,
+				Data: map[string]interface{}{
+					"duration": result.Duration.String(),
+				},
+			})
+		}
+	}
+
+	return result, nil
 }
 
-// WriteFile writes content to a file in the sandbox
+// WriteFile writes content to a file in the sandbox via the agent's
+// streaming FileWrite RPC, falling back to the Docker archive (tar) API's
+// CopyToContainer if the agent isn't reachable yet.
 func (r *DockerRuntime) WriteFile(ctx context.Context, id string, path string, content []byte) error {
+	return r.WriteFileStream(ctx, id, path, bytes.NewReader(content), 0644)
+}
+
+// WriteFileStream writes src to path in the sandbox: it prefers the
+// agent's streaming FileWrite RPC, and falls back to a single-entry tar
+// archive sent through Docker's CopyToContainer if the agent isn't
+// reachable yet. Both paths read all of src into memory before writing
+// anything: the agent client takes the content as one byte slice, and
+// the tar header needs the entry's size up front. mode sets the archived
+// entry's permission bits; zero defaults to 0644.
+func (r *DockerRuntime) WriteFileStream(ctx context.Context, id string, path string, src io.Reader, mode os.FileMode) error {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if agent, agentErr := r.agentFor(ctx, sandbox); agentErr == nil {
+		content, err := io.ReadAll(src)
+		if err != nil {
+			return fmt.Errorf("failed to read source: %w", err)
+		}
+		if err := agent.WriteFile(ctx, path, content); err != nil {
+			return err
+		}
+		r.publishFileWritten(ctx, id, path, len(content))
+		return nil
+	}
+
+	if mode == 0 {
+		mode = 0644
+	}
+
 	dir := filepath.Dir(path)
 
-	// First create parent directories
+	// CopyToContainer requires the destination directory to already
+	// exist, unlike the shell fallback this replaces.
 	result, err := r.Exec(ctx, id, ExecRequest{
 		Command: []string{"mkdir", "-p", dir},
 	})
@@ -386,75 +813,307 @@ func (r *DockerRuntime) WriteFile(ctx context.Context, id string, path string, c
 		return fmt.Errorf("failed to create directory: %s", result.Stderr)
 	}
 
-	// Use base64 encoding to handle binary content safely
-	encoded := base64.StdEncoding.EncodeToString(content)
-
-	// Write file using sh to handle the base64 decoding
-	result, err = r.Exec(ctx, id, ExecRequest{
-		Command: []string{"sh", "-c", fmt.Sprintf("echo '%s' | base64 -d > '%s'", encoded, path)},
-	})
+	content, err := io.ReadAll(src)
 	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return fmt.Errorf("failed to read source: %w", err)
 	}
-	if result.ExitCode != 0 {
-		return fmt.Errorf("failed to write file: %s", result.Stderr)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: filepath.Base(path),
+		Mode: int64(mode.Perm()),
+		Size: int64(len(content)),
+	}); err != nil {
+		return fmt.Errorf("failed to write tar header: %w", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to write tar content: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close tar archive: %w", err)
 	}
 
+	if err := r.client.CopyToContainer(ctx, sandbox.ContainerID, dir, &buf, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	r.publishFileWritten(ctx, id, path, len(content))
 	return nil
 }
 
-// ReadFile reads a file from the sandbox
-func (r *DockerRuntime) ReadFile(ctx context.Context, id string, path string) ([]byte, error) {
-	// First check if file exists
-	checkResult, err := r.Exec(ctx, id, ExecRequest{
-		Command: []string{"test", "-f", path},
+// publishFileWritten emits a file.written event after a successful
+// WriteFileStream, regardless of which of its two code paths (agent RPC or
+// CopyToContainer) handled the write.
+func (r *DockerRuntime) publishFileWritten(ctx context.Context, id, path string, size int) {
+	if r.events == nil {
+		return
+	}
+	r.events.PublishAsync(events.Event{
+		Type:      events.TypeFileWritten,
+		UserID:    auth.GetUserIDFromContext(ctx),
+		SandboxID: id,
+		Data: map[string]interface{}{
+			"path": path,
+			"size": size,
+		},
 	})
+}
+
+// ReadFile reads a file from the sandbox via the agent's streaming FileRead
+// RPC, falling back to the Docker archive (tar) API's CopyFromContainer if
+// the agent isn't reachable yet.
+func (r *DockerRuntime) ReadFile(ctx context.Context, id string, path string) ([]byte, error) {
+	rc, err := r.ReadFileStream(ctx, id, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check file: %w", err)
+		return nil, err
 	}
-	if checkResult.ExitCode != 0 {
-		return nil, fmt.Errorf("file not found: %s", path)
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// ReadFileStream opens path in the sandbox for a streamed read: it prefers
+// the agent's streaming FileRead RPC, and falls back to CopyFromContainer,
+// unwrapping its tar archive down to the single file's content, if the
+// agent isn't reachable yet.
+func (r *DockerRuntime) ReadFileStream(ctx context.Context, id string, path string) (io.ReadCloser, error) {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	// Use base64 encoding to handle binary content safely
-	result, err := r.Exec(ctx, id, ExecRequest{
-		Command: []string{"sh", "-c", fmt.Sprintf("cat '%s' | base64", path)},
-	})
+	if agent, agentErr := r.agentFor(ctx, sandbox); agentErr == nil {
+		content, err := agent.ReadFile(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	reader, _, err := r.client.CopyFromContainer(ctx, sandbox.ContainerID, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	if result.ExitCode != 0 {
-		return nil, fmt.Errorf("failed to read file: %s", result.Stderr)
+
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		reader.Close()
+		if err == io.EOF {
+			return nil, fmt.Errorf("file not found: %s", path)
+		}
+		return nil, fmt.Errorf("failed to read tar archive: %w", err)
 	}
 
-	// Decode base64 content
-	content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(result.Stdout))
+	return &tarEntryReadCloser{Reader: tr, closer: reader}, nil
+}
+
+// tarEntryReadCloser adapts a tar.Reader already positioned at a single
+// entry so ReadFileStream's caller gets a plain io.ReadCloser without
+// seeing the surrounding tar framing, while Close still releases the
+// underlying CopyFromContainer stream.
+type tarEntryReadCloser struct {
+	*tar.Reader
+	closer io.Closer
+}
+
+func (t *tarEntryReadCloser) Close() error {
+	return t.closer.Close()
+}
+
+// PutArchive extracts src, a tar archive (optionally compressed - Docker's
+// archive API accepts identity, gzip, bzip2, or xz) directly into dirPath
+// inside the sandbox via CopyToContainer. Unlike WriteFileStream, which
+// only ever writes one file, this is the entry point for restoring a whole
+// directory tree (a cloned repo, a node_modules) in one streamed request
+// instead of one WriteFile call per entry.
+func (r *DockerRuntime) PutArchive(ctx context.Context, id string, dirPath string, src io.Reader) error {
+	sandbox, err := r.Get(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode file content: %w", err)
+		return err
 	}
 
-	return content, nil
+	if err := r.client.CopyToContainer(ctx, sandbox.ContainerID, dirPath, src, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to put archive: %w", err)
+	}
+	return nil
+}
+
+// GetArchive streams dirPath out of the sandbox as an uncompressed tar
+// archive via CopyFromContainer, the directory-tree counterpart to
+// ReadFileStream's single-file unwrap. The caller must Close the returned
+// reader.
+func (r *DockerRuntime) GetArchive(ctx context.Context, id string, dirPath string) (io.ReadCloser, error) {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, _, err := r.client.CopyFromContainer(ctx, sandbox.ContainerID, dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+	return reader, nil
 }
 
-// ListFiles lists files in a directory
+// ListFiles lists files in a directory using the agent's native
+// os.ReadDir-backed FileList RPC, falling back to ContainerStatPath plus a
+// CopyFromContainer archive walk if the agent isn't reachable yet.
 func (r *DockerRuntime) ListFiles(ctx context.Context, id string, path string) ([]FileInfo, error) {
-	// Use basic ls -la which works with both GNU coreutils and BusyBox
-	result, err := r.Exec(ctx, id, ExecRequest{
-		Command: []string{"ls", "-la", path},
-	})
+	sandbox, err := r.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if agent, agentErr := r.agentFor(ctx, sandbox); agentErr == nil {
+		entries, err := agent.ListFiles(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+
+		files := make([]FileInfo, 0, len(entries))
+		for _, e := range entries {
+			files = append(files, FileInfo{
+				Name:    e.Name,
+				Path:    e.Path,
+				Size:    e.Size,
+				IsDir:   e.IsDir,
+				ModTime: e.ModTime,
+			})
+		}
+		return files, nil
+	}
+
+	stat, err := r.client.ContainerStatPath(ctx, sandbox.ContainerID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
+	}
+	if !stat.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+
+	reader, _, err := r.client.CopyFromContainer(ctx, sandbox.ContainerID, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	defer reader.Close()
+
+	// The archive is rooted at path's own basename and includes that
+	// entry itself; keep only its immediate children to match
+	// os.ReadDir's non-recursive semantics.
+	files := make([]FileInfo, 0)
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		name := strings.Trim(hdr.Name, "/")
+		slash := strings.Index(name, "/")
+		if slash < 0 {
+			continue
+		}
+		rel := name[slash+1:]
+		if rel == "" || strings.Contains(rel, "/") {
+			continue
+		}
+
+		files = append(files, FileInfo{
+			Name:    rel,
+			Path:    filepath.Join(path, rel),
+			Size:    hdr.Size,
+			IsDir:   hdr.Typeflag == tar.TypeDir,
+			ModTime: hdr.ModTime,
+		})
+	}
+
+	return files, nil
+}
+
+// UploadDir tars localPath's directory tree and streams it into the
+// sandbox at remotePath through a single CopyToContainer call, instead of
+// one WriteFile round trip per file.
+func (r *DockerRuntime) UploadDir(ctx context.Context, id string, localPath, remotePath string) error {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	walkErr := filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if walkErr != nil {
+		return fmt.Errorf("failed to build upload archive: %w", walkErr)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close upload archive: %w", err)
+	}
+
+	result, err := r.Exec(ctx, id, ExecRequest{
+		Command: []string{"mkdir", "-p", remotePath},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create remote directory: %w", err)
+	}
 	if result.ExitCode != 0 {
-		return nil, fmt.Errorf("failed to list files: %s", result.Stderr)
+		return fmt.Errorf("failed to create remote directory: %s", result.Stderr)
+	}
+
+	if err := r.client.CopyToContainer(ctx, sandbox.ContainerID, remotePath, &buf, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to upload directory: %w", err)
 	}
 
-	return parseLsOutput(result.Stdout, path), nil
+	return nil
 }
 
-// DeleteFile deletes a file or directory
+// DeleteFile deletes a file or directory via the agent, falling back to
+// shelling out to rm if the agent isn't reachable yet.
 func (r *DockerRuntime) DeleteFile(ctx context.Context, id string, path string) error {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if agent, agentErr := r.agentFor(ctx, sandbox); agentErr == nil {
+		return agent.DeleteFile(ctx, path)
+	}
+
 	result, err := r.Exec(ctx, id, ExecRequest{
 		Command: []string{"rm", "-rf", path},
 	})
@@ -562,6 +1221,13 @@ func (r *DockerRuntime) SyncFromDocker(ctx context.Context) error {
 
 // Close closes the Docker client
 func (r *DockerRuntime) Close() error {
+	r.agentMu.Lock()
+	for id, agent := range r.agents {
+		agent.Close()
+		delete(r.agents, id)
+	}
+	r.agentMu.Unlock()
+
 	return r.client.Close()
 }
 
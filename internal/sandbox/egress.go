@@ -0,0 +1,156 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+)
+
+const (
+	// egressNetworkName is the Docker network every sandbox with
+	// AllowedHosts set is attached to instead of the default bridge.
+	// It's created with Internal: true, so members have no route to the
+	// outside world except through egressProxyContainerName, which sits
+	// on this network *and* the default bridge.
+	egressNetworkName = "cloud-sandbox-egress"
+
+	// egressProxyContainerName is the sidecar container egress-filtered
+	// sandboxes point HTTP_PROXY/HTTPS_PROXY at. Its alias on
+	// egressNetworkName (see ensureEgressProxy) is what sandboxes
+	// actually resolve, so the container name itself only matters for
+	// finding/reusing it across Create calls.
+	egressProxyContainerName = "cloud-sandbox-egress-proxy"
+
+	// egressProxyAlias is the hostname sandboxes resolve on
+	// egressNetworkName to reach the sidecar.
+	egressProxyAlias = "egress-proxy"
+
+	// egressProxyPort is the port cmd/egress-proxy listens on by default.
+	egressProxyPort = "3128"
+
+	// defaultEgressProxyImage is used when Config.EgressProxyImage is
+	// empty.
+	defaultEgressProxyImage = "cloud-sandbox/egress-proxy:latest"
+
+	labelEgressProxy = labelPrefix + ".egress-proxy"
+)
+
+// ensureEgressProxy makes sure the egress network and its sidecar proxy
+// container exist (creating them on first use, reusing them on every
+// later call) and returns the proxy URL sandboxes on that network should
+// set HTTP_PROXY/HTTPS_PROXY to. It's called from Create only when
+// config.AllowedHosts is non-empty.
+func (r *DockerRuntime) ensureEgressProxy(ctx context.Context, config Config) (networkID, proxyURL string, err error) {
+	r.egressMu.Lock()
+	defer r.egressMu.Unlock()
+
+	if r.egressNetworkID != "" && r.egressProxyAddr != "" {
+		return r.egressNetworkID, r.egressProxyAddr, nil
+	}
+
+	networkID, err = r.ensureEgressNetwork(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := r.ensureEgressProxyContainer(ctx, config, networkID); err != nil {
+		return "", "", err
+	}
+
+	r.egressNetworkID = networkID
+	r.egressProxyAddr = "http://" + egressProxyAlias + ":" + egressProxyPort
+	return r.egressNetworkID, r.egressProxyAddr, nil
+}
+
+// ensureEgressNetwork returns egressNetworkName's ID, creating it as an
+// internal-only bridge network if it doesn't exist yet.
+func (r *DockerRuntime) ensureEgressNetwork(ctx context.Context) (string, error) {
+	networks, err := r.client.NetworkList(ctx, network.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("name", egressNetworkName)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range networks {
+		if n.Name == egressNetworkName {
+			return n.ID, nil
+		}
+	}
+
+	resp, err := r.client.NetworkCreate(ctx, egressNetworkName, network.CreateOptions{
+		Driver:   "bridge",
+		Internal: true,
+		Labels:   map[string]string{labelManaged: "true"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create egress network: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// ensureEgressProxyContainer starts cmd/egress-proxy as a sidecar, if one
+// isn't already running: it stays on the default bridge (so it can reach
+// the allowlisted hosts) and is additionally connected to egressNetworkID
+// under egressProxyAlias, which is the only thing egress-filtered
+// sandboxes can resolve on that network.
+func (r *DockerRuntime) ensureEgressProxyContainer(ctx context.Context, config Config, egressNetworkID string) error {
+	existing, err := r.client.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelEgressProxy+"=true")),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list egress proxy containers: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	image := config.EgressProxyImage
+	if image == "" {
+		image = defaultEgressProxyImage
+	}
+	if err := r.ensureImage(ctx, image); err != nil {
+		return fmt.Errorf("failed to ensure egress proxy image: %w", err)
+	}
+
+	ports := make([]string, 0, len(config.AllowedPorts))
+	for _, p := range config.AllowedPorts {
+		ports = append(ports, strconv.Itoa(p))
+	}
+
+	containerConfig := &container.Config{
+		Image: image,
+		Env: []string{
+			"ALLOWED_HOSTS=" + strings.Join(config.AllowedHosts, ","),
+			"ALLOWED_PORTS=" + strings.Join(ports, ","),
+		},
+		Labels: map[string]string{
+			labelEgressProxy: "true",
+			labelManaged:     "true",
+		},
+	}
+
+	resp, err := r.client.ContainerCreate(ctx, containerConfig, &container.HostConfig{
+		RestartPolicy: container.RestartPolicy{Name: "always"},
+	}, nil, nil, egressProxyContainerName)
+	if err != nil {
+		return fmt.Errorf("failed to create egress proxy container: %w", err)
+	}
+
+	if err := r.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start egress proxy container: %w", err)
+	}
+
+	if err := r.client.NetworkConnect(ctx, egressNetworkID, resp.ID, &network.EndpointSettings{
+		Aliases: []string{egressProxyAlias},
+	}); err != nil {
+		return fmt.Errorf("failed to attach egress proxy to egress network: %w", err)
+	}
+
+	return nil
+}
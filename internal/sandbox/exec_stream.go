@@ -0,0 +1,250 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+)
+
+// execSignalBytes maps the POSIX signal names Signal accepts to the
+// control byte a process attached to a terminal would see if the user
+// typed the corresponding key. Docker's exec API has no channel to
+// deliver a real signal to an exec'd process, so this is the same
+// mechanism an interactive `docker exec -it` session relies on.
+var execSignalBytes = map[string]byte{
+	"INT":  0x03, // Ctrl-C
+	"QUIT": 0x1c, // Ctrl-\
+}
+
+// ExecStream starts a command in a sandbox and returns a live
+// ExecSession for interacting with it across multiple reads/writes,
+// instead of Exec's single buffered round trip. Set req.TTY for programs
+// that behave differently attached to a terminal (REPL prompts, line
+// buffering, isatty(3) checks).
+func (r *DockerRuntime) ExecStream(ctx context.Context, id string, req ExecRequest) (ExecSession, error) {
+	sandbox, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	sandbox.Status = StatusActive
+	sandbox.LastActiveAt = time.Now()
+	r.mu.Unlock()
+
+	var cmd []string
+	if len(req.Command) > 0 {
+		cmd = req.Command
+	} else {
+		cmd = r.buildCommand(req.Language, req.Code)
+	}
+
+	workDir := req.WorkDir
+	if workDir == "" {
+		workDir = r.config.WorkDir
+	}
+
+	execConfig := container.ExecOptions{
+		Cmd:          cmd,
+		WorkingDir:   workDir,
+		Tty:          req.TTY,
+		AttachStdout: true,
+		AttachStderr: true,
+		AttachStdin:  true,
+	}
+	if len(req.Env) > 0 {
+		env := make([]string, 0, len(req.Env))
+		for k, v := range req.Env {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+		execConfig.Env = env
+	}
+
+	var sessionCtx context.Context
+	var cancel context.CancelFunc
+	if req.Timeout > 0 {
+		sessionCtx, cancel = context.WithTimeout(context.Background(), req.Timeout)
+	} else {
+		sessionCtx, cancel = context.WithCancel(context.Background())
+	}
+
+	execResp, err := r.client.ContainerExecCreate(ctx, sandbox.ContainerID, execConfig)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	if req.TTY {
+		rows, cols := req.InitialRows, req.InitialCols
+		if rows == 0 {
+			rows = 24
+		}
+		if cols == 0 {
+			cols = 80
+		}
+		if err := r.client.ContainerExecResize(ctx, execResp.ID, container.ResizeOptions{Height: rows, Width: cols}); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to size pty: %w", err)
+		}
+	}
+
+	attachResp, err := r.client.ContainerExecAttach(ctx, execResp.ID, container.ExecAttachOptions{Tty: req.TTY})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+
+	sess := &dockerExecSession{
+		runtime:   r,
+		sandboxID: id,
+		execID:    execResp.ID,
+		userID:    auth.GetUserIDFromContext(ctx),
+		conn:      attachResp,
+		tty:       req.TTY,
+		ctx:       sessionCtx,
+		cancel:    cancel,
+		startTime: time.Now(),
+		done:      make(chan struct{}),
+		stdout:    stdoutR,
+		stderr:    stderrR,
+	}
+
+	go sess.copyOutput(stdoutW, stderrW)
+
+	return sess, nil
+}
+
+// dockerExecSession implements ExecSession around a Docker
+// ContainerExecAttach connection.
+type dockerExecSession struct {
+	runtime   *DockerRuntime
+	sandboxID string
+	execID    string
+	userID    string
+	conn      types.HijackedResponse
+	tty       bool
+
+	stdout io.Reader
+	stderr io.Reader
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	startTime time.Time
+	done      chan struct{}
+
+	waitOnce      sync.Once
+	connCloseOnce sync.Once
+	result        *ExecResult
+	waitErr       error
+}
+
+// closeConn closes the underlying exec connection at most once; Wait may
+// need to close it early (to unblock copyOutput on a timeout) and then
+// again unconditionally once the process has exited.
+func (s *dockerExecSession) closeConn() {
+	s.connCloseOnce.Do(func() { s.conn.Close() })
+}
+
+// copyOutput demultiplexes the exec connection into the session's stdout/
+// stderr pipes until the process exits or the connection is closed by
+// Wait, then signals done. TTY sessions carry stdout and stderr combined
+// on a single stream, so stderr's pipe is closed immediately instead.
+func (s *dockerExecSession) copyOutput(stdoutW, stderrW *io.PipeWriter) {
+	var err error
+	if s.tty {
+		stderrW.Close()
+		_, err = io.Copy(stdoutW, s.conn.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdoutW, stderrW, s.conn.Reader)
+		stderrW.CloseWithError(err)
+	}
+	stdoutW.CloseWithError(err)
+	close(s.done)
+}
+
+func (s *dockerExecSession) Stdout() io.Reader { return s.stdout }
+func (s *dockerExecSession) Stderr() io.Reader { return s.stderr }
+func (s *dockerExecSession) Stdin() io.Writer  { return s.conn.Conn }
+
+// Resize adjusts the session's PTY dimensions via ContainerExecResize.
+func (s *dockerExecSession) Resize(rows, cols uint) error {
+	if !s.tty {
+		return fmt.Errorf("cannot resize a non-TTY exec session")
+	}
+	return s.runtime.client.ContainerExecResize(context.Background(), s.execID, container.ResizeOptions{Height: rows, Width: cols})
+}
+
+// Signal writes sig's control-character equivalent to stdin; see
+// execSignalBytes.
+func (s *dockerExecSession) Signal(sig string) error {
+	b, ok := execSignalBytes[strings.ToUpper(sig)]
+	if !ok {
+		return fmt.Errorf("signal %q is not supported: Docker exec has no out-of-band signal channel, only control-character equivalents for INT and QUIT", sig)
+	}
+	_, err := s.conn.Conn.Write([]byte{b})
+	return err
+}
+
+// Wait blocks until the process exits (or the session's context - bound
+// to ExecRequest.Timeout, if set - expires) and returns its result.
+func (s *dockerExecSession) Wait() (*ExecResult, error) {
+	s.waitOnce.Do(func() {
+		select {
+		case <-s.done:
+		case <-s.ctx.Done():
+			// Unblock copyOutput's read off s.conn.Reader; it will close
+			// done once it observes the connection going away.
+			s.closeConn()
+			<-s.done
+		}
+		s.closeConn()
+
+		inspectResp, err := s.runtime.client.ContainerExecInspect(context.Background(), s.execID)
+		if err != nil {
+			s.waitErr = fmt.Errorf("failed to inspect exec: %w", err)
+		} else {
+			s.result = &ExecResult{
+				ExitCode: inspectResp.ExitCode,
+				Duration: time.Since(s.startTime),
+				TimedOut: s.ctx.Err() == context.DeadlineExceeded,
+			}
+
+			if s.runtime.events != nil {
+				s.runtime.events.PublishAsync(events.Event{
+					Type:      events.TypeExecCompleted,
+					UserID:    s.userID,
+					SandboxID: s.sandboxID,
+					Data: map[string]interface{}{
+						"exit_code": s.result.ExitCode,
+						"duration":  s.result.Duration.String(),
+						"timed_out": s.result.TimedOut,
+					},
+				})
+			}
+		}
+
+		s.cancel()
+
+		s.runtime.mu.Lock()
+		if sb, ok := s.runtime.sandboxes[s.sandboxID]; ok {
+			sb.Status = StatusIdle
+		}
+		s.runtime.mu.Unlock()
+	})
+
+	return s.result, s.waitErr
+}
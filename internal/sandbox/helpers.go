@@ -5,9 +5,7 @@ import (
 	"bytes"
 	"io"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"time"
 )
 
 // createTar creates a tar archive containing a single file
@@ -79,53 +77,3 @@ func extractTar(reader io.Reader) ([]byte, error) {
 
 	return nil, io.EOF
 }
-
-// parseLsOutput parses the output of ls -la command
-// Supports both GNU coreutils and BusyBox ls output formats
-func parseLsOutput(output string, basePath string) []FileInfo {
-	var files []FileInfo
-
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "total") {
-			continue
-		}
-
-		// Parse ls -la output
-		// GNU format: -rw-r--r-- 1 root root 1234 Jan 15 10:30 filename
-		// BusyBox format: -rw-r--r--    1 root     root          1234 Jan 15 10:30 filename
-		fields := strings.Fields(line)
-		if len(fields) < 8 {
-			continue
-		}
-
-		// The filename is always the last field
-		name := fields[len(fields)-1]
-
-		// Skip . and ..
-		if name == "." || name == ".." {
-			continue
-		}
-
-		perms := fields[0]
-		isDir := strings.HasPrefix(perms, "d")
-
-		// Size is at index 4
-		size, _ := strconv.ParseInt(fields[4], 10, 64)
-
-		// Parse date/time (fields 5, 6, 7 are typically: Month Day Time/Year)
-		// We'll use current time as default since parsing ls date format is complex
-		modTime := time.Now()
-
-		files = append(files, FileInfo{
-			Name:    name,
-			Path:    filepath.Join(basePath, name),
-			Size:    size,
-			IsDir:   isDir,
-			ModTime: modTime,
-		})
-	}
-
-	return files
-}
@@ -0,0 +1,391 @@
+// Package imagecache implements a content-addressable cache of prebuilt
+// dependency layers (a node_modules tree, a Python venv, ...) keyed by the
+// SHA-256 of the manifest that produced them (package-lock.json, go.sum,
+// requirements.txt, ...). A runtime looks a manifest's hash up before
+// building a sandbox; on a hit it can bind-mount the cached layer straight
+// in instead of re-running the install.
+package imagecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
+)
+
+// Config holds image cache configuration.
+type Config struct {
+	// BaseDir is where committed layers and staging directories live.
+	// Each committed layer is stored at BaseDir/<hash>.
+	BaseDir string
+
+	// QuotaBytes bounds the total on-disk size of committed layers. GC
+	// evicts the least-recently-used zero-reference layers once the
+	// cache exceeds this, so a long-lived host doesn't fill its disk
+	// with layers nobody's built from in months.
+	QuotaBytes int64
+
+	// Builder produces a fresh layer for a manifest on a Prewarm miss:
+	// it's handed an empty staging directory and must populate it (e.g.
+	// copy the manifest in and run `npm install --prefix stageDir`).
+	// Left nil, Prewarm fails instead of guessing how to build a layer.
+	Builder func(ctx context.Context, manifestPath, stageDir string) error
+
+	// Metrics, if set, receives cache hit/miss/eviction counters. The
+	// cache does not set the size gauges itself; Stats returns the
+	// numbers behind them. Left nil, metrics are skipped.
+	Metrics *metrics.Metrics
+}
+
+// DefaultConfig returns default image cache configuration.
+func DefaultConfig() Config {
+	return Config{
+		BaseDir:    "/var/lib/cloud-sandbox/imagecache",
+		QuotaBytes: 20 * 1024 * 1024 * 1024, // 20GB
+	}
+}
+
+const stagingDirName = ".staging"
+
+// entry tracks one committed layer.
+type entry struct {
+	hash     string
+	path     string
+	size     int64
+	refCount int
+	lastUsed time.Time
+}
+
+// Cache is a reference-counted, content-addressable store of prebuilt
+// dependency layers.
+type Cache struct {
+	config Config
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	total   int64
+}
+
+// NewCache creates a cache rooted at config.BaseDir, loading any layers
+// already committed there by a previous process so the cache survives
+// restarts.
+func NewCache(config Config) (*Cache, error) {
+	if config.BaseDir == "" {
+		config.BaseDir = DefaultConfig().BaseDir
+	}
+	if config.QuotaBytes <= 0 {
+		config.QuotaBytes = DefaultConfig().QuotaBytes
+	}
+
+	if err := os.MkdirAll(filepath.Join(config.BaseDir, stagingDirName), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image cache dir: %w", err)
+	}
+
+	c := &Cache{
+		config:  config,
+		entries: make(map[string]*entry),
+	}
+
+	if err := c.loadExisting(); err != nil {
+		return nil, fmt.Errorf("failed to load existing image cache: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *Cache) loadExisting() error {
+	dirEntries, err := os.ReadDir(c.config.BaseDir)
+	if err != nil {
+		return err
+	}
+
+	for _, de := range dirEntries {
+		if !de.IsDir() || de.Name() == stagingDirName {
+			continue
+		}
+		hash := de.Name()
+		path := filepath.Join(c.config.BaseDir, hash)
+		size, err := dirSize(path)
+		if err != nil {
+			log.Printf("[ImageCache] Skipping unreadable layer %s: %v", hash, err)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		c.entries[hash] = &entry{
+			hash:     hash,
+			path:     path,
+			size:     size,
+			lastUsed: info.ModTime(),
+		}
+		c.total += size
+	}
+
+	return nil
+}
+
+// ManifestHash computes the cache key for a set of dependency manifest
+// files (e.g. package-lock.json and .nvmrc together). Order doesn't
+// matter: paths are sorted before hashing so the same manifest set always
+// produces the same key.
+func ManifestHash(paths ...string) (string, error) {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, p := range sorted {
+		f, err := os.Open(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read manifest %s: %w", p, err)
+		}
+		fmt.Fprintf(h, "%s\x00", filepath.Base(p))
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to hash manifest %s: %w", p, err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Lookup returns the on-disk path of the layer cached for hash, if any.
+// A hit bumps the layer's LRU position and holds a reference to it until
+// the returned release func is called; callers must call release exactly
+// once they're done using the path (e.g. after the sandbox that bind-
+// mounted it is destroyed), or the layer becomes ineligible for GC
+// forever.
+func (c *Cache) Lookup(hash string) (path string, release func(), ok bool) {
+	c.mu.Lock()
+	e, found := c.entries[hash]
+	if found {
+		e.refCount++
+		e.lastUsed = time.Now()
+		path = e.path
+	}
+	c.mu.Unlock()
+
+	c.recordLookup(found)
+	if !found {
+		return "", nil, false
+	}
+
+	var once sync.Once
+	return path, func() {
+		once.Do(func() { c.release(hash) })
+	}, true
+}
+
+func (c *Cache) release(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[hash]; ok && e.refCount > 0 {
+		e.refCount--
+	}
+}
+
+// Staging is a not-yet-committed layer being built.
+type Staging struct {
+	cache *Cache
+	hash  string
+	dir   string
+	done  bool
+}
+
+// Stage opens a fresh staging directory for building the layer for hash.
+// The caller populates Dir() and then calls Commit, or Abort to discard
+// a partial build.
+func (c *Cache) Stage(hash string) (*Staging, error) {
+	dir := filepath.Join(c.config.BaseDir, stagingDirName, hash+"-"+uuid.NewString())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	return &Staging{cache: c, hash: hash, dir: dir}, nil
+}
+
+// Dir returns the staging directory to populate.
+func (s *Staging) Dir() string {
+	return s.dir
+}
+
+// Commit atomically publishes the staged directory as the layer for
+// Stage's hash via rename(2), so a concurrent reader never observes a
+// partially-written layer. Safe to call at most once.
+func (s *Staging) Commit() error {
+	if s.done {
+		return fmt.Errorf("imagecache: staging already finalized")
+	}
+	s.done = true
+
+	size, err := dirSize(s.dir)
+	if err != nil {
+		os.RemoveAll(s.dir)
+		return fmt.Errorf("failed to size staged layer: %w", err)
+	}
+
+	final := filepath.Join(s.cache.config.BaseDir, s.hash)
+	if err := os.Rename(s.dir, final); err != nil {
+		os.RemoveAll(s.dir)
+		return fmt.Errorf("failed to commit staged layer: %w", err)
+	}
+
+	s.cache.mu.Lock()
+	s.cache.entries[s.hash] = &entry{
+		hash:     s.hash,
+		path:     final,
+		size:     size,
+		lastUsed: time.Now(),
+	}
+	s.cache.total += size
+	s.cache.mu.Unlock()
+
+	return nil
+}
+
+// Abort discards a partially-built layer. Safe to call at most once.
+func (s *Staging) Abort() error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+	return os.RemoveAll(s.dir)
+}
+
+// Prewarm builds and commits a layer for every manifest path that isn't
+// already cached, using config.Builder. It's meant to be called once at
+// startup from a list of "hot" project templates so their first sandbox
+// doesn't pay the install cost.
+func (c *Cache) Prewarm(ctx context.Context, manifests []string) error {
+	if c.config.Builder == nil {
+		return fmt.Errorf("imagecache: Prewarm requires a Builder")
+	}
+
+	for _, manifest := range manifests {
+		hash, err := ManifestHash(manifest)
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		_, cached := c.entries[hash]
+		c.mu.Unlock()
+		if cached {
+			continue
+		}
+
+		staging, err := c.Stage(hash)
+		if err != nil {
+			return err
+		}
+
+		if err := c.config.Builder(ctx, manifest, staging.Dir()); err != nil {
+			staging.Abort()
+			return fmt.Errorf("failed to prewarm layer for %s: %w", manifest, err)
+		}
+
+		if err := staging.Commit(); err != nil {
+			return err
+		}
+		log.Printf("[ImageCache] Prewarmed layer %s for %s", hash, manifest)
+	}
+
+	return nil
+}
+
+// GC evicts least-recently-used, unreferenced layers until the cache is
+// back under its size quota. It's meant to run alongside the sandbox
+// pool's own cleanup loop.
+func (c *Cache) GC(ctx context.Context) error {
+	c.mu.Lock()
+
+	if c.total <= c.config.QuotaBytes {
+		c.mu.Unlock()
+		return nil
+	}
+
+	candidates := make([]*entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		if e.refCount == 0 {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+
+	var toEvict []*entry
+	remaining := c.total
+	for _, e := range candidates {
+		if remaining <= c.config.QuotaBytes {
+			break
+		}
+		toEvict = append(toEvict, e)
+		remaining -= e.size
+	}
+	for _, e := range toEvict {
+		delete(c.entries, e.hash)
+		c.total -= e.size
+	}
+
+	c.mu.Unlock()
+
+	for _, e := range toEvict {
+		if err := os.RemoveAll(e.path); err != nil {
+			log.Printf("[ImageCache] Failed to remove evicted layer %s: %v", e.hash, err)
+			continue
+		}
+		log.Printf("[ImageCache] Evicted layer %s (%d bytes, unused since %s)", e.hash, e.size, e.lastUsed)
+		c.recordEviction()
+	}
+
+	return nil
+}
+
+// Stats returns the cache's current size and entry count, for metrics
+// and diagnostics.
+func (c *Cache) Stats() (totalBytes int64, entries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total, len(c.entries)
+}
+
+func (c *Cache) recordLookup(hit bool) {
+	if c.config.Metrics == nil {
+		return
+	}
+	c.config.Metrics.RecordImageCacheLookup(hit)
+}
+
+func (c *Cache) recordEviction() {
+	if c.config.Metrics == nil {
+		return
+	}
+	c.config.Metrics.RecordImageCacheEviction()
+}
+
+func dirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
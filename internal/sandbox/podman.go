@@ -0,0 +1,30 @@
+package sandbox
+
+import "fmt"
+
+// defaultPodmanSocket is the standard rootful Podman REST socket path.
+const defaultPodmanSocket = "unix:///run/podman/podman.sock"
+
+// PodmanRuntime is DockerRuntime pointed at Podman's Docker-API-compatible
+// REST socket instead of the Docker daemon's. Podman implements enough of
+// the same API surface - container create/start/inspect, exec, file copy
+// - that nothing here needs to differ from DockerRuntime beyond which
+// socket the client dials.
+type PodmanRuntime struct {
+	*DockerRuntime
+}
+
+// NewPodmanRuntime creates a PodmanRuntime against config.RuntimeSocket,
+// defaulting to defaultPodmanSocket when unset.
+func NewPodmanRuntime(config Config) (*PodmanRuntime, error) {
+	socket := config.RuntimeSocket
+	if socket == "" {
+		socket = defaultPodmanSocket
+	}
+
+	dr, err := newDockerRuntime(config, socket, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Podman runtime: %w", err)
+	}
+	return &PodmanRuntime{DockerRuntime: dr}, nil
+}
@@ -3,9 +3,19 @@ package sandbox
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/ratelimit"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/sandbox/imagecache"
 )
 
 var (
@@ -13,6 +23,32 @@ var (
 	ErrPoolClosed    = errors.New("sandbox pool is closed")
 )
 
+// defaultQueueFullRetryAfter is ErrQueueFull.RetryAfter when the pool
+// doesn't have a better estimate of when a slot will free up.
+const defaultQueueFullRetryAfter = 1 * time.Second
+
+// ErrQueueFull is returned by AcquireWithOptions when the pool is already
+// at MaxSize and PoolConfig.MaxQueueSize waiters are already queued - the
+// caller should back off, not retry immediately, unlike ErrPoolExhausted
+// which today's callers treat as an outright rejection.
+type ErrQueueFull struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrQueueFull) Error() string {
+	return fmt.Sprintf("sandbox pool wait queue full, retry after %s", e.RetryAfter)
+}
+
+// sandboxSessionIDAttr reads the session ID a sandbox is tagged with (if
+// any) out of its generic Labels map, for span attributes. Sandboxes
+// aren't always leased to a session, so this is "" more often than not.
+func sandboxSessionIDAttr(sb *Sandbox) string {
+	if sb == nil {
+		return ""
+	}
+	return sb.Labels["session_id"]
+}
+
 // PoolConfig holds pool configuration
 type PoolConfig struct {
 	// Minimum number of idle sandboxes to maintain
@@ -27,22 +63,69 @@ type PoolConfig struct {
 	// Time after which idle sandboxes are cleaned up
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
 
+	// CheckpointThreshold, if positive, checkpoints an idle sandbox (via
+	// Runtime.Checkpoint) once it has sat idle this long, freeing its
+	// container's RAM/CPU while keeping its slot reserved in the idle
+	// pool. Acquire restores it on demand instead of creating a fresh
+	// one - much cheaper for "always-warm pool" images with interpreters
+	// already initialized. Should be set below IdleTimeout; sandboxes
+	// still idle past IdleTimeout are destroyed regardless of checkpoint
+	// status. Zero disables checkpointing; idle sandboxes are destroyed
+	// outright at IdleTimeout as before.
+	CheckpointThreshold time.Duration `yaml:"checkpoint_threshold"`
+
 	// Interval for cleanup checks
 	CleanupInterval time.Duration `yaml:"cleanup_interval"`
 
 	// Sandbox configuration
 	SandboxConfig Config `yaml:"sandbox_config"`
+
+	// Limiter, if set, gates Acquire on a per-key rate limit before it
+	// consumes a sandbox slot. The key comes from ratelimit.WithKey on
+	// the passed context; callers that don't set one are limited under
+	// the key "anonymous". Left nil, Acquire is unthrottled.
+	Limiter ratelimit.Limiter `yaml:"-"`
+
+	// PriorityWeights sets each priority class's deficit-round-robin
+	// weight for AcquireWithOptions's waiter queue. A class missing from
+	// the map falls back to defaultPriorityWeights. Nil uses the
+	// defaults for every class.
+	PriorityWeights map[Priority]int `yaml:"priority_weights"`
+
+	// MaxWaitPromotion bounds how long the oldest queued waiter, of any
+	// priority, can be passed over before it's serviced next regardless
+	// of DRR credit. Zero disables the starvation bound entirely.
+	MaxWaitPromotion time.Duration `yaml:"max_wait_promotion"`
+
+	// MaxQueueSize bounds how many callers can be queued across every
+	// priority class at once. Once it's reached, AcquireWithOptions
+	// fails fast with ErrQueueFull instead of adding the caller to an
+	// unbounded queue - predictable back-pressure instead of a request
+	// that waits its full Timeout only to time out anyway. Zero (the
+	// default) leaves the queue unbounded.
+	MaxQueueSize int `yaml:"max_queue_size"`
+
+	// Metrics, if set, receives sandbox and waiter-queue gauges on every
+	// pool mutation. Left nil, metrics are skipped.
+	Metrics *metrics.Metrics `yaml:"-"`
+
+	// ImageCache, if set, is GC'd alongside cleanupLoop so dependency
+	// layers evict on the same schedule idle sandboxes do. Left nil, no
+	// image cache GC runs.
+	ImageCache *imagecache.Cache `yaml:"-"`
 }
 
 // DefaultPoolConfig returns default pool configuration
 func DefaultPoolConfig() PoolConfig {
 	return PoolConfig{
-		MinSize:         2,
-		MaxSize:         50,
-		WarmupSize:      5,
-		IdleTimeout:     30 * time.Minute,
-		CleanupInterval: 5 * time.Minute,
-		SandboxConfig:   DefaultConfig(),
+		MinSize:          2,
+		MaxSize:          50,
+		WarmupSize:       5,
+		IdleTimeout:      30 * time.Minute,
+		CleanupInterval:  5 * time.Minute,
+		SandboxConfig:    DefaultConfig(),
+		PriorityWeights:  defaultPriorityWeights(),
+		MaxWaitPromotion: 30 * time.Second,
 	}
 }
 
@@ -56,19 +139,54 @@ type Pool struct {
 	active   map[string]*Sandbox
 	creating int
 
+	// waiters holds callers queued by AcquireWithOptions once the pool is
+	// full, one FIFO-of-tenants per priority class. drrCredit/drrCursor
+	// are dequeueNext's weighted deficit-round-robin state.
+	waiters   map[Priority]*priorityQueue
+	drrCredit map[Priority]int
+	drrCursor int
+
+	// templates holds idle sandboxes keyed by the snapshot image they were
+	// built from (see AcquireFromSnapshot), separate from idle so a
+	// snapshot-specific sub-pool doesn't get handed out to a caller that
+	// just wants the default SandboxConfig.Image.
+	templates map[string][]*Sandbox
+
 	stopCh chan struct{}
 	wg     sync.WaitGroup
 	closed bool
+
+	// events, if attached via AttachEventBus, receives sandbox.acquired
+	// and sandbox.released alongside the sandbox.created/sandbox.destroyed
+	// DockerRuntime and ContainerdRuntime already publish, so a
+	// /api/v1/events subscriber sees the full lease lifecycle rather than
+	// just the container's.
+	events *events.Bus
+}
+
+// AttachEventBus wires the cross-cutting events.Bus into Acquire and
+// Release, the same way Runtime.AttachEventBus wires it into a runtime
+// backend's Create/Destroy/Exec.
+func (p *Pool) AttachEventBus(bus *events.Bus) {
+	p.events = bus
 }
 
 // NewPool creates a new sandbox pool
 func NewPool(config PoolConfig, runtime Runtime) *Pool {
+	waiters := make(map[Priority]*priorityQueue, len(priorityOrder))
+	for _, pr := range priorityOrder {
+		waiters[pr] = newPriorityQueue()
+	}
+
 	p := &Pool{
-		config:  config,
-		runtime: runtime,
-		idle:    make([]*Sandbox, 0, config.MaxSize),
-		active:  make(map[string]*Sandbox),
-		stopCh:  make(chan struct{}),
+		config:    config,
+		runtime:   runtime,
+		idle:      make([]*Sandbox, 0, config.MaxSize),
+		active:    make(map[string]*Sandbox),
+		waiters:   waiters,
+		drrCredit: make(map[Priority]int, len(priorityOrder)),
+		templates: make(map[string][]*Sandbox),
+		stopCh:    make(chan struct{}),
 	}
 
 	// Start background goroutines
@@ -79,8 +197,78 @@ func NewPool(config PoolConfig, runtime Runtime) *Pool {
 	return p
 }
 
-// Acquire acquires a sandbox from the pool
+// acquireRateLimitScope scopes Pool.Acquire's rate limit separately from
+// any HTTP-layer limits on the same key.
+const acquireRateLimitScope = "sandbox_acquire"
+
+// Acquire acquires a sandbox from the pool, failing immediately with
+// ErrPoolExhausted if none is idle and the pool is already at MaxSize.
+// Callers that would rather queue for one should use AcquireWithOptions.
 func (p *Pool) Acquire(ctx context.Context) (*Sandbox, error) {
+	return p.AcquireWithOptions(ctx, AcquireOpts{Priority: PriorityInteractive})
+}
+
+// AcquireWithOptions acquires a sandbox from the pool, like Acquire, but
+// lets the caller opt into waiting when the pool is full instead of
+// getting ErrPoolExhausted. If opts.Timeout is positive, a caller that
+// can't be served immediately joins opts.Priority's waiter queue (rotating
+// fairly across opts.TenantID within that class) until a sandbox is
+// released or created for it, opts.Timeout elapses, or ctx is cancelled.
+// opts.Timeout's zero value preserves Acquire's immediate-failure
+// behavior.
+func (p *Pool) AcquireWithOptions(ctx context.Context, opts AcquireOpts) (*Sandbox, error) {
+	if opts.Priority == "" {
+		opts.Priority = PriorityInteractive
+	}
+
+	ctx, span := metrics.Tracer.Start(ctx, "sandbox.Pool.Acquire", trace.WithAttributes(
+		attribute.String("sandbox.priority", string(opts.Priority)),
+		attribute.String("sandbox.tenant_id", opts.TenantID),
+	))
+	defer span.End()
+
+	sb, err := p.acquireWithOptions(ctx, opts)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("sandbox.id", sb.ID), attribute.String("session.id", sandboxSessionIDAttr(sb)))
+
+	if p.events != nil {
+		p.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxAcquired,
+			SandboxID: sb.ID,
+			SessionID: sandboxSessionIDAttr(sb),
+			Data: map[string]interface{}{
+				"priority":  string(opts.Priority),
+				"tenant_id": opts.TenantID,
+			},
+		})
+	}
+
+	return sb, nil
+}
+
+// acquireWithOptions is AcquireWithOptions's body, split out so the span
+// set up around it can cover every return path with one RecordError/
+// SetStatus call instead of repeating it at each one.
+func (p *Pool) acquireWithOptions(ctx context.Context, opts AcquireOpts) (*Sandbox, error) {
+	if p.config.Limiter != nil {
+		key, ok := ratelimit.KeyFromContext(ctx)
+		if !ok {
+			key = "anonymous"
+		}
+		result, err := p.config.Limiter.Take(ctx, acquireRateLimitScope, key, 1)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Allowed {
+			return nil, &ratelimit.ErrRateLimited{RetryAfter: result.RetryAfter}
+		}
+	}
+
 	p.mu.Lock()
 
 	if p.closed {
@@ -95,6 +283,22 @@ func (p *Pool) Acquire(ctx context.Context) (*Sandbox, error) {
 		p.active[sb.ID] = sb
 		p.mu.Unlock()
 
+		if sb.Status == StatusCheckpointed {
+			checkpointName := sb.CheckpointName
+			if err := p.runtime.Restore(ctx, sb.ID, checkpointName); err != nil {
+				p.mu.Lock()
+				delete(p.active, sb.ID)
+				p.mu.Unlock()
+				go func() {
+					if err := p.runtime.Destroy(context.Background(), sb.ID); err != nil {
+						log.Printf("[Pool] Failed to destroy sandbox %s after failed restore: %v", sb.ID, err)
+					}
+				}()
+				return nil, fmt.Errorf("failed to restore checkpointed sandbox %s: %w", sb.ID, err)
+			}
+			log.Printf("[Pool] Restored checkpointed sandbox %s from %q", sb.ID, checkpointName)
+		}
+
 		sb.Status = StatusActive
 		sb.LastActiveAt = time.Now()
 
@@ -104,22 +308,99 @@ func (p *Pool) Acquire(ctx context.Context) (*Sandbox, error) {
 
 	// Check if we can create a new one
 	totalCount := len(p.active) + len(p.idle) + p.creating
-	if totalCount >= p.config.MaxSize {
+	if totalCount < p.config.MaxSize {
+		p.creating++
+		p.mu.Unlock()
+
+		// Create new sandbox
+		sb, err := p.runtime.Create(ctx, p.config.SandboxConfig)
+		if err != nil {
+			p.mu.Lock()
+			p.creating--
+			p.mu.Unlock()
+			return nil, err
+		}
+
+		p.mu.Lock()
+		p.creating--
+		p.active[sb.ID] = sb
+		p.mu.Unlock()
+
+		sb.Status = StatusActive
+		log.Printf("[Pool] Created new sandbox %s", sb.ID)
+
+		return sb, nil
+	}
+
+	if opts.Timeout <= 0 {
 		p.mu.Unlock()
 		return nil, ErrPoolExhausted
 	}
 
+	if p.config.MaxQueueSize > 0 && p.totalQueuedLocked() >= p.config.MaxQueueSize {
+		p.mu.Unlock()
+		return nil, &ErrQueueFull{RetryAfter: defaultQueueFullRetryAfter}
+	}
+
+	w := &waiter{opts: opts, ch: make(chan waiterResult, 1), enqueuedAt: time.Now()}
+	p.waiters[opts.Priority].push(w)
+	p.updateMetricsLocked()
+	p.mu.Unlock()
+
+	return p.awaitSandbox(ctx, opts, w)
+}
+
+// AcquireFromSnapshot acquires a sandbox built from a named snapshot image
+// (see DockerRuntime.Commit, and handleSnapshot/handleFork which call it)
+// instead of the pool's default SandboxConfig.Image, reusing an idle one
+// from that snapshot's own template sub-pool if one is sitting warm.
+// Acquired sandboxes are tagged sb.Labels["snapshot"] so Release routes
+// them back to the same sub-pool instead of the shared idle pool. Unlike
+// AcquireWithOptions, this never queues: a caller forking or warming a
+// template is expected to handle ErrPoolExhausted itself, since waiting on
+// an unrelated priority class's DRR queue doesn't make sense per-template.
+func (p *Pool) AcquireFromSnapshot(ctx context.Context, snapshot string) (*Sandbox, error) {
+	p.mu.Lock()
+
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+
+	if idle := p.templates[snapshot]; len(idle) > 0 {
+		sb := idle[len(idle)-1]
+		p.templates[snapshot] = idle[:len(idle)-1]
+		p.active[sb.ID] = sb
+		p.mu.Unlock()
+
+		sb.Status = StatusActive
+		sb.LastActiveAt = time.Now()
+		log.Printf("[Pool] Acquired sandbox %s from snapshot %q template pool", sb.ID, snapshot)
+		return sb, nil
+	}
+
+	totalCount := len(p.active) + len(p.idle) + p.creating
+	if totalCount >= p.config.MaxSize {
+		p.mu.Unlock()
+		return nil, ErrPoolExhausted
+	}
 	p.creating++
 	p.mu.Unlock()
 
-	// Create new sandbox
-	sb, err := p.runtime.Create(ctx, p.config.SandboxConfig)
+	snapshotConfig := p.config.SandboxConfig
+	snapshotConfig.Image = snapshot
+
+	sb, err := p.runtime.Create(ctx, snapshotConfig)
 	if err != nil {
 		p.mu.Lock()
 		p.creating--
 		p.mu.Unlock()
 		return nil, err
 	}
+	if sb.Labels == nil {
+		sb.Labels = make(map[string]string)
+	}
+	sb.Labels["snapshot"] = snapshot
 
 	p.mu.Lock()
 	p.creating--
@@ -127,32 +408,210 @@ func (p *Pool) Acquire(ctx context.Context) (*Sandbox, error) {
 	p.mu.Unlock()
 
 	sb.Status = StatusActive
-	log.Printf("[Pool] Created new sandbox %s", sb.ID)
+	log.Printf("[Pool] Created new sandbox %s from snapshot %q", sb.ID, snapshot)
+
+	if p.events != nil {
+		p.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxAcquired,
+			SandboxID: sb.ID,
+			Data:      map[string]interface{}{"snapshot": snapshot},
+		})
+	}
 
 	return sb, nil
 }
 
-// Release releases a sandbox back to the pool
+// awaitSandbox blocks until w is handed a sandbox, opts.Timeout elapses,
+// or ctx is cancelled, recording wait-queue metrics either way.
+func (p *Pool) awaitSandbox(ctx context.Context, opts AcquireOpts, w *waiter) (*Sandbox, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	select {
+	case res := <-w.ch:
+		p.recordWait(opts.Priority, w.enqueuedAt, false)
+		return res.sandbox, res.err
+	case <-waitCtx.Done():
+		p.mu.Lock()
+		removed := p.waiters[opts.Priority].remove(w)
+		if removed {
+			p.updateMetricsLocked()
+		}
+		p.mu.Unlock()
+
+		if removed {
+			p.recordWait(opts.Priority, w.enqueuedAt, true)
+			return nil, waitCtx.Err()
+		}
+
+		// Lost the race with dequeueNext: a sandbox is already on its
+		// way to w.ch (or Close already sent ErrPoolClosed to it).
+		res := <-w.ch
+		p.recordWait(opts.Priority, w.enqueuedAt, false)
+		return res.sandbox, res.err
+	}
+}
+
+// dequeueNext picks the next waiter to satisfy when a sandbox becomes
+// available, or nil if no one is queued. Callers must hold p.mu.
+//
+// It first checks for cross-class starvation: if the single oldest
+// queued waiter, of any priority, has waited past MaxWaitPromotion, it's
+// serviced immediately regardless of DRR credit. Otherwise it runs one
+// step of weighted deficit round-robin over priorityOrder.
+func (p *Pool) dequeueNext() *waiter {
+	if p.config.MaxWaitPromotion > 0 {
+		var oldest *waiter
+		var oldestPriority Priority
+		for _, pr := range priorityOrder {
+			if w := p.waiters[pr].peekOldest(); w != nil {
+				if oldest == nil || w.enqueuedAt.Before(oldest.enqueuedAt) {
+					oldest = w
+					oldestPriority = pr
+				}
+			}
+		}
+		if oldest != nil && time.Since(oldest.enqueuedAt) > p.config.MaxWaitPromotion {
+			if p.waiters[oldestPriority].remove(oldest) {
+				return oldest
+			}
+		}
+	}
+
+	for i := 0; i < len(priorityOrder); i++ {
+		pr := priorityOrder[(p.drrCursor+i)%len(priorityOrder)]
+		q := p.waiters[pr]
+		if q.count == 0 {
+			p.drrCredit[pr] = 0
+			continue
+		}
+
+		if p.drrCredit[pr] <= 0 {
+			weight := p.config.PriorityWeights[pr]
+			if weight <= 0 {
+				weight = defaultPriorityWeights()[pr]
+			}
+			p.drrCredit[pr] = weight
+		}
+		p.drrCredit[pr]--
+		p.drrCursor = (p.drrCursor + i) % len(priorityOrder)
+		if p.drrCredit[pr] <= 0 {
+			p.drrCursor = (p.drrCursor + 1) % len(priorityOrder)
+		}
+		return q.pop()
+	}
+
+	return nil
+}
+
+// recordWait is a nil-safe wrapper around Metrics.RecordSandboxWait.
+func (p *Pool) recordWait(priority Priority, enqueuedAt time.Time, timedOut bool) {
+	if p.config.Metrics == nil {
+		return
+	}
+	p.config.Metrics.RecordSandboxWait(string(priority), time.Since(enqueuedAt).Seconds(), timedOut)
+}
+
+// updateMetricsLocked pushes current pool and waiter-queue gauges to
+// Metrics. Callers must hold p.mu; a nil Metrics makes this a no-op.
+func (p *Pool) updateMetricsLocked() {
+	if p.config.Metrics == nil {
+		return
+	}
+	waiters := make(map[string]int, len(priorityOrder))
+	for pr, q := range p.waiters {
+		waiters[string(pr)] = q.count
+	}
+	p.config.Metrics.UpdateSandboxStats(len(p.active)+len(p.idle)+p.creating, len(p.active), len(p.idle), waiters)
+}
+
+// totalQueuedLocked returns how many callers are queued across every
+// priority class. Callers must hold p.mu.
+func (p *Pool) totalQueuedLocked() int {
+	total := 0
+	for _, q := range p.waiters {
+		total += q.count
+	}
+	return total
+}
+
+// Release releases a sandbox back to the pool. If a caller is queued in
+// AcquireWithOptions's waiter queue, the sandbox is handed to it directly
+// instead of passing through the idle slice, to minimize its wait. A
+// sandbox built from a snapshot is never handed over this way; it goes
+// back to its template sub-pool.
 func (p *Pool) Release(ctx context.Context, id string) error {
+	ctx, span := metrics.Tracer.Start(ctx, "sandbox.Pool.Release", trace.WithAttributes(attribute.String("sandbox.id", id)))
+	defer span.End()
+
+	err := p.release(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// release is Release's body, split out the same way acquireWithOptions is.
+func (p *Pool) release(ctx context.Context, id string) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 
 	sb, ok := p.active[id]
 	if !ok {
+		p.mu.Unlock()
 		return errors.New("sandbox not found in active pool")
 	}
 
 	delete(p.active, id)
 
+	if p.events != nil {
+		p.events.PublishAsync(events.Event{
+			Type:      events.TypeSandboxReleased,
+			SandboxID: sb.ID,
+			SessionID: sandboxSessionIDAttr(sb),
+		})
+	}
+
+	var w *waiter
+	if sb.Labels["snapshot"] == "" {
+		w = p.dequeueNext()
+	}
+	if w != nil {
+		sb.Status = StatusActive
+		sb.LastActiveAt = time.Now()
+		p.active[sb.ID] = sb
+		p.updateMetricsLocked()
+		p.mu.Unlock()
+
+		log.Printf("[Pool] Handed sandbox %s directly to queued %s waiter", sb.ID, w.opts.Priority)
+		w.ch <- waiterResult{sandbox: sb}
+		return nil
+	}
+
+	// A sandbox forked or acquired from a snapshot goes back to that
+	// snapshot's own template sub-pool rather than the shared idle pool,
+	// so the next AcquireFromSnapshot for the same image can reuse it.
+	if snapshot := sb.Labels["snapshot"]; snapshot != "" && !p.closed {
+		sb.Status = StatusIdle
+		sb.LastActiveAt = time.Now()
+		p.templates[snapshot] = append(p.templates[snapshot], sb)
+		p.mu.Unlock()
+		log.Printf("[Pool] Released sandbox %s to snapshot %q template pool", sb.ID, snapshot)
+		return nil
+	}
+
 	// Reset and return to idle pool if there's room
 	if len(p.idle) < p.config.MaxSize && !p.closed {
 		sb.Status = StatusIdle
 		sb.LastActiveAt = time.Now()
 		p.idle = append(p.idle, sb)
+		p.mu.Unlock()
 		log.Printf("[Pool] Released sandbox %s to idle pool", sb.ID)
 		return nil
 	}
 
+	p.mu.Unlock()
+
 	// Otherwise destroy it
 	go func() {
 		if err := p.runtime.Destroy(context.Background(), sb.ID); err != nil {
@@ -205,11 +664,17 @@ func (p *Pool) Stats() map[string]int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	templated := 0
+	for _, idle := range p.templates {
+		templated += len(idle)
+	}
+
 	return map[string]int{
 		"idle":     len(p.idle),
 		"active":   len(p.active),
 		"creating": p.creating,
 		"max":      p.config.MaxSize,
+		"template": templated,
 	}
 }
 
@@ -261,17 +726,34 @@ func (p *Pool) warmup() {
 			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 			defer cancel()
 
+			ctx, span := metrics.Tracer.Start(ctx, "sandbox.Pool.warmup")
+			defer span.End()
+
 			sb, err := p.runtime.Create(ctx, p.config.SandboxConfig)
 			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 				log.Printf("[Pool] Failed to warm up sandbox: %v", err)
 				p.mu.Lock()
 				p.creating--
 				p.mu.Unlock()
 				return
 			}
+			span.SetAttributes(attribute.String("sandbox.id", sb.ID))
 
 			p.mu.Lock()
 			p.creating--
+			if w := p.dequeueNext(); w != nil {
+				sb.Status = StatusActive
+				sb.LastActiveAt = time.Now()
+				p.active[sb.ID] = sb
+				p.updateMetricsLocked()
+				p.mu.Unlock()
+
+				log.Printf("[Pool] Handed warmed-up sandbox %s directly to queued %s waiter", sb.ID, w.opts.Priority)
+				w.ch <- waiterResult{sandbox: sb}
+				return
+			}
 			p.idle = append(p.idle, sb)
 			p.mu.Unlock()
 
@@ -306,6 +788,20 @@ func (p *Pool) cleanup() {
 	now := time.Now()
 	var toRemove []int
 	var toDestroy []*Sandbox
+	var toCheckpoint []*Sandbox
+
+	// Find idle sandboxes that have sat past CheckpointThreshold, to
+	// checkpoint instead of leaving them fully live. One also past
+	// IdleTimeout is checkpointed too, and is then destroyed below unless
+	// MinSize keeps it. Checkpointed sandboxes stay in p.idle (Restore brings them back on
+	// Acquire) so this doesn't touch toRemove/toDestroy.
+	if p.config.CheckpointThreshold > 0 {
+		for _, sb := range p.idle {
+			if sb.Status == StatusIdle && now.Sub(sb.LastActiveAt) > p.config.CheckpointThreshold {
+				toCheckpoint = append(toCheckpoint, sb)
+			}
+		}
+	}
 
 	// Find idle sandboxes that have timed out
 	for i, sb := range p.idle {
@@ -336,6 +832,17 @@ func (p *Pool) cleanup() {
 
 	p.mu.Unlock()
 
+	// Checkpoint outside lock; a sandbox that fails to checkpoint just
+	// stays idle and live, and is reconsidered next cleanup tick.
+	for _, sb := range toCheckpoint {
+		name := sb.ID
+		if err := p.runtime.Checkpoint(ctx, sb.ID, name); err != nil {
+			log.Printf("[Pool] Failed to checkpoint idle sandbox %s: %v", sb.ID, err)
+			continue
+		}
+		log.Printf("[Pool] Checkpointed idle sandbox %s", sb.ID)
+	}
+
 	// Destroy sandboxes outside lock
 	for _, sb := range toDestroy {
 		if err := p.runtime.Destroy(ctx, sb.ID); err != nil {
@@ -344,6 +851,15 @@ func (p *Pool) cleanup() {
 			log.Printf("[Pool] Cleaned up idle sandbox %s", sb.ID)
 		}
 	}
+
+	if p.config.ImageCache != nil {
+		if err := p.config.ImageCache.GC(ctx); err != nil {
+			log.Printf("[Pool] Image cache GC failed: %v", err)
+		} else if p.config.Metrics != nil {
+			totalBytes, entries := p.config.ImageCache.Stats()
+			p.config.Metrics.UpdateImageCacheStats(totalBytes, entries)
+		}
+	}
 }
 
 // Close closes the pool and destroys all sandboxes
@@ -361,10 +877,24 @@ func (p *Pool) Close() error {
 	for _, sb := range p.active {
 		all = append(all, sb)
 	}
+	for _, idle := range p.templates {
+		all = append(all, idle...)
+	}
 	p.idle = nil
 	p.active = make(map[string]*Sandbox)
+	p.templates = make(map[string][]*Sandbox)
+
+	var waiters []*waiter
+	for _, pr := range priorityOrder {
+		waiters = append(waiters, p.waiters[pr].drainAll()...)
+	}
 	p.mu.Unlock()
 
+	// Wake anyone still queued in AcquireWithOptions
+	for _, w := range waiters {
+		w.ch <- waiterResult{err: ErrPoolClosed}
+	}
+
 	// Stop background goroutines
 	close(p.stopCh)
 	p.wg.Wait()
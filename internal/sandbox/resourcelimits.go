@@ -0,0 +1,50 @@
+package sandbox
+
+// containerResourceLimits is the CPU/memory/tmpfs sizing every
+// container-based Runtime backend derives from a sandbox Config. Pulling
+// it out here means DockerRuntime and ContainerdRuntime both translate
+// the same numbers into their own client's resource types (Docker's
+// container.Resources/mount.TmpfsOptions vs. containerd's OCI spec
+// options) instead of each re-deriving them from Config independently.
+type containerResourceLimits struct {
+	// MemoryBytes is the container's memory limit, converted from
+	// Config.MemoryMB.
+	MemoryBytes int64
+
+	// NanoCPUs is the CPU limit expressed Docker's way: 1e9 per full
+	// core. cfsQuotaPeriod converts this to the quota/period pair
+	// cgroups (and containerd's OCI spec) expect instead.
+	NanoCPUs int64
+
+	// TmpfsSizeBytes is the size of the tmpfs mounted at the sandbox's
+	// WorkDir, converted from Config.DiskSizeMB.
+	TmpfsSizeBytes int64
+}
+
+// newContainerResourceLimits derives containerResourceLimits from a
+// sandbox Config that has already had its zero fields merged in from the
+// runtime's own default Config (see DockerRuntime.Create and
+// ContainerdRuntime.Create).
+func newContainerResourceLimits(config Config) containerResourceLimits {
+	return containerResourceLimits{
+		MemoryBytes:    config.MemoryMB * 1024 * 1024,
+		NanoCPUs:       int64(config.CPUCount) * 1e9,
+		TmpfsSizeBytes: config.DiskSizeMB * 1024 * 1024,
+	}
+}
+
+// defaultCFSPeriod is the CFS scheduler period, in microseconds, quota is
+// expressed against. 100ms matches the value both the Docker daemon and
+// containerd's OCI spec generator default to.
+const defaultCFSPeriod = uint64(100000)
+
+// cfsQuotaPeriod converts NanoCPUs into the quota/period pair the
+// Completely Fair Scheduler (and so containerd's OCI spec, which has no
+// Docker-style "nanocpus" shorthand) expects. A non-positive NanoCPUs
+// returns a zero quota, meaning "unlimited" to the CFS.
+func (l containerResourceLimits) cfsQuotaPeriod() (quota int64, period uint64) {
+	if l.NanoCPUs <= 0 {
+		return 0, defaultCFSPeriod
+	}
+	return l.NanoCPUs * int64(defaultCFSPeriod) / 1e9, defaultCFSPeriod
+}
@@ -0,0 +1,21 @@
+package sandbox
+
+import "fmt"
+
+// NewRuntime builds the Runtime backend selected by config.RuntimeEngine:
+// "docker" (the default), "gvisor", "podman", or "containerd". See
+// Config.RuntimeEngine and Config.RuntimeSocket.
+func NewRuntime(config Config) (Runtime, error) {
+	switch config.RuntimeEngine {
+	case "", "docker":
+		return NewDockerRuntime(config)
+	case "gvisor":
+		return NewGvisorRuntime(config)
+	case "podman":
+		return NewPodmanRuntime(config)
+	case "containerd":
+		return NewContainerdRuntime(config)
+	default:
+		return nil, fmt.Errorf("unknown runtime engine %q", config.RuntimeEngine)
+	}
+}
@@ -0,0 +1,130 @@
+package sandbox
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+//go:embed profiles/*.json
+var builtinProfiles embed.FS
+
+// Security configures a sandbox's syscall/LSM isolation beyond the
+// always-on "no-new-privileges" Create already sets. The zero value
+// keeps Create's previous behavior untouched: no extra SecurityOpt, no
+// extra capabilities, no read-only rootfs.
+type Security struct {
+	// SeccompProfile selects a syscall filter: "default" or "strict"
+	// (bundled under sandbox/profiles/, loaded by name), or a filesystem
+	// path to a custom Docker-format seccomp JSON profile. Empty leaves
+	// the Docker daemon's own default profile in place.
+	SeccompProfile string `yaml:"seccomp_profile"`
+
+	// AppArmorProfile names a profile already loaded into the kernel to
+	// confine the container to (e.g. "docker-sandbox-strict"). Empty
+	// uses the daemon's default.
+	AppArmorProfile string `yaml:"apparmor_profile"`
+
+	// SELinuxLabel sets the container's MCS/MLS label, e.g.
+	// "level:s0:c1,c2". Empty leaves SELinux labeling to the daemon.
+	SELinuxLabel string `yaml:"selinux_label"`
+
+	// Capabilities adds or drops Linux capabilities beyond Docker's
+	// default set, e.g. Drop: []string{"NET_RAW"}.
+	Capabilities struct {
+		Add  []string `yaml:"add"`
+		Drop []string `yaml:"drop"`
+	} `yaml:"capabilities"`
+
+	// ReadOnlyRootfs mounts the container's root filesystem read-only.
+	// Sandboxed code should only need to write under Config.WorkDir,
+	// which is already its own tmpfs mount.
+	ReadOnlyRootfs bool `yaml:"read_only_rootfs"`
+
+	// MaskedPaths are masked with a read-only empty file/device beyond
+	// Docker's own default mask list (e.g. additional /proc/* entries
+	// that could otherwise leak host information). Empty uses Docker's
+	// defaults.
+	MaskedPaths []string `yaml:"masked_paths"`
+
+	// ReadonlyPaths are mounted read-only (without fully masking them)
+	// beyond Docker's own default list. Empty uses Docker's defaults.
+	ReadonlyPaths []string `yaml:"readonly_paths"`
+}
+
+// seccompSpec is just enough of Docker's seccomp profile schema to
+// confirm a SeccompProfile parses as one before Create hands it to the
+// daemon - Create passes the raw JSON through verbatim via SecurityOpt,
+// this only catches a malformed profile here instead of at container
+// start.
+type seccompSpec struct {
+	DefaultAction string        `json:"defaultAction"`
+	Syscalls      []interface{} `json:"syscalls,omitempty"`
+}
+
+// resolveSeccompProfile turns a Security.SeccompProfile value into the
+// HostConfig.SecurityOpt entry Docker expects. "default" and "strict"
+// load the profiles bundled under sandbox/profiles/; anything else is
+// read as a filesystem path to a custom profile. Empty returns ("", nil)
+// unchanged, leaving SecurityOpt to the Docker daemon's own default.
+func resolveSeccompProfile(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	var data []byte
+	switch name {
+	case "default", "strict":
+		b, err := builtinProfiles.ReadFile("profiles/" + name + ".json")
+		if err != nil {
+			return "", fmt.Errorf("failed to read bundled seccomp profile %q: %w", name, err)
+		}
+		data = b
+	default:
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to read seccomp profile %q: %w", name, err)
+		}
+		data = b
+	}
+
+	var spec seccompSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return "", fmt.Errorf("seccomp profile %q does not parse: %w", name, err)
+	}
+	if spec.DefaultAction == "" {
+		return "", fmt.Errorf("seccomp profile %q is missing defaultAction", name)
+	}
+
+	return "seccomp=" + string(data), nil
+}
+
+// applySecurity translates sec into hostConfig's SecurityOpt/CapAdd/
+// CapDrop/ReadonlyRootfs/MaskedPaths/ReadonlyPaths, layering on top of
+// whatever Create already set (e.g. "no-new-privileges").
+func applySecurity(hostConfig *container.HostConfig, sec Security) error {
+	if sec.SeccompProfile != "" {
+		seccompOpt, err := resolveSeccompProfile(sec.SeccompProfile)
+		if err != nil {
+			return err
+		}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, seccompOpt)
+	}
+	if sec.AppArmorProfile != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "apparmor="+sec.AppArmorProfile)
+	}
+	if sec.SELinuxLabel != "" {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "label="+sec.SELinuxLabel)
+	}
+
+	hostConfig.CapAdd = sec.Capabilities.Add
+	hostConfig.CapDrop = sec.Capabilities.Drop
+	hostConfig.ReadonlyRootfs = sec.ReadOnlyRootfs
+	hostConfig.MaskedPaths = sec.MaskedPaths
+	hostConfig.ReadonlyPaths = sec.ReadonlyPaths
+
+	return nil
+}
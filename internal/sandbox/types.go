@@ -10,12 +10,13 @@ import (
 type Status string
 
 const (
-	StatusCreating Status = "creating"
-	StatusIdle     Status = "idle"
-	StatusActive   Status = "active"
-	StatusPaused   Status = "paused"
-	StatusStopped  Status = "stopped"
-	StatusError    Status = "error"
+	StatusCreating     Status = "creating"
+	StatusIdle         Status = "idle"
+	StatusActive       Status = "active"
+	StatusPaused       Status = "paused"
+	StatusStopped      Status = "stopped"
+	StatusError        Status = "error"
+	StatusCheckpointed Status = "checkpointed"
 )
 
 // Sandbox represents a sandbox instance
@@ -28,6 +29,16 @@ type Sandbox struct {
 	CreatedAt    time.Time         `json:"created_at"`
 	LastActiveAt time.Time         `json:"last_active_at"`
 	Labels       map[string]string `json:"labels,omitempty"`
+
+	// CheckpointName identifies the CRIU checkpoint backing this sandbox
+	// while Status == StatusCheckpointed, empty otherwise. Restore takes
+	// this (or a caller-supplied value matching it) to resume the
+	// sandbox's process state instead of starting fresh.
+	CheckpointName string `json:"checkpoint_name,omitempty"`
+
+	// CheckpointedAt is when Checkpoint last succeeded for this sandbox.
+	// Zero if it has never been checkpointed.
+	CheckpointedAt time.Time `json:"checkpointed_at,omitempty"`
 }
 
 // Config holds sandbox configuration
@@ -45,11 +56,81 @@ type Config struct {
 	MaxOutputSize    int64         `yaml:"max_output_size"`
 
 	// Network settings
-	NetworkEnabled bool     `yaml:"network_enabled"`
-	AllowedHosts   []string `yaml:"allowed_hosts"`
+
+	NetworkEnabled bool `yaml:"network_enabled"`
+
+	// AllowedHosts restricts outbound connections to this allowlist,
+	// enforced by routing the sandbox's traffic through a per-runtime
+	// egress-proxy sidecar (see EgressProxyImage and egress.go). Entries
+	// may be an exact hostname ("api.github.com"), a leading-wildcard
+	// pattern ("*.githubusercontent.com"), or a CIDR ("10.0.0.0/8") to
+	// match by destination IP instead of hostname. Empty means "no
+	// egress filtering": NetworkEnabled alone decides whether the
+	// sandbox gets full bridge networking or none. The sidecar is started
+	// once, with the AllowedHosts and AllowedPorts of the first sandbox
+	// that needs it, and every later filtered sandbox shares it: a later
+	// sandbox's own lists do not change what the sidecar allows.
+	AllowedHosts []string `yaml:"allowed_hosts"`
+
+	// AllowedPorts restricts the egress proxy to these destination
+	// ports. Empty allows 80 and 443, the ports an HTTP(S) forward proxy
+	// can actually terminate. Ignored when AllowedHosts is empty.
+	AllowedPorts []int `yaml:"allowed_ports"`
+
+	// DNSServers are passed to the sandbox container as its resolvers
+	// (Docker's HostConfig.DNS) instead of the daemon's default, so name
+	// resolution doesn't leak to resolvers outside AllowedHosts's
+	// intended blast radius. Empty uses the daemon's default.
+	DNSServers []string `yaml:"dns_servers"`
+
+	// EgressProxyImage is the image NewDockerRuntime's egress-proxy
+	// sidecar runs (see cmd/egress-proxy) when AllowedHosts is set.
+	// Empty defaults to "cloud-sandbox/egress-proxy:latest".
+	EgressProxyImage string `yaml:"egress_proxy_image"`
 
 	// Working directory inside container
 	WorkDir string `yaml:"work_dir"`
+
+	// DependencyManifests lists dependency manifest files (e.g.
+	// package-lock.json, go.sum, requirements.txt) whose combined hash
+	// identifies the prebuilt layer this sandbox can reuse from the
+	// runtime's image cache instead of installing dependencies from
+	// scratch. Empty means "don't use the image cache".
+	DependencyManifests []string `yaml:"dependency_manifests"`
+
+	// RuntimeEngine selects which Runtime backend NewRuntime builds:
+	// "docker" (default), "gvisor" (Docker driving the runsc OCI runtime
+	// for a stronger syscall-level sandbox around untrusted agent code),
+	// "podman" (Podman's Docker-API-compatible REST socket), or
+	// "containerd" (talks to containerd directly over its gRPC socket,
+	// bypassing the Docker daemon entirely).
+	RuntimeEngine string `yaml:"runtime_engine"`
+
+	// RuntimeSocket overrides the backend's default connection target: a
+	// DOCKER_HOST-style endpoint for "docker"/"gvisor", the Podman REST
+	// socket for "podman", or the containerd gRPC socket for
+	// "containerd". Empty uses each backend's standard discovery
+	// (DOCKER_HOST env var / unix:///var/run/docker.sock,
+	// unix:///run/podman/podman.sock, or
+	// unix:///run/containerd/containerd.sock).
+	RuntimeSocket string `yaml:"runtime_socket"`
+
+	// ContainerdNamespace scopes every container the "containerd" engine
+	// creates to a containerd namespace, so they don't collide with
+	// containers from another client on the same daemon (e.g. a
+	// kubelet's CRI plugin). Empty defaults to "cloud-sandbox". Ignored
+	// by every other RuntimeEngine.
+	ContainerdNamespace string `yaml:"containerd_namespace"`
+
+	// CheckpointDir overrides where Checkpoint/Restore persist CRIU
+	// checkpoint state on the runtime host. Empty uses the container
+	// engine's default location (under its data root).
+	CheckpointDir string `yaml:"checkpoint_dir"`
+
+	// Security configures syscall/LSM isolation (seccomp, AppArmor,
+	// SELinux, capabilities, read-only rootfs) beyond Create's always-on
+	// "no-new-privileges". See the Security type.
+	Security Security `yaml:"security"`
 }
 
 // DefaultConfig returns default sandbox configuration
@@ -88,6 +169,18 @@ type ExecRequest struct {
 
 	// Stdin input
 	Stdin io.Reader `json:"-"`
+
+	// TTY requests a pseudo-terminal for ExecStream. Programs that behave
+	// differently attached to a terminal - REPLs, full-screen tools, and
+	// anything checking isatty(3) - need this set; Stdout and Stderr are
+	// combined onto one stream when it is, matching real terminal
+	// behavior. Ignored by Exec.
+	TTY bool `json:"tty,omitempty"`
+
+	// InitialRows/InitialCols size the PTY ExecStream allocates when TTY
+	// is set. Zero defaults to 24 rows by 80 columns.
+	InitialRows uint `json:"initial_rows,omitempty"`
+	InitialCols uint `json:"initial_cols,omitempty"`
 }
 
 // ExecResult represents the result of code execution
@@ -111,6 +204,42 @@ type ExecResult struct {
 	Error string `json:"error,omitempty"`
 }
 
+// ExecSession is a live, multi-turn exec attached to a sandbox, returned
+// by Runtime implementations' ExecStream method (where supported - it
+// isn't part of the Runtime interface itself, the same way Checkpoint/
+// Restore and the file-streaming helpers aren't, since not every backend
+// need implement it). Unlike Exec's single buffered round trip, callers
+// read and write it incrementally: the foundation for driving a REPL
+// (python -i, bash) or an LSP/Jupyter-kernel-style agent tool across
+// several turns instead of one command per Exec call.
+type ExecSession interface {
+	// Stdout streams the process's standard output as it's produced.
+	// When the session was started with TTY true, Stdout and Stderr are
+	// combined onto this reader and Stderr reads EOF immediately,
+	// matching a real terminal.
+	Stdout() io.Reader
+
+	// Stderr streams the process's standard error.
+	Stderr() io.Reader
+
+	// Stdin writes to the process's standard input.
+	Stdin() io.Writer
+
+	// Resize adjusts the session's PTY dimensions. Only valid for a
+	// session started with TTY true; otherwise it returns an error.
+	Resize(rows, cols uint) error
+
+	// Signal delivers sig ("INT" or "QUIT") to the process. Docker exec
+	// has no out-of-band signal channel, so this writes the matching
+	// control character (Ctrl-C, Ctrl-\) to stdin, the same mechanism an
+	// interactive terminal uses - any other signal name returns an error.
+	Signal(sig string) error
+
+	// Wait blocks until the process exits and returns its result. Safe
+	// to call more than once; later calls return the same result.
+	Wait() (*ExecResult, error)
+}
+
 // FileInfo represents file metadata
 type FileInfo struct {
 	Name    string    `json:"name"`
@@ -154,4 +283,18 @@ type Runtime interface {
 
 	// DeleteFile deletes a file or directory
 	DeleteFile(ctx context.Context, id string, path string) error
+
+	// Checkpoint freezes a running sandbox's process state to disk via
+	// CRIU and stops its container, so it no longer consumes RAM/CPU
+	// while idle. name identifies the checkpoint for a later Restore; it
+	// must be unique per sandbox. The sandbox's Status becomes
+	// StatusCheckpointed on success.
+	Checkpoint(ctx context.Context, id, name string) error
+
+	// Restore resumes a sandbox previously frozen by Checkpoint from its
+	// saved process state, which is dramatically faster than Create for
+	// warm-pool scenarios (e.g. an interpreter with dependencies already
+	// imported). name must match the identifier Checkpoint was called
+	// with.
+	Restore(ctx context.Context, id, name string) error
 }
@@ -0,0 +1,177 @@
+package sandbox
+
+import "time"
+
+// Priority classes an AcquireWithOptions caller can queue under when the
+// pool is full. Higher classes are serviced more often by the weighted
+// deficit-round-robin selector in Pool.dequeueNext, but never exclusively:
+// MaxWaitPromotion bounds how long a lower class can be starved.
+type Priority string
+
+const (
+	PriorityInteractive Priority = "interactive"
+	PriorityBatch       Priority = "batch"
+	PriorityBackground  Priority = "background"
+)
+
+// priorityOrder fixes the rotation order the DRR selector and the
+// cross-class starvation check walk in. It also doubles as the set of
+// valid priority classes.
+var priorityOrder = []Priority{PriorityInteractive, PriorityBatch, PriorityBackground}
+
+// defaultPriorityWeights returns the DRR weight for each priority class
+// used when PoolConfig.PriorityWeights doesn't set one explicitly.
+func defaultPriorityWeights() map[Priority]int {
+	return map[Priority]int{
+		PriorityInteractive: 4,
+		PriorityBatch:       2,
+		PriorityBackground:  1,
+	}
+}
+
+// AcquireOpts customizes AcquireWithOptions. A caller that leaves Timeout
+// at its zero value gets today's Acquire behavior: an immediate
+// ErrPoolExhausted instead of being queued.
+type AcquireOpts struct {
+	// Priority selects which waiter queue to join if the pool is full.
+	// Defaults to PriorityInteractive.
+	Priority Priority
+
+	// TenantID, if set, gives this caller its own round-robin turn
+	// within its priority queue, so one tenant issuing a burst of
+	// requests can't starve another tenant at the same priority.
+	TenantID string
+
+	// Timeout bounds how long to wait for a sandbox once the pool is
+	// full. Zero (the default) means don't wait at all.
+	Timeout time.Duration
+}
+
+// waiter is a single queued AcquireWithOptions call. ch is buffered so
+// the goroutine that satisfies it (Release, or a freshly warmed-up
+// sandbox) never blocks on a caller that has already timed out.
+type waiter struct {
+	opts       AcquireOpts
+	ch         chan waiterResult
+	enqueuedAt time.Time
+}
+
+type waiterResult struct {
+	sandbox *Sandbox
+	err     error
+}
+
+// priorityQueue holds the waiters of one priority class, round-robining
+// across tenants so a single busy tenant can't monopolize its class.
+// Callers must hold Pool.mu.
+type priorityQueue struct {
+	byTenant map[string][]*waiter
+	order    []string // tenant rotation order
+	cursor   int
+	count    int
+}
+
+func newPriorityQueue() *priorityQueue {
+	return &priorityQueue{byTenant: make(map[string][]*waiter)}
+}
+
+func (q *priorityQueue) push(w *waiter) {
+	tenant := w.opts.TenantID
+	if _, ok := q.byTenant[tenant]; !ok {
+		q.order = append(q.order, tenant)
+	}
+	q.byTenant[tenant] = append(q.byTenant[tenant], w)
+	q.count++
+}
+
+// pop removes and returns the next waiter in tenant round-robin order, or
+// nil if the queue is empty.
+func (q *priorityQueue) pop() *waiter {
+	for i := 0; i < len(q.order); i++ {
+		idx := (q.cursor + i) % len(q.order)
+		tenant := q.order[idx]
+		pending := q.byTenant[tenant]
+		if len(pending) == 0 {
+			continue
+		}
+
+		w := pending[0]
+		q.byTenant[tenant] = pending[1:]
+		q.count--
+		q.cursor = (idx + 1) % len(q.order)
+		q.dropTenantIfEmptyLocked(tenant, idx)
+		return w
+	}
+	return nil
+}
+
+// peekOldest returns the longest-queued waiter in this class without
+// removing it, for the cross-class starvation check.
+func (q *priorityQueue) peekOldest() *waiter {
+	var oldest *waiter
+	for _, pending := range q.byTenant {
+		if len(pending) == 0 {
+			continue
+		}
+		if oldest == nil || pending[0].enqueuedAt.Before(oldest.enqueuedAt) {
+			oldest = pending[0]
+		}
+	}
+	return oldest
+}
+
+// remove removes w if it's still queued, reporting whether it found it.
+// It returns false once w has already been popped by a concurrent
+// dequeueNext, telling the caller a sandbox is already on its way to w.ch.
+func (q *priorityQueue) remove(w *waiter) bool {
+	tenant := w.opts.TenantID
+	pending, ok := q.byTenant[tenant]
+	if !ok {
+		return false
+	}
+
+	for i, candidate := range pending {
+		if candidate != w {
+			continue
+		}
+		q.byTenant[tenant] = append(pending[:i], pending[i+1:]...)
+		q.count--
+		for idx, t := range q.order {
+			if t == tenant {
+				q.dropTenantIfEmptyLocked(tenant, idx)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// dropTenantIfEmptyLocked removes tenant from the rotation once its
+// sub-queue is drained, keeping cursor valid for the shrunk order slice.
+func (q *priorityQueue) dropTenantIfEmptyLocked(tenant string, orderIdx int) {
+	if len(q.byTenant[tenant]) > 0 {
+		return
+	}
+	delete(q.byTenant, tenant)
+	q.order = append(q.order[:orderIdx], q.order[orderIdx+1:]...)
+	if len(q.order) == 0 {
+		q.cursor = 0
+	} else if q.cursor > orderIdx {
+		q.cursor--
+	}
+}
+
+// drainAll empties the queue and returns every waiter it held, for Close
+// to wake them all with ErrPoolClosed.
+func (q *priorityQueue) drainAll() []*waiter {
+	var all []*waiter
+	for _, pending := range q.byTenant {
+		all = append(all, pending...)
+	}
+	q.byTenant = make(map[string][]*waiter)
+	q.order = nil
+	q.cursor = 0
+	q.count = 0
+	return all
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/docker/docker/client"
+)
+
+// azblobBackend implements Backend against an Azure Blob Storage
+// container.
+type azblobBackend struct {
+	client    *azblob.Client
+	container string
+}
+
+func (b *azblobBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	// azblob's UploadStream doesn't need size up front; it buffers and
+	// uploads in blocks internally.
+	_, err := b.client.UploadStream(ctx, b.container, key, r, nil)
+	return err
+}
+
+func (b *azblobBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := b.client.DownloadStream(ctx, b.container, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (b *azblobBackend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteBlob(ctx, b.container, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (b *azblobBackend) Stat(ctx context.Context, key string) (bool, error) {
+	pager := b.client.NewListBlobsFlatPager(b.container, &azblob.ListBlobsFlatOptions{
+		Prefix: &key,
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil && *item.Name == key {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// AzureBlobStorage implements WorkspaceStorage using Azure Blob Storage.
+type AzureBlobStorage struct {
+	*objectWorkspaceStorage
+	client *azblob.Client
+}
+
+// AzureBlobConfig holds Azure Blob Storage connection configuration.
+type AzureBlobConfig struct {
+	// AccountURL is the storage account's blob endpoint, e.g.
+	// "https://<account>.blob.core.windows.net/".
+	AccountURL string
+	Container  string
+	WorkDir    string // Workspace directory inside container
+
+	// Credential authenticates against AccountURL. Nil is only valid
+	// when AccountURL already embeds a SAS token.
+	Credential azcore.TokenCredential
+
+	// CopyBufferSize bounds the intermediate buffer Restore streams
+	// through when copying into a container. Zero means
+	// defaultCopyBufferSize.
+	CopyBufferSize int
+
+	// MaxConcurrentTransfers bounds how many Save/Restore calls run at
+	// once. Zero means defaultMaxConcurrentTransfers.
+	MaxConcurrentTransfers int
+}
+
+// NewAzureBlobStorage creates a new Azure Blob Storage workspace storage.
+func NewAzureBlobStorage(config AzureBlobConfig) (*AzureBlobStorage, error) {
+	var azClient *azblob.Client
+	var err error
+	if config.Credential != nil {
+		azClient, err = azblob.NewClient(config.AccountURL, config.Credential, nil)
+	} else {
+		azClient, err = azblob.NewClientWithNoCredential(config.AccountURL, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure blob client: %w", err)
+	}
+
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	storageInst := &AzureBlobStorage{
+		objectWorkspaceStorage: newObjectWorkspaceStorage(
+			&azblobBackend{client: azClient, container: config.Container},
+			dockerClient, config.WorkDir, config.CopyBufferSize, config.MaxConcurrentTransfers,
+		),
+		client: azClient,
+	}
+
+	if err := storageInst.ensureContainer(context.Background(), config.Container); err != nil {
+		return nil, err
+	}
+
+	return storageInst, nil
+}
+
+// ensureContainer creates container if it doesn't already exist.
+func (s *AzureBlobStorage) ensureContainer(ctx context.Context, container string) error {
+	_, err := s.client.CreateContainer(ctx, container, nil)
+	if err == nil {
+		log.Printf("[AzureBlob] Created container: %s", container)
+		return nil
+	}
+	if bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		return nil
+	}
+	return fmt.Errorf("failed to create azure blob container: %w", err)
+}
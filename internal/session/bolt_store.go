@@ -0,0 +1,343 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	boltBucketSessions = []byte("sessions")
+	boltBucketByUser   = []byte("idx_user")
+	boltBucketByExpiry = []byte("idx_expiry")
+)
+
+// BoltStore implements Store on top of a local BoltDB file, for single-
+// binary deployments that don't want to stand up PostgreSQL. Alongside the
+// primary sessions bucket it keeps two secondary-index buckets so
+// GetByUser and ListExpired don't have to scan every session:
+//
+//   - idx_user:   "<userID>\x00<sessionID>"               -> sessionID
+//   - idx_expiry: "<expiresAt unix nano, big-endian><sessionID>" -> sessionID
+//
+// bbolt keeps bucket keys in byte-sorted order, so idx_expiry's keys sort
+// chronologically and ListExpired/DeleteExpired can stop as soon as they
+// pass the current time instead of walking the whole index.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// BoltConfig holds BoltDB connection configuration.
+type BoltConfig struct {
+	// Path is the database file, e.g. "sessions.bolt".
+	Path string
+}
+
+// DefaultBoltConfig returns default BoltDB configuration.
+func DefaultBoltConfig() BoltConfig {
+	return BoltConfig{Path: "sessions.bolt"}
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB-backed store.
+func NewBoltStore(config BoltConfig) (*BoltStore, error) {
+	db, err := bbolt.Open(config.Path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt database: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{boltBucketSessions, boltBucketByUser, boltBucketByExpiry} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize bolt buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func boltExpiryKey(expiresAt time.Time, id string) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(expiresAt.UnixNano()))
+	return append(key, id...)
+}
+
+func boltUserKey(userID, id string) []byte {
+	key := make([]byte, 0, len(userID)+1+len(id))
+	key = append(key, userID...)
+	key = append(key, 0)
+	return append(key, id...)
+}
+
+// putIndexes writes sess's secondary-index entries. Callers that are
+// updating an existing session must removeIndexes(old) first, since the
+// old entries won't otherwise be cleaned up if UserID or ExpiresAt changed.
+func putIndexes(tx *bbolt.Tx, sess *Session) error {
+	if err := tx.Bucket(boltBucketByUser).Put(boltUserKey(sess.UserID, sess.ID), []byte(sess.ID)); err != nil {
+		return err
+	}
+	return tx.Bucket(boltBucketByExpiry).Put(boltExpiryKey(sess.ExpiresAt, sess.ID), []byte(sess.ID))
+}
+
+func removeIndexes(tx *bbolt.Tx, sess *Session) error {
+	if err := tx.Bucket(boltBucketByUser).Delete(boltUserKey(sess.UserID, sess.ID)); err != nil {
+		return err
+	}
+	return tx.Bucket(boltBucketByExpiry).Delete(boltExpiryKey(sess.ExpiresAt, sess.ID))
+}
+
+// Create stores a new session.
+func (b *BoltStore) Create(ctx context.Context, sess *Session) error {
+	sess.ResourceVersion = 1
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		sessions := tx.Bucket(boltBucketSessions)
+		if sessions.Get([]byte(sess.ID)) != nil {
+			return fmt.Errorf("session already exists: %s", sess.ID)
+		}
+		if err := sessions.Put([]byte(sess.ID), data); err != nil {
+			return err
+		}
+		return putIndexes(tx, sess)
+	})
+}
+
+// Get retrieves a session by ID.
+func (b *BoltStore) Get(ctx context.Context, id string) (*Session, error) {
+	var sess Session
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(boltBucketSessions).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("session not found: %s", id)
+		}
+		return json.Unmarshal(data, &sess)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// GetByUser retrieves sessions by user ID using the idx_user secondary
+// index instead of scanning every session.
+func (b *BoltStore) GetByUser(ctx context.Context, userID string) ([]*Session, error) {
+	var sessions []*Session
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		sessionsBucket := tx.Bucket(boltBucketSessions)
+		prefix := append([]byte(userID), 0)
+		cur := tx.Bucket(boltBucketByUser).Cursor()
+		for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+			data := sessionsBucket.Get(v)
+			if data == nil {
+				continue
+			}
+			var sess Session
+			if err := json.Unmarshal(data, &sess); err != nil {
+				return err
+			}
+			sessions = append(sessions, &sess)
+		}
+		return nil
+	})
+	return sessions, err
+}
+
+// GetByBearerTokenHash retrieves a session by its hashed bearer token.
+// There's no secondary index for this (only UserID and ExpiresAt are
+// indexed), so it falls back to a full scan of the sessions bucket.
+func (b *BoltStore) GetByBearerTokenHash(ctx context.Context, hash string) (*Session, error) {
+	var found *Session
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltBucketSessions).ForEach(func(_, data []byte) error {
+			if found != nil {
+				return nil
+			}
+			var sess Session
+			if err := json.Unmarshal(data, &sess); err != nil {
+				return err
+			}
+			if sess.BearerTokenHash == hash {
+				found = &sess
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("session not found for bearer token")
+	}
+	return found, nil
+}
+
+// Update updates a session, rewriting its secondary-index entries in case
+// UserID or ExpiresAt changed. sess's ResourceVersion must match the
+// currently stored value, or the write is rejected with ErrConflict
+// instead of silently clobbering a concurrent writer's change.
+func (b *BoltStore) Update(ctx context.Context, sess *Session) error {
+	expectedVersion := sess.ResourceVersion
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		sessions := tx.Bucket(boltBucketSessions)
+		old := sessions.Get([]byte(sess.ID))
+		if old == nil {
+			return fmt.Errorf("session not found: %s", sess.ID)
+		}
+		var oldSess Session
+		if err := json.Unmarshal(old, &oldSess); err != nil {
+			return err
+		}
+		if oldSess.ResourceVersion != expectedVersion {
+			return fmt.Errorf("%w: session %s", ErrConflict, sess.ID)
+		}
+
+		sess.UpdatedAt = time.Now()
+		sess.ResourceVersion = expectedVersion + 1
+		data, err := json.Marshal(sess)
+		if err != nil {
+			return err
+		}
+
+		if err := removeIndexes(tx, &oldSess); err != nil {
+			return err
+		}
+		if err := sessions.Put([]byte(sess.ID), data); err != nil {
+			return err
+		}
+		return putIndexes(tx, sess)
+	})
+	if err != nil {
+		sess.ResourceVersion = expectedVersion
+		return err
+	}
+	return nil
+}
+
+// Delete deletes a session.
+func (b *BoltStore) Delete(ctx context.Context, id string) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		sessions := tx.Bucket(boltBucketSessions)
+		data := sessions.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		var sess Session
+		if err := json.Unmarshal(data, &sess); err != nil {
+			return err
+		}
+		if err := removeIndexes(tx, &sess); err != nil {
+			return err
+		}
+		return sessions.Delete([]byte(id))
+	})
+}
+
+// ListExpired lists expired sessions by walking idx_expiry from the start
+// until it passes the current time.
+func (b *BoltStore) ListExpired(ctx context.Context) ([]*Session, error) {
+	now := boltExpiryKey(time.Now(), "")
+	var sessions []*Session
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		sessionsBucket := tx.Bucket(boltBucketSessions)
+		cur := tx.Bucket(boltBucketByExpiry).Cursor()
+		for k, v := cur.First(); k != nil && bytes.Compare(k, now) < 0; k, v = cur.Next() {
+			data := sessionsBucket.Get(v)
+			if data == nil {
+				continue
+			}
+			var sess Session
+			if err := json.Unmarshal(data, &sess); err != nil {
+				return err
+			}
+			sessions = append(sessions, &sess)
+		}
+		return nil
+	})
+	return sessions, err
+}
+
+// DeleteExpired deletes expired sessions, again via idx_expiry's sorted
+// range instead of a full scan.
+func (b *BoltStore) DeleteExpired(ctx context.Context) (int, error) {
+	now := boltExpiryKey(time.Now(), "")
+	count := 0
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		sessions := tx.Bucket(boltBucketSessions)
+		expiry := tx.Bucket(boltBucketByExpiry)
+		byUser := tx.Bucket(boltBucketByUser)
+
+		cur := expiry.Cursor()
+		var expiredKeys [][]byte
+		var expiredIDs []string
+		for k, v := cur.First(); k != nil && bytes.Compare(k, now) < 0; k, v = cur.Next() {
+			expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			expiredIDs = append(expiredIDs, string(v))
+		}
+
+		for i, key := range expiredKeys {
+			id := expiredIDs[i]
+			data := sessions.Get([]byte(id))
+			if data != nil {
+				var sess Session
+				if err := json.Unmarshal(data, &sess); err == nil {
+					byUser.Delete(boltUserKey(sess.UserID, sess.ID))
+				}
+				sessions.Delete([]byte(id))
+			}
+			expiry.Delete(key)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// ListExpiringWithin lists sessions not yet expired whose ExpiresAt falls
+// within window from now, by walking idx_expiry's sorted range between the
+// two bounds.
+func (b *BoltStore) ListExpiringWithin(ctx context.Context, window time.Duration) ([]*Session, error) {
+	now := time.Now()
+	start := boltExpiryKey(now, "")
+	end := boltExpiryKey(now.Add(window), "")
+	var sessions []*Session
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		sessionsBucket := tx.Bucket(boltBucketSessions)
+		cur := tx.Bucket(boltBucketByExpiry).Cursor()
+		for k, v := cur.Seek(start); k != nil && bytes.Compare(k, end) < 0; k, v = cur.Next() {
+			data := sessionsBucket.Get(v)
+			if data == nil {
+				continue
+			}
+			var sess Session
+			if err := json.Unmarshal(data, &sess); err != nil {
+				return err
+			}
+			sessions = append(sessions, &sess)
+		}
+		return nil
+	})
+	return sessions, err
+}
+
+// Close closes the underlying BoltDB file.
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,458 @@
+package session
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"gorm.io/gorm"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session/chunking"
+)
+
+const chunkObjectPrefix = "chunks/"
+
+// ChunkedStorage implements WorkspaceStorage using FastCDC content-defined
+// chunking: each file in the workspace is split into variable-size,
+// content-addressed chunks, so Save only has to upload chunks this bucket
+// doesn't already hold. Restore downloads every distinct chunk the
+// manifest references, whatever the sandbox already has locally. Unlike MinIOStorage, which
+// round-trips the whole workspace as one tar.gz blob per Pause, unchanged
+// files cost nothing beyond the manifest row.
+type ChunkedStorage struct {
+	client       *minio.Client
+	dockerClient *client.Client
+	db           *gorm.DB
+	bucket       string
+	workDir      string
+	chunkConfig  chunking.Config
+
+	// dedup, when attached via AttachDedupObserver, receives each Save
+	// call's dedup effectiveness.
+	dedup DedupObserver
+}
+
+// DedupObserver observes ChunkedStorage.Save's dedup effectiveness,
+// letting a caller (the metrics package's counters/gauge) track bytes
+// saved over time without ChunkedStorage depending on a concrete metrics
+// backend - the same decoupling ProgressCallback gives MinIOStorage.
+type DedupObserver interface {
+	// OnSave reports, for one Save call, the total bytes across every
+	// file in the workspace and how many of those bytes actually had to
+	// be uploaded because no existing chunk already covered them.
+	OnSave(sessionID string, totalBytes, uploadedBytes int64)
+}
+
+// AttachDedupObserver wires a DedupObserver into every subsequent Save. A
+// nil observer (the default) just means dedup effectiveness goes
+// unobserved.
+func (s *ChunkedStorage) AttachDedupObserver(observer DedupObserver) {
+	s.dedup = observer
+}
+
+// ChunkedStorageConfig holds ChunkedStorage configuration.
+type ChunkedStorageConfig struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+	WorkDir   string // Workspace directory inside container
+	DB        *gorm.DB
+}
+
+// DefaultChunkedStorageConfig returns default configuration.
+func DefaultChunkedStorageConfig() ChunkedStorageConfig {
+	return ChunkedStorageConfig{
+		Endpoint:  "localhost:9000",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		Bucket:    "sandbox-workspaces",
+		UseSSL:    false,
+		WorkDir:   "/workspace",
+	}
+}
+
+// NewChunkedStorage creates a new content-defined-chunking workspace
+// storage backend.
+func NewChunkedStorage(config ChunkedStorageConfig) (*ChunkedStorage, error) {
+	minioClient, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio client: %w", err)
+	}
+
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	if config.DB == nil {
+		return nil, fmt.Errorf("chunked storage requires a database handle for manifests")
+	}
+	if err := config.DB.AutoMigrate(&WorkspaceManifest{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate workspace manifests: %w", err)
+	}
+
+	storage := &ChunkedStorage{
+		client:       minioClient,
+		dockerClient: dockerClient,
+		db:           config.DB,
+		bucket:       config.Bucket,
+		workDir:      config.WorkDir,
+		chunkConfig:  chunking.DefaultConfig(),
+	}
+
+	ctx := context.Background()
+	exists, err := storage.client.BucketExists(ctx, storage.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket: %w", err)
+	}
+	if !exists {
+		if err := storage.client.MakeBucket(ctx, storage.bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	return storage, nil
+}
+
+func chunkObjectKey(hash string) string {
+	return chunkObjectPrefix + hash
+}
+
+// Save walks the sandbox's workspace, splits every file into
+// content-defined chunks, uploads whichever chunks the bucket doesn't
+// already hold, and records the result as a new manifest revision.
+func (s *ChunkedStorage) Save(ctx context.Context, sessionID, sandboxID string) (string, error) {
+	containerName := "sandbox-" + sandboxID
+
+	reader, _, err := s.dockerClient.CopyFromContainer(ctx, containerName, s.workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy from container: %w", err)
+	}
+	defer reader.Close()
+
+	entries, err := readTarEntries(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read workspace tar: %w", err)
+	}
+
+	files := make([]ManifestFile, 0, len(entries))
+	var totalBytes, uploadedBytes int64
+	for _, entry := range entries {
+		chunks, err := chunking.Split(bytes.NewReader(entry.content), s.chunkConfig)
+		if err != nil {
+			return "", fmt.Errorf("failed to chunk %s: %w", entry.name, err)
+		}
+
+		hashes := make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			sum := sha256.Sum256(chunk.Data)
+			hash := hex.EncodeToString(sum[:])
+			hashes = append(hashes, hash)
+
+			uploaded, err := s.putChunkIfMissing(ctx, hash, chunk.Data)
+			if err != nil {
+				return "", fmt.Errorf("failed to store chunk %s: %w", hash, err)
+			}
+			if uploaded {
+				uploadedBytes += int64(len(chunk.Data))
+			}
+		}
+		totalBytes += int64(len(entry.content))
+
+		files = append(files, ManifestFile{
+			Path:    entry.name,
+			Mode:    entry.mode,
+			ModTime: entry.modTime,
+			Size:    int64(len(entry.content)),
+			Chunks:  hashes,
+		})
+	}
+
+	if s.dedup != nil {
+		s.dedup.OnSave(sessionID, totalBytes, uploadedBytes)
+	}
+
+	prev, err := latestManifest(s.db, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up previous manifest: %w", err)
+	}
+	rev := 1
+	if prev != nil {
+		rev = prev.Rev + 1
+	}
+
+	manifest := WorkspaceManifest{SessionID: sessionID, Rev: rev, CreatedAt: time.Now()}
+	if err := manifest.SetFiles(files); err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Create(&manifest).Error; err != nil {
+		return "", fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	log.Printf("[ChunkedStorage] Saved workspace for session %s at rev %d (%d files)", sessionID, rev, len(files))
+	return fmt.Sprintf("%s@%d", sessionID, rev), nil
+}
+
+// putChunkIfMissing uploads data keyed by hash unless the bucket already
+// holds an object under that key: since the key is the content's own
+// hash, a hit means the bytes are already there. Reports whether it
+// actually uploaded, for dedup-ratio/bytes-saved metrics.
+func (s *ChunkedStorage) putChunkIfMissing(ctx context.Context, hash string, data []byte) (bool, error) {
+	_, err := s.client.StatObject(ctx, s.bucket, chunkObjectKey(hash), minio.StatObjectOptions{})
+	if err == nil {
+		return false, nil
+	}
+
+	_, err = s.client.PutObject(ctx, s.bucket, chunkObjectKey(hash), bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Restore reconstructs the session's latest workspace revision and
+// copies it into the sandbox. Chunks shared by more than one file in the
+// manifest are only fetched once per call.
+func (s *ChunkedStorage) Restore(ctx context.Context, sessionID, sandboxID string) error {
+	manifest, err := latestManifest(s.db, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to look up manifest: %w", err)
+	}
+	if manifest == nil {
+		return fmt.Errorf("no saved workspace for session %s", sessionID)
+	}
+
+	files, err := manifest.Files()
+	if err != nil {
+		return fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	fetched := make(map[string][]byte)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, file := range files {
+		var content bytes.Buffer
+		for _, hash := range file.Chunks {
+			data, ok := fetched[hash]
+			if !ok {
+				data, err = s.getChunk(ctx, hash)
+				if err != nil {
+					return fmt.Errorf("failed to fetch chunk %s for %s: %w", hash, file.Path, err)
+				}
+				fetched[hash] = data
+			}
+			content.Write(data)
+		}
+
+		hdr := &tar.Header{
+			Name:    file.Path,
+			Mode:    file.Mode,
+			Size:    int64(content.Len()),
+			ModTime: file.ModTime,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write tar header for %s: %w", file.Path, err)
+		}
+		if _, err := tw.Write(content.Bytes()); err != nil {
+			return fmt.Errorf("failed to write tar content for %s: %w", file.Path, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to close tar writer: %w", err)
+	}
+
+	containerName := "sandbox-" + sandboxID
+	if err := s.dockerClient.CopyToContainer(ctx, containerName, "/", &buf, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy to container: %w", err)
+	}
+
+	log.Printf("[ChunkedStorage] Restored workspace for session %s (rev %d) to sandbox %s", sessionID, manifest.Rev, sandboxID)
+	return nil
+}
+
+func (s *ChunkedStorage) getChunk(ctx context.Context, hash string) ([]byte, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, chunkObjectKey(hash), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+	return io.ReadAll(object)
+}
+
+// Delete removes every manifest revision recorded for a session. The
+// chunks those manifests referenced are not deleted here, since other
+// sessions' manifests may still reference them; GC reclaims them once
+// they're truly orphaned.
+func (s *ChunkedStorage) Delete(ctx context.Context, sessionID string) error {
+	if err := s.db.WithContext(ctx).Where("session_id = ?", sessionID).Delete(&WorkspaceManifest{}).Error; err != nil {
+		return fmt.Errorf("failed to delete manifests: %w", err)
+	}
+	return nil
+}
+
+// Exists reports whether a session has at least one saved manifest.
+func (s *ChunkedStorage) Exists(ctx context.Context, sessionID string) (bool, error) {
+	manifest, err := latestManifest(s.db, sessionID)
+	if err != nil {
+		return false, err
+	}
+	return manifest != nil, nil
+}
+
+// Diff returns the paths that changed between sinceRev and the latest
+// revision of sessionID's workspace, by comparing each file's chunk list
+// rather than its content. Save does not consult it; it chunks every file.
+func (s *ChunkedStorage) Diff(sessionID string, sinceRev int) ([]string, error) {
+	oldManifest, err := manifestAtRev(s.db, sessionID, sinceRev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest at rev %d: %w", sinceRev, err)
+	}
+	newManifest, err := latestManifest(s.db, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load latest manifest: %w", err)
+	}
+	if newManifest == nil {
+		return nil, fmt.Errorf("no saved workspace for session %s", sessionID)
+	}
+
+	oldFiles := map[string]string{} // path -> joined chunk hashes
+	if oldManifest != nil {
+		files, err := oldManifest.Files()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			oldFiles[f.Path] = strings.Join(f.Chunks, ",")
+		}
+	}
+
+	newFiles, err := newManifest.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	seen := map[string]bool{}
+	for _, f := range newFiles {
+		seen[f.Path] = true
+		if oldFiles[f.Path] != strings.Join(f.Chunks, ",") {
+			changed = append(changed, f.Path)
+		}
+	}
+	for path := range oldFiles {
+		if !seen[path] {
+			changed = append(changed, path) // deleted since sinceRev
+		}
+	}
+
+	return changed, nil
+}
+
+// GC deletes chunks no manifest references any more. It re-counts
+// references from scratch on every call rather than maintaining a live
+// counter, since manifest rows are cheap to scan and this only needs to
+// run periodically, not on every Save.
+func (s *ChunkedStorage) GC(ctx context.Context) (int, error) {
+	var manifests []WorkspaceManifest
+	if err := s.db.WithContext(ctx).Find(&manifests).Error; err != nil {
+		return 0, fmt.Errorf("failed to list manifests: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	for _, m := range manifests {
+		files, err := m.Files()
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			for _, hash := range f.Chunks {
+				referenced[hash] = true
+			}
+		}
+	}
+
+	removed := 0
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: chunkObjectPrefix, Recursive: true})
+	for obj := range objects {
+		if obj.Err != nil {
+			return removed, obj.Err
+		}
+		hash := strings.TrimPrefix(obj.Key, chunkObjectPrefix)
+		if referenced[hash] {
+			continue
+		}
+		if err := s.client.RemoveObject(ctx, s.bucket, obj.Key, minio.RemoveObjectOptions{}); err != nil {
+			return removed, fmt.Errorf("failed to remove orphaned chunk %s: %w", hash, err)
+		}
+		removed++
+	}
+
+	log.Printf("[ChunkedStorage] GC removed %d orphaned chunks", removed)
+	return removed, nil
+}
+
+// Close closes the storage clients.
+func (s *ChunkedStorage) Close() error {
+	return s.dockerClient.Close()
+}
+
+type tarFileEntry struct {
+	name    string
+	mode    int64
+	modTime time.Time
+	content []byte
+}
+
+// readTarEntries reads a tar stream fully, preserving each regular file's
+// mode and modification time (unlike extractTar, which only keeps path
+// and content).
+func readTarEntries(r io.Reader) ([]tarFileEntry, error) {
+	var entries []tarFileEntry
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, tarFileEntry{
+			name:    strings.TrimPrefix(hdr.Name, "/"),
+			mode:    hdr.Mode,
+			modTime: hdr.ModTime,
+			content: content,
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,112 @@
+// Package chunking splits a byte stream into content-defined chunks so
+// that inserting or deleting bytes anywhere in the stream only changes
+// the one or two chunks around the edit, instead of shifting every
+// fixed-size block boundary after it. This is what lets workspace
+// snapshots dedupe unchanged file content across saves.
+package chunking
+
+import (
+	"bufio"
+	"io"
+	"math/bits"
+)
+
+const (
+	// DefaultMinSize is the smallest chunk Split will ever produce (other
+	// than a final short chunk at EOF).
+	DefaultMinSize = 16 * 1024
+	// DefaultAvgSize is the chunk size Split's boundary mask targets.
+	DefaultAvgSize = 64 * 1024
+	// DefaultMaxSize is the largest chunk Split will produce; a boundary
+	// is forced here even if the rolling hash never matches.
+	DefaultMaxSize = 256 * 1024
+)
+
+// Config controls chunk size bounds.
+type Config struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+}
+
+// DefaultConfig returns the 16KB/64KB/256KB bounds used for workspace
+// snapshots.
+func DefaultConfig() Config {
+	return Config{MinSize: DefaultMinSize, AvgSize: DefaultAvgSize, MaxSize: DefaultMaxSize}
+}
+
+// Chunk is one content-defined slice of a stream.
+type Chunk struct {
+	Offset int64
+	Data   []byte
+}
+
+// gearTable maps each byte value to a fixed pseudo-random 64-bit value,
+// per the "gear hash" rolling hash FastCDC builds on. It only needs to be
+// well-distributed, not cryptographically random, and is generated once
+// with a plain LCG so it's identical across builds.
+var gearTable = func() [256]uint64 {
+	var t [256]uint64
+	seed := uint64(0x9E3779B97F4A7C15)
+	for i := range t {
+		seed = seed*6364136223846793005 + 1442695040888963407
+		t[i] = seed
+	}
+	return t
+}()
+
+// Split reads r to EOF and splits it into content-defined chunks. A
+// boundary falls wherever the rolling gear hash's low maskBits happen to
+// be zero, once the chunk has reached MinSize; a boundary is forced at
+// MaxSize regardless.
+func Split(r io.Reader, config Config) ([]Chunk, error) {
+	if config.MinSize <= 0 || config.AvgSize <= 0 || config.MaxSize <= 0 {
+		config = DefaultConfig()
+	}
+
+	maskBits := bits.Len(uint(config.AvgSize)) - 1
+	mask := uint64(1)<<uint(maskBits) - 1
+
+	br := bufio.NewReaderSize(r, 1<<20)
+
+	var chunks []Chunk
+	var buf []byte
+	var hash uint64
+	var offset int64
+
+	flush := func() {
+		if len(buf) == 0 {
+			return
+		}
+		data := make([]byte, len(buf))
+		copy(data, buf)
+		chunks = append(chunks, Chunk{Offset: offset, Data: data})
+		offset += int64(len(buf))
+		buf = buf[:0]
+		hash = 0
+	}
+
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, b)
+		hash = (hash << 1) + gearTable[b]
+
+		if len(buf) >= config.MaxSize {
+			flush()
+			continue
+		}
+		if len(buf) >= config.MinSize && hash&mask == 0 {
+			flush()
+		}
+	}
+	flush()
+
+	return chunks, nil
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// codec identifies how a cached session payload's bytes are compressed.
+type codec byte
+
+const (
+	codecNone codec = 0
+	codecGzip codec = 1
+	codecZstd codec = 2
+
+	// envelopeMagic prefixes every payload written by encodeSession. It's
+	// not a valid leading byte for a JSON object ('{' == 0x7B), so
+	// decodeSession can tell a versioned, possibly-compressed envelope
+	// apart from a plain JSON blob written before this format existed.
+	envelopeMagic byte = 0xFE
+)
+
+// encodeSession serializes session as JSON, compresses it per c, and
+// prefixes the result with a header carrying the codec and version so
+// decodeSession can reverse it without being told which codec was used.
+func encodeSession(session *Session, version int64, c codec) ([]byte, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	switch c {
+	case codecGzip:
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to gzip session: %w", err)
+		}
+		if err := gw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to gzip session: %w", err)
+		}
+		data = buf.Bytes()
+	case codecZstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		data = enc.EncodeAll(data, nil)
+		enc.Close()
+	}
+
+	header := make([]byte, 10)
+	header[0] = envelopeMagic
+	header[1] = byte(c)
+	binary.BigEndian.PutUint64(header[2:], uint64(version))
+
+	return append(header, data...), nil
+}
+
+// decodeSession reverses encodeSession. Data written before the envelope
+// format existed (plain JSON, no magic prefix) decodes as version 0.
+func decodeSession(data []byte) (*Session, int64, error) {
+	if len(data) == 0 || data[0] != envelopeMagic {
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal session: %w", err)
+		}
+		return &session, 0, nil
+	}
+
+	if len(data) < 10 {
+		return nil, 0, fmt.Errorf("truncated session envelope")
+	}
+	c := codec(data[1])
+	version := int64(binary.BigEndian.Uint64(data[2:10]))
+	payload := data[10:]
+
+	switch c {
+	case codecGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open gzip session payload: %w", err)
+		}
+		defer gr.Close()
+		decoded, err := io.ReadAll(gr)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read gzip session payload: %w", err)
+		}
+		payload = decoded
+	case codecZstd:
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create zstd decoder: %w", err)
+		}
+		decoded, err := dec.DecodeAll(payload, nil)
+		dec.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode zstd session payload: %w", err)
+		}
+		payload = decoded
+	case codecNone:
+		// payload is already plain JSON
+	default:
+		return nil, 0, fmt.Errorf("unknown session cache codec: %d", c)
+	}
+
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return &session, version, nil
+}
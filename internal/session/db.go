@@ -0,0 +1,106 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DBConfig selects the SQL driver and connection pool settings a
+// DBConnector should use once it is asked to open a connection.
+type DBConfig struct {
+	Driver          string // "postgres", "sqlite", or "mysql"
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DBConnector lazily opens and memoizes a single *gorm.DB connection pool.
+// PostgresStore, SQLiteStore and MySQLStore all hold one rather than
+// dialing in their constructors, so the same pool survives Pause/Resume
+// cycles of the process embedding them and tests can substitute their own
+// already-open db (an in-memory SQLite handle, say) without touching the
+// Driver/DSN fields at all.
+type DBConnector struct {
+	Config DBConfig
+
+	mu sync.Mutex
+	db *gorm.DB
+}
+
+// GetDB returns the shared *gorm.DB, opening and migrating it on first
+// use. Later calls reuse the pool and just re-scope it to ctx.
+func (c *DBConnector) GetDB(ctx context.Context) (*gorm.DB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db == nil {
+		dialector, err := c.Config.dialector()
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := gorm.Open(dialector, &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s database: %w", c.Config.Driver, err)
+		}
+
+		if sqlDB, err := db.DB(); err == nil {
+			if c.Config.MaxOpenConns > 0 {
+				sqlDB.SetMaxOpenConns(c.Config.MaxOpenConns)
+			}
+			if c.Config.MaxIdleConns > 0 {
+				sqlDB.SetMaxIdleConns(c.Config.MaxIdleConns)
+			}
+			if c.Config.ConnMaxLifetime > 0 {
+				sqlDB.SetConnMaxLifetime(c.Config.ConnMaxLifetime)
+			}
+		}
+
+		if err := db.AutoMigrate(&Session{}); err != nil {
+			return nil, fmt.Errorf("failed to migrate: %w", err)
+		}
+
+		c.db = db
+	}
+
+	return c.db.WithContext(ctx), nil
+}
+
+// Close closes the underlying connection pool, if one was ever opened.
+func (c *DBConnector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db == nil {
+		return nil
+	}
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func (c DBConfig) dialector() (gorm.Dialector, error) {
+	switch c.Driver {
+	case "postgres":
+		return postgres.Open(c.DSN), nil
+	case "sqlite":
+		return sqlite.Open(c.DSN), nil
+	case "mysql":
+		return mysql.Open(c.DSN), nil
+	default:
+		return nil, fmt.Errorf("session: unknown db driver %q", c.Driver)
+	}
+}
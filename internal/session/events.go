@@ -0,0 +1,169 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EventType identifies a session lifecycle transition.
+type EventType string
+
+const (
+	EventCreated           EventType = "session.created"
+	EventPaused            EventType = "session.paused"
+	EventResumed           EventType = "session.resumed"
+	EventDeleted           EventType = "session.deleted"
+	EventExpired           EventType = "session.expired"
+	EventSandboxBound      EventType = "session.sandbox_bound"
+	EventSandboxUnbound    EventType = "session.sandbox_unbound"
+	EventWorkspaceSaved    EventType = "session.workspace_saved"
+	EventWorkspaceRestored EventType = "session.workspace_restored"
+	EventAboutToExpire     EventType = "session.about_to_expire"
+	EventWorkspaceForked   EventType = "session.workspace_forked"
+	EventWorkspaceTagged   EventType = "session.workspace_tagged"
+)
+
+// Event describes a single session lifecycle transition. Seq is the
+// session's EventSeq at the time of the transition, so a subscriber that
+// missed some events (a dropped webhook delivery, a disconnected Watch
+// stream) can tell it has a gap and replay from the last Seq it saw via
+// GET /api/v1/sessions/{id}/events?since=N.
+type Event struct {
+	Type       EventType `json:"type"`
+	SessionID  string    `json:"session_id"`
+	UserID     string    `json:"user_id"`
+	OldStatus  Status    `json:"old_status,omitempty"`
+	NewStatus  Status    `json:"new_status"`
+	SandboxID  string    `json:"sandbox_id,omitempty"`
+	Seq        int64     `json:"seq"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// EventPublisher delivers lifecycle events to a sink: an in-process
+// fan-out, an HTTP webhook, a Kafka topic, and so on. WebhookPublisher and
+// KafkaPublisher deliver synchronously inside Publish, bounded only by
+// their client's timeout; neither queues events, so a slow sink delays
+// the caller.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// MultiPublisher fans an event out to every configured EventPublisher,
+// continuing past individual failures so one broken sink (a webhook
+// endpoint that's down) doesn't stop the others from receiving the event.
+type MultiPublisher struct {
+	publishers []EventPublisher
+}
+
+// NewMultiPublisher combines publishers into one EventPublisher.
+func NewMultiPublisher(publishers ...EventPublisher) *MultiPublisher {
+	return &MultiPublisher{publishers: publishers}
+}
+
+// Publish calls Publish on every configured publisher, returning the first
+// error encountered (after attempting all of them).
+func (m *MultiPublisher) Publish(ctx context.Context, event Event) error {
+	var firstErr error
+	for _, p := range m.publishers {
+		if err := p.Publish(ctx, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// defaultEventHistoryLimit bounds how many events FanoutPublisher keeps
+// per session for replay. Older events age out; a subscriber that falls
+// further behind than this needs a stronger durability guarantee, which is
+// what the Kafka sink is for.
+const defaultEventHistoryLimit = 500
+
+// FanoutPublisher is the in-process sink: it delivers events to local
+// Subscribe callers (DefaultManager.Watch, in turn the gRPC Watch RPC) and
+// retains a bounded per-session history so the /events?since=N endpoint
+// can serve a replay without a subscriber having been connected the whole
+// time.
+type FanoutPublisher struct {
+	mu           sync.Mutex
+	subscribers  map[string][]chan Event
+	history      map[string][]Event
+	historyLimit int
+}
+
+// NewFanoutPublisher creates a FanoutPublisher retaining historyLimit
+// events per session (defaultEventHistoryLimit if historyLimit <= 0).
+func NewFanoutPublisher(historyLimit int) *FanoutPublisher {
+	if historyLimit <= 0 {
+		historyLimit = defaultEventHistoryLimit
+	}
+	return &FanoutPublisher{
+		subscribers:  make(map[string][]chan Event),
+		history:      make(map[string][]Event),
+		historyLimit: historyLimit,
+	}
+}
+
+// Publish records event in its session's history and delivers it to that
+// session's subscribers, dropping the send for any subscriber that isn't
+// keeping up rather than blocking the caller.
+func (f *FanoutPublisher) Publish(ctx context.Context, event Event) error {
+	f.mu.Lock()
+	hist := append(f.history[event.SessionID], event)
+	if len(hist) > f.historyLimit {
+		hist = hist[len(hist)-f.historyLimit:]
+	}
+	f.history[event.SessionID] = hist
+	subs := append([]chan Event(nil), f.subscribers[event.SessionID]...)
+	f.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel of sessionID's future events and a cancel
+// func that must be called to release the subscription.
+func (f *FanoutPublisher) Subscribe(sessionID string) (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	f.mu.Lock()
+	f.subscribers[sessionID] = append(f.subscribers[sessionID], ch)
+	f.mu.Unlock()
+
+	cancel := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		subs := f.subscribers[sessionID]
+		for i, c := range subs {
+			if c == ch {
+				f.subscribers[sessionID] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(f.subscribers[sessionID]) == 0 {
+			delete(f.subscribers, sessionID)
+		}
+	}
+	return ch, cancel
+}
+
+// Since returns sessionID's retained events with Seq > since, for the
+// /api/v1/sessions/{id}/events?since=N replay endpoint.
+func (f *FanoutPublisher) Since(sessionID string, since int64) []Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hist := f.history[sessionID]
+	out := make([]Event, 0, len(hist))
+	for _, evt := range hist {
+		if evt.Seq > since {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
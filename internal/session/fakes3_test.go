@@ -0,0 +1,105 @@
+package session
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeS3Server is a minimal stand-in for the S3-compatible endpoint
+// minioBackend (and ChunkedStorage's chunk store) talk to through
+// minio-go: just enough of PUT/GET/HEAD/DELETE on /{bucket}/{key} to
+// exercise Put/Get/Stat/Delete, plus no-op 200s for the bucket-level
+// housekeeping calls (MakeBucket, BucketExists, EnableVersioning) made at
+// construction time. It doesn't validate SigV4 signatures or implement
+// multipart uploads/versioning/listing.
+type fakeS3Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3Server() *fakeS3Server {
+	s := &fakeS3Server{objects: make(map[string][]byte)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// endpoint returns the host:port minio.New expects, with the scheme
+// stripped.
+func (s *fakeS3Server) endpoint() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func (s *fakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	parts := strings.SplitN(path, "/", 2)
+
+	// A bucket-level request (no object key, or the ?versioning
+	// sub-resource used by EnableVersioning) just needs to succeed - this
+	// fake doesn't model bucket existence or versioning state.
+	if len(parts) < 2 || parts[1] == "" || r.URL.Query().Has("versioning") || r.URL.Query().Has("location") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	key := parts[1]
+
+	// ListObjects (ChunkedStorage.GC) targets the bucket with a
+	// ?prefix=... query and no object segment beyond the bucket name in
+	// practice the minio SDK still addresses /{bucket}?list-type=2&..., so
+	// it's already handled by the bucket-level branch above; this fake
+	// doesn't support GC's listing path and callers relying on it should
+	// use a real MinIO instance.
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.objects[key] = data
+		w.Header().Set("ETag", `"fake-etag"`)
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"fake-etag"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+
+	case http.MethodHead:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"fake-etag"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/client"
+)
+
+// fsBackend implements Backend directly on the local filesystem, for
+// air-gapped or single-node dev setups that don't want to run an object
+// store just to hold workspace snapshots.
+type fsBackend struct {
+	baseDir string
+}
+
+func (b *fsBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	path := filepath.Join(b.baseDir, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+func (b *fsBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(b.baseDir, key))
+}
+
+func (b *fsBackend) Delete(ctx context.Context, key string) error {
+	err := os.Remove(filepath.Join(b.baseDir, key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (b *fsBackend) Stat(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(filepath.Join(b.baseDir, key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FSStorage implements WorkspaceStorage directly on the local filesystem.
+type FSStorage struct {
+	*objectWorkspaceStorage
+}
+
+// FSStorageConfig holds local filesystem workspace storage configuration.
+type FSStorageConfig struct {
+	// BaseDir is the root directory workspace snapshots are written
+	// under.
+	BaseDir string
+	WorkDir string // Workspace directory inside container
+
+	// CopyBufferSize bounds the intermediate buffer Restore streams
+	// through when copying into a container. Zero means
+	// defaultCopyBufferSize.
+	CopyBufferSize int
+
+	// MaxConcurrentTransfers bounds how many Save/Restore calls run at
+	// once. Zero means defaultMaxConcurrentTransfers.
+	MaxConcurrentTransfers int
+}
+
+// NewFSStorage creates (if necessary) config.BaseDir and returns a
+// workspace storage backed by it.
+func NewFSStorage(config FSStorageConfig) (*FSStorage, error) {
+	if err := os.MkdirAll(config.BaseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fs workspace storage directory %s: %w", config.BaseDir, err)
+	}
+
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	return &FSStorage{
+		objectWorkspaceStorage: newObjectWorkspaceStorage(
+			&fsBackend{baseDir: config.BaseDir},
+			dockerClient, config.WorkDir, config.CopyBufferSize, config.MaxConcurrentTransfers,
+		),
+	}, nil
+}
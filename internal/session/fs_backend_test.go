@@ -0,0 +1,9 @@
+package session
+
+import "testing"
+
+func TestFSBackendConformance(t *testing.T) {
+	runBackendConformance(t, func(t *testing.T) Backend {
+		return &fsBackend{baseDir: t.TempDir()}
+	})
+}
@@ -0,0 +1,357 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FSStore implements Store directly on the filesystem, for single-binary
+// deployments that don't want to embed even a BoltDB file. Each session is
+// one JSON file; two directories of empty marker files serve as secondary
+// indexes so GetByUser and ListExpired don't have to read every session:
+//
+//	sessions/<id>.json
+//	idx_user/<userID>/<id>
+//	idx_expiry/<expiresAt unix nano, zero-padded>-<id>
+//
+// os.ReadDir returns entries sorted by name, so idx_expiry's zero-padded
+// timestamps sort chronologically and ListExpired/DeleteExpired can stop
+// as soon as they pass the current time.
+//
+// All reads and writes go through a single mutex. That's coarse for a
+// multi-process deployment, but FSStore is meant for single-binary,
+// single-process use; anything wanting concurrent writers belongs on
+// BoltStore or a SQL backend instead.
+type FSStore struct {
+	mu      sync.Mutex
+	baseDir string
+}
+
+// FSConfig holds filesystem store configuration.
+type FSConfig struct {
+	// BaseDir is the root directory for sessions and their indexes.
+	BaseDir string
+}
+
+// DefaultFSConfig returns default filesystem store configuration.
+func DefaultFSConfig() FSConfig {
+	return FSConfig{BaseDir: "./sessions-data"}
+}
+
+// NewFSStore creates (if necessary) the store's directory layout under
+// config.BaseDir.
+func NewFSStore(config FSConfig) (*FSStore, error) {
+	s := &FSStore{baseDir: config.BaseDir}
+	for _, dir := range []string{s.sessionsDir(), s.userIndexDir(), s.expiryIndexDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create fs store directory %s: %w", dir, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *FSStore) sessionsDir() string    { return filepath.Join(s.baseDir, "sessions") }
+func (s *FSStore) userIndexDir() string   { return filepath.Join(s.baseDir, "idx_user") }
+func (s *FSStore) expiryIndexDir() string { return filepath.Join(s.baseDir, "idx_expiry") }
+
+func (s *FSStore) sessionPath(id string) string {
+	return filepath.Join(s.sessionsDir(), id+".json")
+}
+
+func (s *FSStore) userMarkerPath(userID, id string) string {
+	return filepath.Join(s.userIndexDir(), userID, id)
+}
+
+func (s *FSStore) expiryMarkerName(expiresAt time.Time, id string) string {
+	return fmt.Sprintf("%020d-%s", expiresAt.UnixNano(), id)
+}
+
+func (s *FSStore) expiryMarkerPath(expiresAt time.Time, id string) string {
+	return filepath.Join(s.expiryIndexDir(), s.expiryMarkerName(expiresAt, id))
+}
+
+// writeFileAtomic writes data to path via a temp file + rename, so a
+// reader never observes a partially-written session.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp-" + uuid.NewString()
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FSStore) putIndexes(sess *Session) error {
+	if err := os.MkdirAll(filepath.Join(s.userIndexDir(), sess.UserID), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.userMarkerPath(sess.UserID, sess.ID), nil, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(s.expiryMarkerPath(sess.ExpiresAt, sess.ID), nil, 0644)
+}
+
+func (s *FSStore) removeIndexes(sess *Session) {
+	os.Remove(s.userMarkerPath(sess.UserID, sess.ID))
+	os.Remove(s.expiryMarkerPath(sess.ExpiresAt, sess.ID))
+}
+
+func (s *FSStore) readSession(id string) (*Session, error) {
+	data, err := os.ReadFile(s.sessionPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, err
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// Create stores a new session.
+func (s *FSStore) Create(ctx context.Context, sess *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.sessionPath(sess.ID)); err == nil {
+		return fmt.Errorf("session already exists: %s", sess.ID)
+	}
+
+	sess.ResourceVersion = 1
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.sessionPath(sess.ID), data); err != nil {
+		return err
+	}
+	return s.putIndexes(sess)
+}
+
+// Get retrieves a session by ID.
+func (s *FSStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readSession(id)
+}
+
+// GetByUser retrieves sessions by user ID using the idx_user secondary
+// index instead of reading every session file.
+func (s *FSStore) GetByUser(ctx context.Context, userID string) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(s.userIndexDir(), userID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(entries))
+	for _, entry := range entries {
+		sess, err := s.readSession(entry.Name())
+		if err != nil {
+			continue // index entry outlived its session; skip rather than fail the whole query
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// GetByBearerTokenHash retrieves a session by its hashed bearer token.
+// There's no secondary index for this (only UserID and ExpiresAt are
+// indexed), so it falls back to reading every session file.
+func (s *FSStore) GetByBearerTokenHash(ctx context.Context, hash string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.sessionsDir())
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		sess, err := s.readSession(id)
+		if err != nil {
+			continue
+		}
+		if sess.BearerTokenHash == hash {
+			return sess, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found for bearer token")
+}
+
+// Update updates a session, rewriting its secondary-index entries in case
+// UserID or ExpiresAt changed. sess's ResourceVersion must match the
+// currently stored value, or the write is rejected with ErrConflict
+// instead of silently clobbering a concurrent writer's change.
+func (s *FSStore) Update(ctx context.Context, sess *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.readSession(sess.ID)
+	if err != nil {
+		return err
+	}
+	expectedVersion := sess.ResourceVersion
+	if old.ResourceVersion != expectedVersion {
+		return fmt.Errorf("%w: session %s", ErrConflict, sess.ID)
+	}
+
+	sess.UpdatedAt = time.Now()
+	sess.ResourceVersion = expectedVersion + 1
+	data, err := json.Marshal(sess)
+	if err != nil {
+		sess.ResourceVersion = expectedVersion
+		return err
+	}
+	if err := writeFileAtomic(s.sessionPath(sess.ID), data); err != nil {
+		sess.ResourceVersion = expectedVersion
+		return err
+	}
+
+	s.removeIndexes(old)
+	return s.putIndexes(sess)
+}
+
+// Delete deletes a session.
+func (s *FSStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, err := s.readSession(id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return nil
+	}
+	s.removeIndexes(sess)
+	return os.Remove(s.sessionPath(id))
+}
+
+// ListExpired lists expired sessions by walking idx_expiry in sorted
+// order until it passes the current time.
+func (s *FSStore) ListExpired(ctx context.Context) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.expiredIDsLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		if sess, err := s.readSession(id); err == nil {
+			sessions = append(sessions, sess)
+		}
+	}
+	return sessions, nil
+}
+
+// DeleteExpired deletes expired sessions.
+func (s *FSStore) DeleteExpired(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.expiredIDsLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		sess, err := s.readSession(id)
+		if err == nil {
+			s.removeIndexes(sess)
+		}
+		if err := os.Remove(s.sessionPath(id)); err == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListExpiringWithin lists sessions not yet expired whose ExpiresAt falls
+// within window from now, by walking idx_expiry between the two bounds.
+func (s *FSStore) ListExpiringWithin(ctx context.Context, window time.Duration) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.expiryIndexDir())
+	if err != nil {
+		return nil, err
+	}
+
+	nowPrefix := fmt.Sprintf("%020d", time.Now().UnixNano())
+	untilPrefix := fmt.Sprintf("%020d", time.Now().Add(window).UnixNano())
+	start := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name() >= nowPrefix
+	})
+	end := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name() >= untilPrefix
+	})
+
+	sessions := make([]*Session, 0, end-start)
+	for _, entry := range entries[start:end] {
+		parts := strings.SplitN(entry.Name(), "-", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if sess, err := s.readSession(parts[1]); err == nil {
+			sessions = append(sessions, sess)
+		}
+	}
+	return sessions, nil
+}
+
+// expiredIDsLocked returns the IDs of every session whose idx_expiry
+// marker sorts before now. Callers must hold s.mu.
+func (s *FSStore) expiredIDsLocked() ([]string, error) {
+	entries, err := os.ReadDir(s.expiryIndexDir())
+	if err != nil {
+		return nil, err
+	}
+
+	nowPrefix := fmt.Sprintf("%020d", time.Now().UnixNano())
+	// os.ReadDir already sorts by name, so this is a prefix search, not a
+	// full sort.
+	idx := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name() >= nowPrefix
+	})
+
+	ids := make([]string, 0, idx)
+	for _, entry := range entries[:idx] {
+		parts := strings.SplitN(entry.Name(), "-", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if _, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
+			continue
+		}
+		ids = append(ids, parts[1])
+	}
+	return ids, nil
+}
+
+// Close is a no-op; FSStore holds no open handles between calls.
+func (s *FSStore) Close() error {
+	return nil
+}
@@ -0,0 +1,143 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"cloud.google.com/go/storage"
+	"github.com/docker/docker/client"
+	"google.golang.org/api/option"
+)
+
+// gcsBackend implements Backend against a Google Cloud Storage bucket.
+type gcsBackend struct {
+	client *storage.Client
+	bucket string
+}
+
+func (b *gcsBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	w := b.client.Bucket(b.bucket).Object(key).NewWriter(ctx)
+	w.ContentType = "application/gzip"
+	if _, err := io.Copy(w, r); err != nil {
+		// Deliberately don't call w.Close() here: that would commit
+		// whatever partial bytes made it through as the final object.
+		// Leaving the writer unclosed abandons the upload instead.
+		return fmt.Errorf("failed to write gcs object: %w", err)
+	}
+	return w.Close()
+}
+
+func (b *gcsBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.client.Bucket(b.bucket).Object(key).NewReader(ctx)
+}
+
+func (b *gcsBackend) Delete(ctx context.Context, key string) error {
+	err := b.client.Bucket(b.bucket).Object(key).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (b *gcsBackend) Stat(ctx context.Context, key string) (bool, error) {
+	_, err := b.client.Bucket(b.bucket).Object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GCSStorage implements WorkspaceStorage using Google Cloud Storage.
+type GCSStorage struct {
+	*objectWorkspaceStorage
+	client *storage.Client
+}
+
+// GCSConfig holds Google Cloud Storage connection configuration.
+type GCSConfig struct {
+	Bucket  string
+	WorkDir string // Workspace directory inside container
+
+	// ProjectID is used only to create Bucket if it doesn't already
+	// exist; an existing bucket is used as-is without one.
+	ProjectID string
+
+	// HTTPClient, when set, replaces the GCS client's default
+	// ADC-derived transport - e.g. to inject a workload-identity-bound
+	// client or, in tests, a fake server's client.
+	HTTPClient *http.Client
+
+	// CopyBufferSize bounds the intermediate buffer Restore streams
+	// through when copying into a container. Zero means
+	// defaultCopyBufferSize.
+	CopyBufferSize int
+
+	// MaxConcurrentTransfers bounds how many Save/Restore calls run at
+	// once. Zero means defaultMaxConcurrentTransfers.
+	MaxConcurrentTransfers int
+}
+
+// NewGCSStorage creates a new Google Cloud Storage workspace storage.
+func NewGCSStorage(config GCSConfig) (*GCSStorage, error) {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if config.HTTPClient != nil {
+		opts = append(opts, option.WithHTTPClient(config.HTTPClient))
+	}
+	gcsClient, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcs client: %w", err)
+	}
+
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	storageInst := &GCSStorage{
+		objectWorkspaceStorage: newObjectWorkspaceStorage(
+			&gcsBackend{client: gcsClient, bucket: config.Bucket},
+			dockerClient, config.WorkDir, config.CopyBufferSize, config.MaxConcurrentTransfers,
+		),
+		client: gcsClient,
+	}
+
+	if err := storageInst.ensureBucket(ctx, config.Bucket, config.ProjectID); err != nil {
+		return nil, err
+	}
+
+	return storageInst, nil
+}
+
+// ensureBucket creates bucket if it doesn't already exist.
+func (s *GCSStorage) ensureBucket(ctx context.Context, bucket, projectID string) error {
+	_, err := s.client.Bucket(bucket).Attrs(ctx)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, storage.ErrBucketNotExist) {
+		return fmt.Errorf("failed to check gcs bucket: %w", err)
+	}
+
+	if err := s.client.Bucket(bucket).Create(ctx, projectID, nil); err != nil {
+		return fmt.Errorf("failed to create gcs bucket: %w", err)
+	}
+	log.Printf("[GCS] Created bucket: %s", bucket)
+	return nil
+}
+
+// Close closes the GCS client and the underlying docker client.
+func (s *GCSStorage) Close() error {
+	if err := s.client.Close(); err != nil {
+		return err
+	}
+	return s.objectWorkspaceStorage.Close()
+}
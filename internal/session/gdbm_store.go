@@ -0,0 +1,379 @@
+//go:build cgo
+
+package session
+
+// GDBMStore binds directly to libgdbm via cgo. It requires CGO_ENABLED=1
+// and libgdbm-dev (or your distro's gdbm-devel) at build time; there is no
+// pure-Go GDBM implementation to fall back to. Deployments that can't
+// satisfy that should use BoltStore or FSStore instead, which cover the
+// same single-binary use case without the cgo dependency.
+
+/*
+#cgo LDFLAGS: -lgdbm
+#include <gdbm.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// GDBMStore implements Store on top of three GDBM databases: the sessions
+// themselves, plus idx_user and idx_expiry secondary indexes. GDBM is a
+// pure hash table with no ordered iteration, so unlike BoltStore the
+// indexes only save GetByUser/ListExpired from deserializing every
+// session's JSON; ListExpired and DeleteExpired still walk every entry of
+// idx_expiry (cheap: it's just an id and a timestamp) rather than every
+// entry of the sessions database.
+type GDBMStore struct {
+	mu       sync.Mutex
+	sessions C.GDBM_FILE
+	byUser   C.GDBM_FILE
+	byExpiry C.GDBM_FILE
+}
+
+// GDBMConfig holds GDBM connection configuration.
+type GDBMConfig struct {
+	// Path is the base path; the store opens "<Path>.sessions.gdbm",
+	// "<Path>.idx_user.gdbm" and "<Path>.idx_expiry.gdbm" alongside it.
+	Path string
+}
+
+// DefaultGDBMConfig returns default GDBM configuration.
+func DefaultGDBMConfig() GDBMConfig {
+	return GDBMConfig{Path: "sessions"}
+}
+
+func gdbmOpen(path string) (C.GDBM_FILE, error) {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+
+	handle := C.gdbm_open(cPath, 0, C.GDBM_WRCREAT, 0644, nil)
+	if handle == nil {
+		return nil, fmt.Errorf("failed to open gdbm database %s: %s", path, C.GoString(C.gdbm_strerror(C.gdbm_errno)))
+	}
+	return handle, nil
+}
+
+// NewGDBMStore opens (creating if necessary) a GDBM-backed store.
+func NewGDBMStore(config GDBMConfig) (*GDBMStore, error) {
+	sessions, err := gdbmOpen(config.Path + ".sessions.gdbm")
+	if err != nil {
+		return nil, err
+	}
+	byUser, err := gdbmOpen(config.Path + ".idx_user.gdbm")
+	if err != nil {
+		C.gdbm_close(sessions)
+		return nil, err
+	}
+	byExpiry, err := gdbmOpen(config.Path + ".idx_expiry.gdbm")
+	if err != nil {
+		C.gdbm_close(sessions)
+		C.gdbm_close(byUser)
+		return nil, err
+	}
+
+	return &GDBMStore{
+		sessions: sessions,
+		byUser:   byUser,
+		byExpiry: byExpiry,
+	}, nil
+}
+
+func makeDatum(b []byte) C.datum {
+	if len(b) == 0 {
+		return C.datum{dptr: nil, dsize: 0}
+	}
+	return C.datum{dptr: (*C.char)(unsafe.Pointer(&b[0])), dsize: C.int(len(b))}
+}
+
+func gdbmGet(handle C.GDBM_FILE, key []byte) ([]byte, bool) {
+	d := C.gdbm_fetch(handle, makeDatum(key))
+	if d.dptr == nil {
+		return nil, false
+	}
+	defer C.free(unsafe.Pointer(d.dptr))
+	return C.GoBytes(unsafe.Pointer(d.dptr), d.dsize), true
+}
+
+func gdbmPut(handle C.GDBM_FILE, key, value []byte) error {
+	if rc := C.gdbm_store(handle, makeDatum(key), makeDatum(value), C.GDBM_REPLACE); rc != 0 {
+		return fmt.Errorf("gdbm_store failed: %s", C.GoString(C.gdbm_strerror(C.gdbm_errno)))
+	}
+	return nil
+}
+
+func gdbmDelete(handle C.GDBM_FILE, key []byte) {
+	C.gdbm_delete(handle, makeDatum(key))
+}
+
+// gdbmKeys returns every key currently in handle, via gdbm_firstkey /
+// gdbm_nextkey. GDBM has no ordered iteration, so this is always a full
+// walk of whichever database it's called on.
+func gdbmKeys(handle C.GDBM_FILE) [][]byte {
+	var keys [][]byte
+	d := C.gdbm_firstkey(handle)
+	for d.dptr != nil {
+		keys = append(keys, C.GoBytes(unsafe.Pointer(d.dptr), d.dsize))
+		next := C.gdbm_nextkey(handle, d)
+		C.free(unsafe.Pointer(d.dptr))
+		d = next
+	}
+	return keys
+}
+
+func userIndexValue(userID string) []byte { return []byte(userID) }
+
+func expiryIndexValue(expiresAt time.Time) []byte {
+	return []byte(strconv.FormatInt(expiresAt.UnixNano(), 10))
+}
+
+// Create stores a new session.
+func (g *GDBMStore) Create(ctx context.Context, sess *Session) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := []byte(sess.ID)
+	if _, ok := gdbmGet(g.sessions, id); ok {
+		return fmt.Errorf("session already exists: %s", sess.ID)
+	}
+
+	sess.ResourceVersion = 1
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	if err := gdbmPut(g.sessions, id, data); err != nil {
+		return err
+	}
+	if err := gdbmPut(g.byUser, id, userIndexValue(sess.UserID)); err != nil {
+		return err
+	}
+	return gdbmPut(g.byExpiry, id, expiryIndexValue(sess.ExpiresAt))
+}
+
+// Get retrieves a session by ID.
+func (g *GDBMStore) Get(ctx context.Context, id string) (*Session, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	data, ok := gdbmGet(g.sessions, []byte(id))
+	if !ok {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// GetByUser retrieves sessions by user ID. The idx_user index avoids
+// deserializing sessions that don't belong to userID, but since GDBM
+// can't look values up by value, it still walks every id in the index.
+func (g *GDBMStore) GetByUser(ctx context.Context, userID string) ([]*Session, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var sessions []*Session
+	for _, id := range gdbmKeys(g.byUser) {
+		owner, ok := gdbmGet(g.byUser, id)
+		if !ok || string(owner) != userID {
+			continue
+		}
+		data, ok := gdbmGet(g.sessions, id)
+		if !ok {
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal(data, &sess); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &sess)
+	}
+	return sessions, nil
+}
+
+// GetByBearerTokenHash retrieves a session by its hashed bearer token.
+// There's no secondary index for this, so it scans every session.
+func (g *GDBMStore) GetByBearerTokenHash(ctx context.Context, hash string) (*Session, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, id := range gdbmKeys(g.sessions) {
+		data, ok := gdbmGet(g.sessions, id)
+		if !ok {
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal(data, &sess); err != nil {
+			continue
+		}
+		if sess.BearerTokenHash == hash {
+			return &sess, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found for bearer token")
+}
+
+// Update updates a session, rewriting its secondary-index entries. sess's
+// ResourceVersion must match the currently stored value, or the write is
+// rejected with ErrConflict instead of silently clobbering a concurrent
+// writer's change.
+func (g *GDBMStore) Update(ctx context.Context, sess *Session) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := []byte(sess.ID)
+	existing, ok := gdbmGet(g.sessions, id)
+	if !ok {
+		return fmt.Errorf("session not found: %s", sess.ID)
+	}
+	var current Session
+	if err := json.Unmarshal(existing, &current); err != nil {
+		return err
+	}
+	expectedVersion := sess.ResourceVersion
+	if current.ResourceVersion != expectedVersion {
+		return fmt.Errorf("%w: session %s", ErrConflict, sess.ID)
+	}
+
+	sess.UpdatedAt = time.Now()
+	sess.ResourceVersion = expectedVersion + 1
+	data, err := json.Marshal(sess)
+	if err != nil {
+		sess.ResourceVersion = expectedVersion
+		return err
+	}
+	if err := gdbmPut(g.sessions, id, data); err != nil {
+		sess.ResourceVersion = expectedVersion
+		return err
+	}
+	if err := gdbmPut(g.byUser, id, userIndexValue(sess.UserID)); err != nil {
+		return err
+	}
+	return gdbmPut(g.byExpiry, id, expiryIndexValue(sess.ExpiresAt))
+}
+
+// Delete deletes a session.
+func (g *GDBMStore) Delete(ctx context.Context, id string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := []byte(id)
+	gdbmDelete(g.sessions, key)
+	gdbmDelete(g.byUser, key)
+	gdbmDelete(g.byExpiry, key)
+	return nil
+}
+
+// expiredIDsLocked returns every session ID whose idx_expiry entry is
+// before now. Callers must hold g.mu.
+func (g *GDBMStore) expiredIDsLocked() []string {
+	now := time.Now().UnixNano()
+	var ids []string
+	for _, id := range gdbmKeys(g.byExpiry) {
+		raw, ok := gdbmGet(g.byExpiry, id)
+		if !ok {
+			continue
+		}
+		expiresAt, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil || expiresAt >= now {
+			continue
+		}
+		ids = append(ids, string(id))
+	}
+	return ids
+}
+
+// ListExpired lists expired sessions.
+func (g *GDBMStore) ListExpired(ctx context.Context) ([]*Session, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var sessions []*Session
+	for _, id := range g.expiredIDsLocked() {
+		data, ok := gdbmGet(g.sessions, []byte(id))
+		if !ok {
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal(data, &sess); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &sess)
+	}
+	return sessions, nil
+}
+
+// ListExpiringWithin lists sessions not yet expired whose ExpiresAt falls
+// within window from now. GDBM's byExpiry index isn't ordered, so this
+// scans every entry like expiredIDsLocked does.
+func (g *GDBMStore) ListExpiringWithin(ctx context.Context, window time.Duration) ([]*Session, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	until := time.Now().Add(window).UnixNano()
+
+	var sessions []*Session
+	for _, id := range gdbmKeys(g.byExpiry) {
+		raw, ok := gdbmGet(g.byExpiry, id)
+		if !ok {
+			continue
+		}
+		expiresAt, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil || expiresAt < now || expiresAt >= until {
+			continue
+		}
+		data, ok := gdbmGet(g.sessions, id)
+		if !ok {
+			continue
+		}
+		var sess Session
+		if err := json.Unmarshal(data, &sess); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &sess)
+	}
+	return sessions, nil
+}
+
+// DeleteExpired deletes expired sessions.
+func (g *GDBMStore) DeleteExpired(ctx context.Context) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := g.expiredIDsLocked()
+	for _, id := range ids {
+		key := []byte(id)
+		gdbmDelete(g.sessions, key)
+		gdbmDelete(g.byUser, key)
+		gdbmDelete(g.byExpiry, key)
+	}
+	return len(ids), nil
+}
+
+// Close closes all three underlying GDBM databases.
+func (g *GDBMStore) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	C.gdbm_close(g.sessions)
+	C.gdbm_close(g.byUser)
+	C.gdbm_close(g.byExpiry)
+	return nil
+}
+
+// newGDBMStoreFactory adapts NewGDBMStore to the registry's StoreFactory
+// signature, using dsn as GDBMConfig.Path.
+func newGDBMStoreFactory(dsn string) (Store, error) {
+	return NewGDBMStore(GDBMConfig{Path: dsn})
+}
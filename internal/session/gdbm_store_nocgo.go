@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package session
+
+import "fmt"
+
+// newGDBMStoreFactory stands in for gdbm_store.go's cgo-backed factory
+// when the binary is built with CGO_ENABLED=0, so the "gdbm" name still
+// resolves in the registry instead of failing to compile.
+func newGDBMStoreFactory(dsn string) (Store, error) {
+	return nil, fmt.Errorf("session: gdbm backend requires building with cgo enabled (CGO_ENABLED=1) and libgdbm-dev")
+}
@@ -0,0 +1,163 @@
+// Package grpcserver implements session.pb's SessionServiceServer on top of
+// a *session.DefaultManager, so the gRPC and REST (cmd/session-manager)
+// surfaces share one manager instance instead of diverging.
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/session/pb"
+)
+
+// Server adapts session.DefaultManager to pb.SessionServiceServer.
+type Server struct {
+	manager *session.DefaultManager
+}
+
+// New creates a Server backed by manager.
+func New(manager *session.DefaultManager) *Server {
+	return &Server{manager: manager}
+}
+
+func (s *Server) CreateSession(ctx context.Context, req *pb.CreateSessionRequest) (*pb.Session, error) {
+	sess, err := s.manager.Create(ctx, session.CreateSessionRequest{
+		UserID:   req.UserID,
+		Image:    req.Image,
+		CPUCount: int(req.CPUCount),
+		MemoryMB: req.MemoryMB,
+		TTL:      time.Duration(req.TTLSeconds) * time.Second,
+		Metadata: req.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProto(sess), nil
+}
+
+func (s *Server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	sessions, err := s.manager.GetByUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*pb.Session, len(sessions))
+	for i, sess := range sessions {
+		out[i] = toProto(sess)
+	}
+	return &pb.ListSessionsResponse{Sessions: out}, nil
+}
+
+func (s *Server) GetSession(ctx context.Context, req *pb.GetSessionRequest) (*pb.Session, error) {
+	sess, err := s.manager.Get(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(sess), nil
+}
+
+func (s *Server) DeleteSession(ctx context.Context, req *pb.DeleteSessionRequest) (*pb.DeleteSessionResponse, error) {
+	if err := s.manager.Delete(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteSessionResponse{Success: true}, nil
+}
+
+func (s *Server) PauseSession(ctx context.Context, req *pb.PauseSessionRequest) (*pb.Session, error) {
+	if err := s.manager.Pause(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	sess, err := s.manager.Get(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(sess), nil
+}
+
+func (s *Server) ResumeSession(ctx context.Context, req *pb.ResumeSessionRequest) (*pb.Session, error) {
+	sess, err := s.manager.Resume(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toProto(sess), nil
+}
+
+func (s *Server) TouchSession(ctx context.Context, req *pb.TouchSessionRequest) (*pb.TouchSessionResponse, error) {
+	sess, err := s.manager.Touch(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TouchSessionResponse{Success: true, BearerToken: sess.BearerToken}, nil
+}
+
+func (s *Server) BindSandbox(ctx context.Context, req *pb.BindSandboxRequest) (*pb.BindSandboxResponse, error) {
+	if err := s.manager.BindSandbox(ctx, req.ID, req.SandboxID); err != nil {
+		return nil, err
+	}
+	return &pb.BindSandboxResponse{Success: true}, nil
+}
+
+func (s *Server) UnbindSandbox(ctx context.Context, req *pb.UnbindSandboxRequest) (*pb.UnbindSandboxResponse, error) {
+	if err := s.manager.UnbindSandbox(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	return &pb.UnbindSandboxResponse{Success: true}, nil
+}
+
+func (s *Server) RestoreWorkspace(ctx context.Context, req *pb.RestoreWorkspaceRequest) (*pb.RestoreWorkspaceResponse, error) {
+	if err := s.manager.RestoreWorkspace(ctx, req.ID, req.SandboxID); err != nil {
+		return nil, err
+	}
+	return &pb.RestoreWorkspaceResponse{Success: true}, nil
+}
+
+// Watch streams req.ID's session record on every change until it's deleted
+// or the client disconnects.
+func (s *Server) Watch(req *pb.WatchRequest, stream pb.SessionService_WatchServer) error {
+	// Send the current state first so a caller that connects between
+	// mutations isn't left waiting on one that may never come.
+	if sess, err := s.manager.Get(stream.Context(), req.ID); err == nil {
+		if err := stream.Send(toProto(sess)); err != nil {
+			return err
+		}
+	}
+
+	updates, cancel := s.manager.Watch(req.ID)
+	defer cancel()
+
+	for {
+		select {
+		case sess, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toProto(sess)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func toProto(sess *session.Session) *pb.Session {
+	out := &pb.Session{
+		ID:           sess.ID,
+		UserID:       sess.UserID,
+		SandboxID:    sess.SandboxID,
+		Status:       string(sess.Status),
+		WorkspaceURL: sess.WorkspaceURL,
+		Image:        sess.Image,
+		CPUCount:     int32(sess.CPUCount),
+		MemoryMB:     sess.MemoryMB,
+		CreatedAt:    sess.CreatedAt,
+		UpdatedAt:    sess.UpdatedAt,
+		LastActiveAt: sess.LastActiveAt,
+		ExpiresAt:    sess.ExpiresAt,
+		PausedAt:     sess.PausedAt,
+		Metadata:     sess.Metadata,
+		BearerToken:  sess.BearerToken,
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures KafkaPublisher.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+}
+
+// DefaultKafkaConfig returns default Kafka configuration. Topic defaults
+// to "session-events"; Brokers is left empty and callers must set it.
+func DefaultKafkaConfig() KafkaConfig {
+	return KafkaConfig{
+		Topic: "session-events",
+	}
+}
+
+// KafkaPublisher durably streams events to a Kafka topic, keyed by session
+// ID so a consumer's partition assignment keeps one session's events in
+// order.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a KafkaPublisher writing to config.Topic on
+// config.Brokers.
+func NewKafkaPublisher(config KafkaConfig) (*KafkaPublisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one Kafka broker is required")
+	}
+	if config.Topic == "" {
+		return nil, fmt.Errorf("Kafka topic is required")
+	}
+
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			Topic:        config.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		},
+	}, nil
+}
+
+// Publish writes event to the configured topic, keyed by SessionID.
+func (k *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.SessionID),
+		Value: value,
+	})
+}
+
+// Close flushes and closes the underlying Kafka writer.
+func (k *KafkaPublisher) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,145 @@
+package session
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// LayeredStore is a SessionStore with an LRUCache sitting in front of a
+// remote SessionStore (normally a RedisCache). Reads are served locally
+// when possible; writes go to the remote first (so other replicas see
+// them) and are mirrored locally. If the remote also implements
+// SessionWatcher, LayeredStore subscribes to it and evicts its local
+// entries on remote writes/deletes from other replicas, keeping every
+// replica's local tier coherent instead of relying on local TTLs alone.
+type LayeredStore struct {
+	local  *LRUCache
+	remote SessionStore
+}
+
+// NewLayeredStore creates a LayeredStore and, if remote implements
+// SessionWatcher, starts the background goroutine that evicts local
+// entries on remote invalidation events. The goroutine exits when ctx is
+// cancelled.
+func NewLayeredStore(ctx context.Context, local *LRUCache, remote SessionStore) *LayeredStore {
+	ls := &LayeredStore{local: local, remote: remote}
+
+	if watcher, ok := remote.(SessionWatcher); ok {
+		go ls.watchInvalidations(ctx, watcher)
+	}
+
+	return ls
+}
+
+func (ls *LayeredStore) watchInvalidations(ctx context.Context, watcher SessionWatcher) {
+	events, err := watcher.Watch(ctx)
+	if err != nil {
+		log.Printf("[LayeredStore] Failed to subscribe to invalidations: %v", err)
+		return
+	}
+
+	for event := range events {
+		ls.local.evictIfStale(event.SessionID, event.Version)
+	}
+}
+
+// Get retrieves a session, checking the local tier before falling back to
+// the remote store.
+func (ls *LayeredStore) Get(ctx context.Context, id string) (*Session, error) {
+	if session, err := ls.local.Get(ctx, id); err == nil && session != nil {
+		return session, nil
+	}
+
+	session, err := ls.remote.Get(ctx, id)
+	if err != nil || session == nil {
+		return session, err
+	}
+
+	ls.local.setVersioned(id, session, nextVersion(), 0)
+	return session, nil
+}
+
+// Set writes a session to the remote store, then mirrors it locally.
+func (ls *LayeredStore) Set(ctx context.Context, session *Session, ttl time.Duration) error {
+	if err := ls.remote.Set(ctx, session, ttl); err != nil {
+		return err
+	}
+	ls.local.setVersioned(session.ID, session, nextVersion(), ttl)
+	return nil
+}
+
+// Delete removes a session from both tiers.
+func (ls *LayeredStore) Delete(ctx context.Context, id string) error {
+	if err := ls.remote.Delete(ctx, id); err != nil {
+		return err
+	}
+	return ls.local.Delete(ctx, id)
+}
+
+// Touch updates the TTL of a cached session in both tiers.
+func (ls *LayeredStore) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	if err := ls.remote.Touch(ctx, id, ttl); err != nil {
+		return err
+	}
+	return ls.local.Touch(ctx, id, ttl)
+}
+
+// MGet retrieves multiple sessions, serving whatever it can from the
+// local tier and pipelining a single remote round-trip for the rest.
+func (ls *LayeredStore) MGet(ctx context.Context, ids []string) (map[string]*Session, error) {
+	result := make(map[string]*Session, len(ids))
+	var misses []string
+
+	for _, id := range ids {
+		if session, err := ls.local.Get(ctx, id); err == nil && session != nil {
+			result[id] = session
+		} else {
+			misses = append(misses, id)
+		}
+	}
+
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	remoteResult, err := ls.remote.MGet(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for id, session := range remoteResult {
+		ls.local.setVersioned(id, session, nextVersion(), 0)
+		result[id] = session
+	}
+
+	return result, nil
+}
+
+// MSet writes multiple sessions to the remote store in one round-trip,
+// then mirrors each into the local tier.
+func (ls *LayeredStore) MSet(ctx context.Context, sessions []*Session, ttl time.Duration) error {
+	if err := ls.remote.MSet(ctx, sessions, ttl); err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		ls.local.setVersioned(session.ID, session, nextVersion(), ttl)
+	}
+	return nil
+}
+
+// MDelete removes multiple sessions from both tiers.
+func (ls *LayeredStore) MDelete(ctx context.Context, ids []string) error {
+	if err := ls.remote.MDelete(ctx, ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		ls.local.Delete(ctx, id)
+	}
+	return nil
+}
+
+// Scan delegates to the remote store, since the local tier only ever
+// holds a subset of keys.
+func (ls *LayeredStore) Scan(ctx context.Context) (<-chan string, error) {
+	return ls.remote.Scan(ctx)
+}
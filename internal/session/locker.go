@@ -0,0 +1,253 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	lockKeyPrefix = "lock:session:"
+
+	// lockRefreshFraction controls how often the background refresh
+	// goroutine renews a held lock's TTL, expressed as a fraction of the
+	// TTL itself (every TTL/3), so a single missed refresh still leaves
+	// two more chances before the lease actually expires.
+	lockRefreshFraction = 3
+
+	// maxRefreshFailures is how many consecutive refresh failures Lock
+	// tolerates before giving up on the lease: releasing it locally (so
+	// other nodes waiting on the same key aren't blocked on a lease this
+	// process can no longer prove it holds) and cancelling the lock's
+	// context.
+	maxRefreshFailures = 3
+)
+
+// Locker provides distributed mutual exclusion around session mutations, so
+// two concurrent calls for the same session ID (e.g. a Pause racing a
+// Resume) can't interleave their read-modify-write cycles.
+type Locker interface {
+	// Lock blocks until it acquires the named lock or ctx is done. On
+	// success it returns a context derived from ctx that is cancelled the
+	// moment the lock is lost -- whether because release was called, or
+	// because the background lease refresh gave up -- and a release func
+	// the caller must call exactly once when done. Callers should pass
+	// the returned context into whatever mutation they perform while
+	// holding the lock, so they stop rather than complete work they no
+	// longer have exclusive access to.
+	Lock(ctx context.Context, key string, ttl time.Duration) (lockCtx context.Context, release func(), err error)
+
+	// DeleteExpiredLocks sweeps stale lock entries left behind by a node
+	// that died mid-operation and never released its lease, returning how
+	// many were removed.
+	DeleteExpiredLocks(ctx context.Context) (int, error)
+}
+
+// acquireScript sets the lock key to token with a TTL, but only if the key
+// doesn't already exist.
+//
+// KEYS[1] = lock key
+// ARGV[1] = token
+// ARGV[2] = ttl in milliseconds
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// refreshScript extends the lock's TTL, but only if it's still held by
+// token -- otherwise a refresh racing a stolen or already-released lock
+// could resurrect someone else's lease.
+//
+// KEYS[1] = lock key
+// ARGV[1] = token
+// ARGV[2] = ttl in milliseconds
+var refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the lock, but only if it's still held by token.
+//
+// KEYS[1] = lock key
+// ARGV[1] = token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+return 0
+`)
+
+// RedisLocker implements Locker on top of Redis SET NX PX, matching the
+// compare-and-swap-by-token pattern RedisLimiter uses for its token
+// bucket: every lease is tagged with a random owner token so a refresh or
+// release can never act on a lease it doesn't actually hold.
+//
+// Redis's own PX expiry already reclaims a lease a dead node never
+// released, so DeleteExpiredLocks is a defensive sweep rather than the
+// sole mechanism -- it exists for the rare case a lock key outlives its
+// TTL (e.g. an operator restoring from an RDB snapshot taken mid-write),
+// not as the normal cleanup path.
+type RedisLocker struct {
+	client     redis.UniversalClient
+	retryDelay time.Duration
+}
+
+// NewRedisLocker creates a RedisLocker on top of the same Redis
+// configuration session.RedisCache uses.
+func NewRedisLocker(redisConfig RedisConfig) (*RedisLocker, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisConfig.Addr,
+		Password: redisConfig.Password,
+		DB:       redisConfig.DB,
+		PoolSize: redisConfig.PoolSize,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisLocker{client: client, retryDelay: 50 * time.Millisecond}, nil
+}
+
+func lockKey(key string) string {
+	return lockKeyPrefix + key
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Lock implements Locker.
+func (l *RedisLocker) Lock(ctx context.Context, key string, ttl time.Duration) (context.Context, func(), error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+
+	redisKey := lockKey(key)
+	ttlMs := ttl.Milliseconds()
+
+	for {
+		acquired, err := acquireScript.Run(ctx, l.client, []string{redisKey}, token, ttlMs).Int()
+		if err != nil {
+			return nil, nil, fmt.Errorf("session: lock acquire failed: %w", err)
+		}
+		if acquired == 1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(l.retryDelay):
+		}
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	stopRefresh := make(chan struct{})
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(stopRefresh)
+			cancel()
+			// Best effort: if the lease was already lost (stolen after
+			// repeated refresh failures), this is a harmless no-op.
+			releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer releaseCancel()
+			if err := releaseScript.Run(releaseCtx, l.client, []string{redisKey}, token).Err(); err != nil {
+				log.Printf("[RedisLocker] Failed to release lock %s: %v", key, err)
+			}
+		})
+	}
+
+	go l.refreshLoop(key, redisKey, token, ttl, cancel, stopRefresh)
+
+	return lockCtx, release, nil
+}
+
+// refreshLoop periodically extends the lease until stopCh closes or it
+// fails to do so maxRefreshFailures times in a row, at which point it
+// deletes the lease (if token still holds it) and cancels cancel so the
+// in-flight mutation holding this lock stops rather than finish without
+// exclusive access.
+func (l *RedisLocker) refreshLoop(key, redisKey, token string, ttl time.Duration, cancel context.CancelFunc, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(ttl / lockRefreshFraction)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancelRefresh := context.WithTimeout(context.Background(), ttl/lockRefreshFraction)
+			refreshed, err := refreshScript.Run(ctx, l.client, []string{redisKey}, token, ttl.Milliseconds()).Int()
+			cancelRefresh()
+
+			if err != nil || refreshed != 1 {
+				failures++
+				log.Printf("[RedisLocker] Failed to refresh lock %s (%d/%d): %v", key, failures, maxRefreshFailures, err)
+				if failures >= maxRefreshFailures {
+					log.Printf("[RedisLocker] Giving up on lock %s after %d failed refreshes; cancelling holder", key, failures)
+					releaseCtx, releaseCancel := context.WithTimeout(context.Background(), ttl/lockRefreshFraction)
+					if err := releaseScript.Run(releaseCtx, l.client, []string{redisKey}, token).Err(); err != nil {
+						log.Printf("[RedisLocker] Failed to release lock %s: %v", key, err)
+					}
+					releaseCancel()
+					cancel()
+					return
+				}
+				continue
+			}
+			failures = 0
+		}
+	}
+}
+
+// DeleteExpiredLocks implements Locker.
+func (l *RedisLocker) DeleteExpiredLocks(ctx context.Context) (int, error) {
+	removed := 0
+	iter := l.client.Scan(ctx, 0, lockKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		redisKey := iter.Val()
+
+		// PTTL returns -2 if the key no longer exists (nothing to do) and
+		// -1 if it exists but carries no expiry at all -- exactly the
+		// stale state described on RedisLocker: a lease that should have
+		// self-expired but didn't.
+		ttl, err := l.client.PTTL(ctx, redisKey).Result()
+		if err != nil || ttl != -1 {
+			continue
+		}
+		if l.client.Del(ctx, redisKey).Err() == nil {
+			removed++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return removed, fmt.Errorf("session: lock sweep scan failed: %w", err)
+	}
+	return removed, nil
+}
+
+// Close closes the underlying Redis client.
+func (l *RedisLocker) Close() error {
+	return l.client.Close()
+}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRUCache is an in-memory Cache bounded by a maximum entry count, evicting
+// the least-recently-used session once it's full. Used standalone it's a
+// single-process cache; fronting a RedisCache as a LayeredStore, it's the
+// local tier that gets invalidated over Redis pub/sub instead of just
+// expiring on its own TTL.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+type lruNode struct {
+	id        string
+	session   *Session
+	version   int64
+	expiresAt time.Time
+}
+
+// NewLRUCache creates an in-memory Cache holding up to capacity sessions,
+// with defaultTTL applied whenever Set/Touch are called with ttl == 0.
+func NewLRUCache(capacity int, defaultTTL time.Duration) *LRUCache {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ttl:      defaultTTL,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get retrieves a session from cache
+func (l *LRUCache) Get(_ context.Context, id string) (*Session, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[id]
+	if !ok {
+		return nil, nil
+	}
+	node := elem.Value.(*lruNode)
+	if time.Now().After(node.expiresAt) {
+		l.removeLocked(elem)
+		return nil, nil
+	}
+
+	l.order.MoveToFront(elem)
+	return node.session, nil
+}
+
+// Set stores a session in cache
+func (l *LRUCache) Set(_ context.Context, session *Session, ttl time.Duration) error {
+	l.setVersioned(session.ID, session, nextVersion(), ttl)
+	return nil
+}
+
+// setVersioned is Set with an explicit version, used by LayeredStore so a
+// local entry's version reflects when its data was last confirmed fresh
+// (either by a local write or a refill after a remote read), not just
+// when the in-process cache last touched it.
+func (l *LRUCache) setVersioned(id string, session *Session, version int64, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = l.ttl
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	node := &lruNode{id: id, session: session, version: version, expiresAt: time.Now().Add(ttl)}
+	if elem, ok := l.entries[id]; ok {
+		elem.Value = node
+		l.order.MoveToFront(elem)
+	} else {
+		l.entries[id] = l.order.PushFront(node)
+		l.evictIfNeededLocked()
+	}
+}
+
+// Delete removes a session from cache
+func (l *LRUCache) Delete(_ context.Context, id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[id]; ok {
+		l.removeLocked(elem)
+	}
+	return nil
+}
+
+// Touch updates the TTL of a cached session
+func (l *LRUCache) Touch(_ context.Context, id string, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = l.ttl
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[id]
+	if !ok {
+		return nil
+	}
+	node := elem.Value.(*lruNode)
+	node.expiresAt = time.Now().Add(ttl)
+	l.order.MoveToFront(elem)
+	return nil
+}
+
+// evictIfStale removes id's entry only if version is at least as new as
+// the entry's own version, so a delayed invalidation can't undo a fresher
+// local write or refill that happened after the event was published.
+func (l *LRUCache) evictIfStale(id string, version int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[id]
+	if !ok {
+		return
+	}
+	if version >= elem.Value.(*lruNode).version {
+		l.removeLocked(elem)
+	}
+}
+
+// removeLocked must be called with l.mu held.
+func (l *LRUCache) removeLocked(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.entries, elem.Value.(*lruNode).id)
+}
+
+// evictIfNeededLocked must be called with l.mu held.
+func (l *LRUCache) evictIfNeededLocked() {
+	for len(l.entries) > l.capacity {
+		oldest := l.order.Back()
+		if oldest == nil {
+			return
+		}
+		l.removeLocked(oldest)
+	}
+}
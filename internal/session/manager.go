@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -10,16 +11,47 @@ import (
 	"github.com/google/uuid"
 )
 
+// sessionLockTTL is the lease duration DefaultManager asks its Locker for
+// around a single mutating call. It only needs to outlast one
+// read-modify-write cycle; the Locker's own lease refresh keeps it alive
+// for calls that take longer than that.
+const sessionLockTTL = 10 * time.Second
+
+// maxUpdateConflictRetries bounds how many times updateWithRetry re-fetches
+// and reapplies a mutation after losing an optimistic-concurrency race
+// before giving up and returning the conflict to the caller.
+const maxUpdateConflictRetries = 3
+
 // DefaultManager implements the Manager interface
 type DefaultManager struct {
 	store            Store
 	cache            Cache
 	workspaceStorage WorkspaceStorage
 
+	// locker, when attached via AttachLocker, serializes Pause, Resume,
+	// BindSandbox, and UnbindSandbox per session ID so two concurrent
+	// calls can't interleave their read-modify-write cycles.
+	locker Locker
+
+	// replicator, when attached via AttachReplicator, copies each
+	// Pause's workspace snapshot to secondary endpoints and lets
+	// RestoreWorkspace fall back to them if the primary read fails.
+	replicator *Replicator
+
+	// events is the in-process lifecycle event sink. It always exists
+	// (even with no external sinks attached) since Watch is built on it.
+	events *FanoutPublisher
+
+	// externalPublisher, when attached via AttachEventPublisher, also
+	// receives every lifecycle event emit() publishes - typically a
+	// MultiPublisher combining a WebhookPublisher and/or KafkaPublisher.
+	externalPublisher EventPublisher
+
 	// Configuration
 	defaultTTL      time.Duration
 	maxTTL          time.Duration
 	cleanupInterval time.Duration
+	ttlPolicy       TTLPolicy
 
 	// Background cleanup
 	stopCh chan struct{}
@@ -31,6 +63,11 @@ type ManagerConfig struct {
 	DefaultTTL      time.Duration
 	MaxTTL          time.Duration
 	CleanupInterval time.Duration
+
+	// TTLPolicy governs how Create/Touch/Resume set a session's
+	// ExpiresAt. Nil (the default) falls back to a SlidingWindowTTLPolicy
+	// built from DefaultTTL, MaxTTL, and defaultWarnWindow.
+	TTLPolicy TTLPolicy
 }
 
 // DefaultManagerConfig returns default manager configuration
@@ -44,13 +81,20 @@ func DefaultManagerConfig() ManagerConfig {
 
 // NewManager creates a new session manager
 func NewManager(store Store, cache Cache, workspaceStorage WorkspaceStorage, config ManagerConfig) *DefaultManager {
+	ttlPolicy := config.TTLPolicy
+	if ttlPolicy == nil {
+		ttlPolicy = NewSlidingWindowTTLPolicy(config.DefaultTTL, config.MaxTTL, defaultWarnWindow)
+	}
+
 	m := &DefaultManager{
 		store:            store,
 		cache:            cache,
 		workspaceStorage: workspaceStorage,
+		events:           NewFanoutPublisher(0),
 		defaultTTL:       config.DefaultTTL,
 		maxTTL:           config.MaxTTL,
 		cleanupInterval:  config.CleanupInterval,
+		ttlPolicy:        ttlPolicy,
 		stopCh:           make(chan struct{}),
 	}
 
@@ -61,19 +105,143 @@ func NewManager(store Store, cache Cache, workspaceStorage WorkspaceStorage, con
 	return m
 }
 
-// Create creates a new session
-func (m *DefaultManager) Create(ctx context.Context, req CreateSessionRequest) (*Session, error) {
-	now := time.Now()
+// AttachLocker wires a distributed lock into Pause, Resume, BindSandbox,
+// and UnbindSandbox. A nil locker (the default) leaves those calls
+// unsynchronized, matching the manager's behavior before this was
+// introduced.
+func (m *DefaultManager) AttachLocker(locker Locker) {
+	m.locker = locker
+}
+
+// AttachReplicator wires cross-region workspace replication into Pause and
+// RestoreWorkspace. A nil replicator (the default) leaves Pause saving
+// only to the primary, matching the manager's behavior before this was
+// introduced.
+func (m *DefaultManager) AttachReplicator(replicator *Replicator) {
+	m.replicator = replicator
+}
+
+// AttachEventPublisher wires an external lifecycle event sink (a
+// WebhookPublisher, a KafkaPublisher, or a MultiPublisher combining both)
+// in addition to the always-on in-process fan-out Watch relies on. A nil
+// publisher (the default) means lifecycle events never leave the process.
+func (m *DefaultManager) AttachEventPublisher(publisher EventPublisher) {
+	m.externalPublisher = publisher
+}
+
+// emit bumps session.EventSeq, persists it, and publishes the resulting
+// Event to the in-process fan-out and any attached external sink. Errors
+// are logged rather than returned: a lifecycle notification failing is
+// never a reason to fail the mutation that triggered it.
+func (m *DefaultManager) emit(ctx context.Context, eventType EventType, sess *Session, oldStatus Status) {
+	sess.EventSeq++
+	if err := m.store.Update(ctx, sess); err != nil {
+		log.Printf("[Session] Failed to persist event sequence for session %s: %v", sess.ID, err)
+	}
+	m.publishEvent(ctx, eventType, sess, oldStatus)
+}
+
+// emitTerminal is emit for Deleted and Expired: the session row is already
+// gone by the time these fire, so there's nothing left to persist the
+// bumped sequence number to.
+func (m *DefaultManager) emitTerminal(ctx context.Context, eventType EventType, sess *Session, oldStatus Status) {
+	sess.EventSeq++
+	m.publishEvent(ctx, eventType, sess, oldStatus)
+}
+
+func (m *DefaultManager) publishEvent(ctx context.Context, eventType EventType, sess *Session, oldStatus Status) {
+	event := Event{
+		Type:       eventType,
+		SessionID:  sess.ID,
+		UserID:     sess.UserID,
+		OldStatus:  oldStatus,
+		NewStatus:  sess.Status,
+		SandboxID:  sess.SandboxID,
+		Seq:        sess.EventSeq,
+		OccurredAt: time.Now(),
+	}
+
+	m.events.Publish(ctx, event)
+	if m.externalPublisher != nil {
+		if err := m.externalPublisher.Publish(ctx, event); err != nil {
+			log.Printf("[Session] Failed to publish %s event for session %s: %v", eventType, sess.ID, err)
+		}
+	}
+}
+
+// EventsSince returns sessionID's retained lifecycle events with Seq >
+// since, for the GET /api/v1/sessions/{id}/events?since=N replay endpoint.
+func (m *DefaultManager) EventsSince(sessionID string, since int64) []Event {
+	return m.events.Since(sessionID, since)
+}
+
+// withSessionLock runs fn while holding id's distributed lock, if a Locker
+// is attached; otherwise it just calls fn. fn receives the lock-scoped
+// context, which is cancelled if the lock is lost mid-call so fn can stop
+// rather than finish a mutation it no longer has exclusive access to.
+func (m *DefaultManager) withSessionLock(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	if m.locker == nil {
+		return fn(ctx)
+	}
 
-	// Determine TTL
-	ttl := req.TTL
-	if ttl == 0 {
-		ttl = m.defaultTTL
+	lockCtx, release, err := m.locker.Lock(ctx, id, sessionLockTTL)
+	if err != nil {
+		return fmt.Errorf("failed to acquire session lock: %w", err)
 	}
-	if ttl > m.maxTTL {
-		ttl = m.maxTTL
+	defer release()
+
+	return fn(lockCtx)
+}
+
+// updateWithRetry re-fetches id straight from the store (bypassing the
+// cache, which may be serving a copy another replica has since replaced),
+// applies tryUpdate to it, and saves via store.Update. A Session.Version
+// mismatch - another writer updated id between the fetch and the save -
+// makes store.Update return ErrConflict; updateWithRetry invalidates the
+// cache (so a subsequent read can't be served the now-stale copy it just
+// overwrote) and retries from a fresh fetch, up to maxUpdateConflictRetries
+// times. This mirrors the pattern etcd-backed stores use for
+// GuaranteedUpdate.
+func (m *DefaultManager) updateWithRetry(ctx context.Context, id string, tryUpdate func(*Session) error) (*Session, error) {
+	var lastErr error
+	for attempt := 0; attempt <= maxUpdateConflictRetries; attempt++ {
+		session, err := m.store.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tryUpdate(session); err != nil {
+			return nil, err
+		}
+
+		session.UpdatedAt = time.Now()
+		if err := m.store.Update(ctx, session); err != nil {
+			if errors.Is(err, ErrConflict) {
+				if m.cache != nil {
+					m.cache.Delete(ctx, id)
+				}
+				lastErr = err
+				continue
+			}
+			return nil, err
+		}
+
+		if m.cache != nil {
+			ttl := time.Until(session.ExpiresAt)
+			if ttl > 0 {
+				m.cache.Set(ctx, session, ttl)
+			}
+		}
+		return session, nil
 	}
 
+	return nil, fmt.Errorf("failed to update session %s after %d attempts: %w", id, maxUpdateConflictRetries+1, lastErr)
+}
+
+// Create creates a new session
+func (m *DefaultManager) Create(ctx context.Context, req CreateSessionRequest) (*Session, error) {
+	now := time.Now()
+
 	// Set defaults
 	if req.Image == "" {
 		req.Image = "python:3.11-slim"
@@ -85,32 +253,43 @@ func (m *DefaultManager) Create(ctx context.Context, req CreateSessionRequest) (
 		req.MemoryMB = 2048
 	}
 
-	session := &Session{
-		ID:           uuid.New().String(),
-		UserID:       req.UserID,
-		Status:       StatusActive,
-		Image:        req.Image,
-		CPUCount:     req.CPUCount,
-		MemoryMB:     req.MemoryMB,
-		CreatedAt:    now,
-		UpdatedAt:    now,
-		LastActiveAt: now,
-		ExpiresAt:    now.Add(ttl),
-		Metadata:     req.Metadata,
+	token, tokenHash, err := generateBearerToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate bearer token: %w", err)
 	}
 
+	session := &Session{
+		ID:              uuid.New().String(),
+		UserID:          req.UserID,
+		Status:          StatusActive,
+		Image:           req.Image,
+		CPUCount:        req.CPUCount,
+		MemoryMB:        req.MemoryMB,
+		BearerTokenHash: tokenHash,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		LastActiveAt:    now,
+		Metadata:        req.Metadata,
+	}
+	session.ExpiresAt = m.ttlPolicy.Expiry(ctx, now, session, TTLEventCreate, req.TTL)
+
 	// Store in database
 	if err := m.store.Create(ctx, session); err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
+	session.BearerToken = token
 
 	// Cache the session
 	if m.cache != nil {
-		if err := m.cache.Set(ctx, session, ttl); err != nil {
-			log.Printf("[Session] Failed to cache session %s: %v", session.ID, err)
+		if ttl := time.Until(session.ExpiresAt); ttl > 0 {
+			if err := m.cache.Set(ctx, session, ttl); err != nil {
+				log.Printf("[Session] Failed to cache session %s: %v", session.ID, err)
+			}
 		}
 	}
 
+	m.emit(ctx, EventCreated, session, "")
+
 	log.Printf("[Session] Created session %s for user %s", session.ID, session.UserID)
 	return session, nil
 }
@@ -146,6 +325,50 @@ func (m *DefaultManager) Get(ctx context.Context, id string) (*Session, error) {
 	return session, nil
 }
 
+// ValidateSession validates a bearer token and returns the session it
+// grants access to. This is the sole entry point gateways should use to
+// authenticate a request, since (unlike Get) a leaked session ID is not
+// sufficient on its own to pass this check.
+func (m *DefaultManager) ValidateSession(ctx context.Context, bearerToken string) (*Session, error) {
+	hash := hashBearerToken(bearerToken)
+
+	session, err := m.store.GetByBearerTokenHash(ctx, hash)
+	if err == nil && session != nil {
+		if session.IsExpired() {
+			return nil, ErrSessionExpired
+		}
+		return session, nil
+	}
+
+	// Implicit migration: rows created before bearer tokens existed have no
+	// hash to match against. Such a session's ID was previously usable as
+	// its own credential, so accept it once here and mint a real token.
+	session, err = m.store.Get(ctx, bearerToken)
+	if err != nil || session == nil {
+		return nil, ErrInvalidBearerToken
+	}
+	if session.BearerTokenHash != "" {
+		// Already migrated; the session ID is no longer a valid credential.
+		return nil, ErrInvalidBearerToken
+	}
+	if session.IsExpired() {
+		return nil, ErrSessionExpired
+	}
+
+	token, tokenHash, err := generateBearerToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate bearer token: %w", err)
+	}
+	session.BearerTokenHash = tokenHash
+	if err := m.store.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to migrate session bearer token: %w", err)
+	}
+	session.BearerToken = token
+
+	log.Printf("[Session] Migrated session %s to bearer-token auth", session.ID)
+	return session, nil
+}
+
 // GetByUser retrieves all sessions for a user
 func (m *DefaultManager) GetByUser(ctx context.Context, userID string) ([]*Session, error) {
 	sessions, err := m.store.GetByUser(ctx, userID)
@@ -165,10 +388,19 @@ func (m *DefaultManager) GetByUser(ctx context.Context, userID string) ([]*Sessi
 }
 
 // Update updates a session
+// Update saves the caller's copy of session as-is: unlike Touch, Pause,
+// Resume, BindSandbox and UnbindSandbox, there's no tryUpdate mutator to
+// reapply to a freshly-fetched copy, so a lost optimistic-concurrency race
+// (ErrConflict) is reported to the caller rather than retried; the cache
+// is still invalidated so a subsequent read doesn't see the stale copy
+// this call just lost the race against.
 func (m *DefaultManager) Update(ctx context.Context, session *Session) error {
 	session.UpdatedAt = time.Now()
 
 	if err := m.store.Update(ctx, session); err != nil {
+		if errors.Is(err, ErrConflict) && m.cache != nil {
+			m.cache.Delete(ctx, session.ID)
+		}
 		return err
 	}
 
@@ -183,8 +415,53 @@ func (m *DefaultManager) Update(ctx context.Context, session *Session) error {
 	return nil
 }
 
+// Watch subscribes to id's lifecycle events: every emit() (Create, Pause,
+// Resume, Delete, Cleanup, BindSandbox, UnbindSandbox, RestoreWorkspace)
+// pushes the session's new state, and the channel is closed once Delete or
+// Cleanup removes the session. The returned cancel func must be called to
+// release the subscription. Used by the gRPC server's Watch RPC.
+func (m *DefaultManager) Watch(id string) (<-chan *Session, func()) {
+	events, unsubscribe := m.events.Subscribe(id)
+	out := make(chan *Session, 4)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-done:
+				return
+			case event := <-events:
+				if event.Type == EventDeleted || event.Type == EventExpired {
+					return
+				}
+				session, err := m.store.Get(context.Background(), id)
+				if err != nil {
+					return
+				}
+				select {
+				case out <- session:
+				default:
+					log.Printf("[Session] Watch subscriber for session %s is behind, dropping update", id)
+				}
+			}
+		}
+	}()
+
+	cancel := func() {
+		unsubscribe()
+		close(done)
+	}
+	return out, cancel
+}
+
 // Delete deletes a session
 func (m *DefaultManager) Delete(ctx context.Context, id string) error {
+	session, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	// Delete workspace if exists
 	if m.workspaceStorage != nil {
 		if exists, _ := m.workspaceStorage.Exists(ctx, id); exists {
@@ -204,74 +481,102 @@ func (m *DefaultManager) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
+	m.emitTerminal(ctx, EventDeleted, session, session.Status)
+
 	log.Printf("[Session] Deleted session %s", id)
 	return nil
 }
 
 // Pause pauses a session (saves workspace, releases sandbox)
 func (m *DefaultManager) Pause(ctx context.Context, id string) error {
-	session, err := m.Get(ctx, id)
-	if err != nil {
-		return err
-	}
-
-	if session.Status != StatusActive {
-		return fmt.Errorf("session is not active: %s", session.Status)
-	}
+	return m.withSessionLock(ctx, id, func(ctx context.Context) error {
+		var oldStatus Status
+		var savedWorkspace bool
 
-	if session.SandboxID == "" {
-		return fmt.Errorf("session has no sandbox bound")
-	}
+		session, err := m.updateWithRetry(ctx, id, func(session *Session) error {
+			if session.Status != StatusActive {
+				return fmt.Errorf("session is not active: %s", session.Status)
+			}
+			if session.SandboxID == "" {
+				return fmt.Errorf("session has no sandbox bound")
+			}
+			oldStatus = session.Status
+			savedWorkspace = false
+
+			// Save workspace
+			if m.workspaceStorage != nil {
+				workspaceURL, err := m.workspaceStorage.Save(ctx, id, session.SandboxID)
+				if err != nil {
+					return fmt.Errorf("failed to save workspace: %w", err)
+				}
+				session.WorkspaceURL = workspaceURL
+				savedWorkspace = true
+			}
 
-	// Save workspace
-	if m.workspaceStorage != nil {
-		workspaceURL, err := m.workspaceStorage.Save(ctx, id, session.SandboxID)
+			// Update session status
+			now := time.Now()
+			session.Status = StatusPaused
+			session.PausedAt = &now
+			session.SandboxID = "" // Sandbox will be released
+			return nil
+		})
 		if err != nil {
-			return fmt.Errorf("failed to save workspace: %w", err)
+			return err
 		}
-		session.WorkspaceURL = workspaceURL
-	}
 
-	// Update session status
-	now := time.Now()
-	session.Status = StatusPaused
-	session.PausedAt = &now
-	session.SandboxID = "" // Sandbox will be released
+		if savedWorkspace {
+			m.emit(ctx, EventWorkspaceSaved, session, oldStatus)
+		}
+		m.emit(ctx, EventPaused, session, oldStatus)
 
-	if err := m.Update(ctx, session); err != nil {
-		return err
-	}
+		// Enqueue replication only after the new WorkspaceURL is
+		// persisted, so the worker's own store.Get sees it.
+		if m.workspaceStorage != nil && m.replicator != nil {
+			m.replicator.Replicate(id)
+		}
 
-	log.Printf("[Session] Paused session %s", id)
-	return nil
+		log.Printf("[Session] Paused session %s", id)
+		return nil
+	})
 }
 
 // Resume resumes a paused session
 func (m *DefaultManager) Resume(ctx context.Context, id string) (*Session, error) {
-	session, err := m.Get(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-
-	if session.Status != StatusPaused {
-		return nil, fmt.Errorf("session is not paused: %s", session.Status)
-	}
+	var resumed *Session
+	err := m.withSessionLock(ctx, id, func(ctx context.Context) error {
+		var oldStatus Status
 
-	// Update session status (sandbox will be bound later)
-	now := time.Now()
-	session.Status = StatusActive
-	session.PausedAt = nil
-	session.LastActiveAt = now
+		session, err := m.updateWithRetry(ctx, id, func(session *Session) error {
+			if session.Status != StatusPaused {
+				return fmt.Errorf("session is not paused: %s", session.Status)
+			}
+			oldStatus = session.Status
+
+			// Update session status (sandbox will be bound later)
+			now := time.Now()
+			session.Status = StatusActive
+			session.PausedAt = nil
+			session.LastActiveAt = now
+
+			// Extend expiration
+			session.ExpiresAt = m.ttlPolicy.Expiry(ctx, now, session, TTLEventResume, 0)
+			session.ExpiryWarnedAt = nil
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 
-	// Extend expiration
-	session.ExpiresAt = now.Add(m.defaultTTL)
+		m.emit(ctx, EventResumed, session, oldStatus)
 
-	if err := m.Update(ctx, session); err != nil {
+		log.Printf("[Session] Resumed session %s", id)
+		resumed = session
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	log.Printf("[Session] Resumed session %s", id)
-	return session, nil
+	return resumed, nil
 }
 
 // RestoreWorkspace restores workspace to a sandbox after resume
@@ -291,37 +596,218 @@ func (m *DefaultManager) RestoreWorkspace(ctx context.Context, sessionID, sandbo
 	}
 
 	if err := m.workspaceStorage.Restore(ctx, sessionID, sandboxID); err != nil {
-		return fmt.Errorf("failed to restore workspace: %w", err)
+		if m.replicator == nil {
+			return fmt.Errorf("failed to restore workspace: %w", err)
+		}
+
+		log.Printf("[Session] Primary workspace restore failed for session %s, falling back to replicas: %v", sessionID, err)
+		if fallbackErr := m.restoreFromReplica(ctx, sessionID, sandboxID); fallbackErr != nil {
+			return fmt.Errorf("failed to restore workspace from primary or any replica: %w", err)
+		}
+		m.emitWorkspaceRestored(ctx, sessionID)
+		return nil
 	}
 
+	m.emitWorkspaceRestored(ctx, sessionID)
 	log.Printf("[Session] Restored workspace for session %s to sandbox %s", sessionID, sandboxID)
 	return nil
 }
 
-// Touch updates the last active time
-func (m *DefaultManager) Touch(ctx context.Context, id string) error {
-	session, err := m.Get(ctx, id)
+// emitWorkspaceRestored emits EventWorkspaceRestored for sessionID. The
+// restore methods above only have IDs, not the Session itself, so this
+// re-fetches it; a failure to do so just means the event is skipped
+// rather than the restore being reported as failed.
+func (m *DefaultManager) emitWorkspaceRestored(ctx context.Context, sessionID string) {
+	session, err := m.store.Get(ctx, sessionID)
+	if err != nil {
+		return
+	}
+	m.emit(ctx, EventWorkspaceRestored, session, session.Status)
+}
+
+// restoreFromReplica walks sessionID's WorkspaceReplicas in priority order
+// (the order Replicator persisted them in), restoring from the first one
+// whose status is ReplicaStatusOK.
+func (m *DefaultManager) restoreFromReplica(ctx context.Context, sessionID, sandboxID string) error {
+	session, err := m.store.Get(ctx, sessionID)
 	if err != nil {
 		return err
 	}
 
-	session.LastActiveAt = time.Now()
+	for _, replica := range session.WorkspaceReplicas {
+		if replica.Status != ReplicaStatusOK {
+			continue
+		}
+		if err := m.replicator.RestoreFrom(ctx, replica.Name, sessionID, sandboxID); err != nil {
+			log.Printf("[Session] Replica %s restore failed for session %s: %v", replica.Name, sessionID, err)
+			continue
+		}
+		log.Printf("[Session] Restored workspace for session %s from replica %s", sessionID, replica.Name)
+		return nil
+	}
 
-	if err := m.store.Update(ctx, session); err != nil {
+	return fmt.Errorf("no replica available")
+}
+
+// RetryReplication re-enqueues id's workspace snapshot for replication to
+// every configured secondary, regardless of current replica status. Used
+// by the /replication endpoint's manual retry.
+func (m *DefaultManager) RetryReplication(ctx context.Context, id string) error {
+	if m.replicator == nil {
+		return fmt.Errorf("no replication configured")
+	}
+	if _, err := m.Get(ctx, id); err != nil {
 		return err
 	}
+	m.replicator.Retry(id)
+	return nil
+}
 
-	// Touch cache
-	if m.cache != nil {
-		ttl := time.Until(session.ExpiresAt)
-		if ttl > 0 {
-			m.cache.Touch(ctx, id, ttl)
-		}
+// ReplicationStatus returns id's per-secondary replica status, for the
+// /replication endpoint's GET.
+func (m *DefaultManager) ReplicationStatus(ctx context.Context, id string) ([]ReplicaRef, error) {
+	if m.replicator == nil {
+		return nil, fmt.Errorf("no replication configured")
+	}
+	return m.replicator.Status(ctx, id)
+}
+
+// ListWorkspaceSnapshots returns id's workspace version history, for the
+// /snapshots endpoint's GET. Only supported when the primary workspace
+// storage is MinIOStorage, since it relies on MinIO object versioning.
+func (m *DefaultManager) ListWorkspaceSnapshots(ctx context.Context, id string) ([]Snapshot, error) {
+	minioStorage, ok := m.workspaceStorage.(*MinIOStorage)
+	if !ok {
+		return nil, fmt.Errorf("workspace versioning requires MinIO storage")
+	}
+	if _, err := m.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return minioStorage.ListVersions(ctx, id)
+}
+
+// RestoreWorkspaceSnapshot restores id's workspace at versionID into
+// sandboxID, for the /snapshots endpoint's POST: promoting a prior
+// snapshot into a running sandbox without a full Pause/Resume cycle.
+func (m *DefaultManager) RestoreWorkspaceSnapshot(ctx context.Context, id, versionID, sandboxID string) error {
+	minioStorage, ok := m.workspaceStorage.(*MinIOStorage)
+	if !ok {
+		return fmt.Errorf("workspace versioning requires MinIO storage")
+	}
+	if _, err := m.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := minioStorage.RestoreVersion(ctx, id, versionID, sandboxID); err != nil {
+		return err
 	}
+	m.emitWorkspaceRestored(ctx, id)
+	return nil
+}
 
+// TagWorkspace labels id's current workspace snapshot as name by writing a
+// pointer object that records its MinIO version ID. Nothing in this package
+// reads tag pointers back; RestoreWorkspaceSnapshot takes a version ID.
+func (m *DefaultManager) TagWorkspace(ctx context.Context, id, name string) error {
+	minioStorage, ok := m.workspaceStorage.(*MinIOStorage)
+	if !ok {
+		return fmt.Errorf("workspace versioning requires MinIO storage")
+	}
+	session, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := minioStorage.Tag(ctx, id, name); err != nil {
+		return err
+	}
+	m.emit(ctx, EventWorkspaceTagged, session, session.Status)
 	return nil
 }
 
+// ForkSession creates a new session whose workspace starts as a
+// server-side copy of srcSessionID's current snapshot, so an agent can
+// branch off to experiment and either discard the fork or later promote
+// one of its own snapshots, without touching the source session.
+func (m *DefaultManager) ForkSession(ctx context.Context, srcSessionID string, req CreateSessionRequest) (*Session, error) {
+	minioStorage, ok := m.workspaceStorage.(*MinIOStorage)
+	if !ok {
+		return nil, fmt.Errorf("workspace forking requires MinIO storage")
+	}
+
+	src, err := m.Get(ctx, srcSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserID == "" {
+		req.UserID = src.UserID
+	}
+	if req.Image == "" {
+		req.Image = src.Image
+	}
+	if req.CPUCount == 0 {
+		req.CPUCount = src.CPUCount
+	}
+	if req.MemoryMB == 0 {
+		req.MemoryMB = src.MemoryMB
+	}
+
+	dst, err := m.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	bearerToken := dst.BearerToken
+
+	exists, err := minioStorage.Exists(ctx, srcSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check source workspace: %w", err)
+	}
+	if exists {
+		if err := minioStorage.Fork(ctx, srcSessionID, dst.ID); err != nil {
+			return nil, fmt.Errorf("failed to fork workspace: %w", err)
+		}
+
+		dst, err = m.updateWithRetry(ctx, dst.ID, func(session *Session) error {
+			session.WorkspaceURL = workspaceKey(dst.ID)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to persist forked workspace: %w", err)
+		}
+		dst.BearerToken = bearerToken
+	}
+
+	m.emit(ctx, EventWorkspaceForked, dst, dst.Status)
+	log.Printf("[Session] Forked session %s from %s", dst.ID, srcSessionID)
+	return dst, nil
+}
+
+// Touch updates the last active time and extends ExpiresAt per the
+// configured TTLPolicy.
+func (m *DefaultManager) Touch(ctx context.Context, id string) (*Session, error) {
+	var token string
+	session, err := m.updateWithRetry(ctx, id, func(session *Session) error {
+		now := time.Now()
+		session.LastActiveAt = now
+		session.ExpiresAt = m.ttlPolicy.Expiry(ctx, now, session, TTLEventTouch, 0)
+		session.ExpiryWarnedAt = nil
+
+		// Rotate the bearer token on every touch so a captured token has a
+		// short useful lifetime even if the session itself stays alive.
+		newToken, tokenHash, err := generateBearerToken()
+		if err != nil {
+			return fmt.Errorf("failed to rotate bearer token: %w", err)
+		}
+		token = newToken
+		session.BearerTokenHash = tokenHash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	session.BearerToken = token
+	return session, nil
+}
+
 // Cleanup removes expired sessions
 func (m *DefaultManager) Cleanup(ctx context.Context) (int, error) {
 	// Get expired sessions for workspace cleanup
@@ -345,64 +831,140 @@ func (m *DefaultManager) Cleanup(ctx context.Context) (int, error) {
 		return 0, err
 	}
 
+	for _, s := range expired {
+		m.emitTerminal(ctx, EventExpired, s, s.Status)
+	}
+
 	if count > 0 {
 		log.Printf("[Session] Cleaned up %d expired sessions", count)
 	}
 
+	// Sweep any lock left behind by a node that died mid-operation and
+	// never released its lease.
+	if m.locker != nil {
+		if swept, err := m.locker.DeleteExpiredLocks(ctx); err != nil {
+			log.Printf("[Session] Failed to sweep expired locks: %v", err)
+		} else if swept > 0 {
+			log.Printf("[Session] Swept %d expired session locks", swept)
+		}
+	}
+
 	return count, nil
 }
 
 // BindSandbox binds a sandbox to a session
 func (m *DefaultManager) BindSandbox(ctx context.Context, sessionID, sandboxID string) error {
-	session, err := m.Get(ctx, sessionID)
-	if err != nil {
-		return err
-	}
-
-	session.SandboxID = sandboxID
-	session.LastActiveAt = time.Now()
+	return m.withSessionLock(ctx, sessionID, func(ctx context.Context) error {
+		var oldStatus Status
+
+		session, err := m.updateWithRetry(ctx, sessionID, func(session *Session) error {
+			oldStatus = session.Status
+			session.SandboxID = sandboxID
+			session.LastActiveAt = time.Now()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 
-	if err := m.Update(ctx, session); err != nil {
-		return err
-	}
+		m.emit(ctx, EventSandboxBound, session, oldStatus)
 
-	log.Printf("[Session] Bound sandbox %s to session %s", sandboxID, sessionID)
-	return nil
+		log.Printf("[Session] Bound sandbox %s to session %s", sandboxID, sessionID)
+		return nil
+	})
 }
 
 // UnbindSandbox unbinds a sandbox from a session
 func (m *DefaultManager) UnbindSandbox(ctx context.Context, sessionID string) error {
-	session, err := m.Get(ctx, sessionID)
-	if err != nil {
-		return err
-	}
-
-	session.SandboxID = ""
+	return m.withSessionLock(ctx, sessionID, func(ctx context.Context) error {
+		var oldStatus Status
+
+		session, err := m.updateWithRetry(ctx, sessionID, func(session *Session) error {
+			oldStatus = session.Status
+			session.SandboxID = ""
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 
-	if err := m.Update(ctx, session); err != nil {
-		return err
-	}
+		m.emit(ctx, EventSandboxUnbound, session, oldStatus)
 
-	log.Printf("[Session] Unbound sandbox from session %s", sessionID)
-	return nil
+		log.Printf("[Session] Unbound sandbox from session %s", sessionID)
+		return nil
+	})
 }
 
-// cleanupLoop periodically cleans up expired sessions
+// cleanupLoop periodically cleans up expired sessions and warns sessions
+// nearing expiry. Rather than a fixed-interval ticker, it reschedules
+// itself after every pass using nextCleanupDelay, so the configured
+// TTLPolicy's NextExpiryCheck hints control the cadence - waking up early
+// when something is about to expire, or sleeping through a quiet period
+// for a schedule-based policy - instead of the manager polling every
+// session on the same fixed clock regardless of policy.
 func (m *DefaultManager) cleanupLoop() {
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(m.cleanupInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(m.cleanupInterval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-m.stopCh:
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			ctx := context.Background()
 			m.Cleanup(ctx)
+			timer.Reset(m.warnAndScheduleNext(ctx))
+		}
+	}
+}
+
+// warnAndScheduleNext lists sessions due within the wider of
+// ttlPolicy.WarnBefore and cleanupInterval - one scan covers both - emits
+// EventAboutToExpire for any not yet warned, and returns how long
+// cleanupLoop should sleep before its next pass, per ttlPolicy's
+// NextExpiryCheck hints. It falls back to cleanupInterval if there's
+// nothing due or the store can't be queried.
+func (m *DefaultManager) warnAndScheduleNext(ctx context.Context) time.Duration {
+	warnWindow := m.ttlPolicy.WarnBefore()
+	scanWindow := m.cleanupInterval
+	if warnWindow > scanWindow {
+		scanWindow = warnWindow
+	}
+
+	upcoming, err := m.store.ListExpiringWithin(ctx, scanWindow)
+	if err != nil {
+		log.Printf("[Session] Failed to list sessions expiring within %s: %v", scanWindow, err)
+		return m.cleanupInterval
+	}
+	if len(upcoming) == 0 {
+		return m.cleanupInterval
+	}
+
+	now := time.Now()
+	delay := m.cleanupInterval
+	for _, sess := range upcoming {
+		if d := m.ttlPolicy.NextExpiryCheck(now, sess); d < delay {
+			delay = d
+		}
+
+		if warnWindow <= 0 || sess.ExpiryWarnedAt != nil || sess.ExpiresAt.Sub(now) > warnWindow {
+			continue
 		}
+		warnedAt := time.Now()
+		sess.ExpiryWarnedAt = &warnedAt
+		m.emit(ctx, EventAboutToExpire, sess, sess.Status)
+		if m.cache != nil {
+			if ttl := time.Until(sess.ExpiresAt); ttl > 0 {
+				m.cache.Set(ctx, sess, ttl)
+			}
+		}
+	}
+	if delay <= 0 {
+		delay = time.Second
 	}
+	return delay
 }
 
 // Close stops the manager
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkspaceManifest is one saved revision of a session's workspace: the
+// ordered list of content-addressed chunk hashes that reconstruct each
+// file. It is stored in the same database as the Session row (rather
+// than in object storage) so Diff and ListExpired-style cleanup can run
+// as plain SQL instead of round-tripping to MinIO.
+type WorkspaceManifest struct {
+	ID        uint      `gorm:"primaryKey"`
+	SessionID string    `gorm:"column:session_id;index;size:36"`
+	Rev       int       `gorm:"column:rev;index"`
+	FilesJSON string    `gorm:"column:files;type:text"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WorkspaceManifest) TableName() string {
+	return "workspace_manifests"
+}
+
+// ManifestFile is one file's entry within a WorkspaceManifest.
+type ManifestFile struct {
+	Path    string    `json:"path"`
+	Mode    int64     `json:"mode"`
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	// Chunks is the ordered list of SHA-256 hex hashes that, concatenated,
+	// reconstruct the file's content.
+	Chunks []string `json:"chunks"`
+}
+
+// Files decodes the manifest's file list.
+func (m *WorkspaceManifest) Files() ([]ManifestFile, error) {
+	var files []ManifestFile
+	if m.FilesJSON == "" {
+		return files, nil
+	}
+	if err := json.Unmarshal([]byte(m.FilesJSON), &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// SetFiles encodes files into the manifest's storage column.
+func (m *WorkspaceManifest) SetFiles(files []ManifestFile) error {
+	data, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+	m.FilesJSON = string(data)
+	return nil
+}
+
+// latestManifest returns the highest-revision manifest for sessionID, or
+// nil if none exists.
+func latestManifest(db *gorm.DB, sessionID string) (*WorkspaceManifest, error) {
+	var manifest WorkspaceManifest
+	err := db.Where("session_id = ?", sessionID).Order("rev DESC").First(&manifest).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// manifestAtRev returns a session's manifest at exactly rev, or nil if
+// none exists.
+func manifestAtRev(db *gorm.DB, sessionID string, rev int) (*WorkspaceManifest, error) {
+	var manifest WorkspaceManifest
+	err := db.Where("session_id = ? AND rev = ?", sessionID, rev).First(&manifest).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,25 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestMinioBackendConformance(t *testing.T) {
+	runBackendConformance(t, func(t *testing.T) Backend {
+		server := newFakeS3Server()
+		t.Cleanup(server.Close)
+
+		client, err := minio.New(server.endpoint(), &minio.Options{
+			Creds:  credentials.NewStaticV4("fake-access-key", "fake-secret-key", ""),
+			Secure: false,
+		})
+		if err != nil {
+			t.Fatalf("failed to create minio client: %v", err)
+		}
+
+		return &minioBackend{client: client, bucket: "test-bucket", partSize: defaultPartSize}
+	})
+}
@@ -3,7 +3,6 @@ package session
 import (
 	"archive/tar"
 	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -11,18 +10,73 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
-// MinIOStorage implements WorkspaceStorage using MinIO
+// defaultPartSize is the multipart upload chunk size PutObject uses when
+// MinIOConfig.PartSize is unset, large enough that a multi-GiB workspace
+// doesn't turn into thousands of parts.
+const defaultPartSize = 32 * 1024 * 1024 // 32 MiB
+
+// defaultCopyBufferSize bounds the intermediate buffer Restore uses to
+// shuttle bytes from the decompressed object into the container, instead
+// of reading the whole decompressed workspace into memory.
+const defaultCopyBufferSize = 4 * 1024 * 1024 // 4 MiB
+
+// defaultMaxConcurrentTransfers bounds how many Save/Restore calls a
+// workspace storage backend runs at once, so a burst of Pause/Resume
+// calls across many sessions can't each open their own multi-GiB stream
+// simultaneously.
+const defaultMaxConcurrentTransfers = 4
+
+// minioBackend implements Backend against a MinIO (or other S3-compatible)
+// bucket.
+type minioBackend struct {
+	client   *minio.Client
+	bucket   string
+	partSize uint64
+}
+
+func (b *minioBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := b.client.PutObject(ctx, b.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: "application/gzip",
+		PartSize:    b.partSize,
+	})
+	return err
+}
+
+func (b *minioBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.client.GetObject(ctx, b.bucket, key, minio.GetObjectOptions{})
+}
+
+func (b *minioBackend) Delete(ctx context.Context, key string) error {
+	return b.client.RemoveObject(ctx, b.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (b *minioBackend) Stat(ctx context.Context, key string) (bool, error) {
+	_, err := b.client.StatObject(ctx, b.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		errResp := minio.ToErrorResponse(err)
+		if errResp.Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// MinIOStorage implements WorkspaceStorage using MinIO. It composes the
+// shared objectWorkspaceStorage (container<->tar.gz streaming, progress,
+// concurrency) with a minioBackend; client and bucket stay on the struct
+// directly (rather than only inside the backend) because Replicator reads
+// the primary's MinIO client to copy snapshots to secondary endpoints
+// without going through a second Backend round-trip.
 type MinIOStorage struct {
-	client       *minio.Client
-	dockerClient *client.Client
-	bucket       string
-	workDir      string
+	*objectWorkspaceStorage
+	client *minio.Client
+	bucket string
 }
 
 // MinIOConfig holds MinIO connection configuration
@@ -33,17 +87,33 @@ type MinIOConfig struct {
 	Bucket    string
 	UseSSL    bool
 	WorkDir   string // Workspace directory inside container
+
+	// PartSize is the multipart upload chunk size Save uses. Zero means
+	// defaultPartSize.
+	PartSize uint64
+
+	// CopyBufferSize bounds the intermediate buffer Restore streams
+	// through when copying into a container. Zero means
+	// defaultCopyBufferSize.
+	CopyBufferSize int
+
+	// MaxConcurrentTransfers bounds how many Save/Restore calls run at
+	// once. Zero means defaultMaxConcurrentTransfers.
+	MaxConcurrentTransfers int
 }
 
 // DefaultMinIOConfig returns default MinIO configuration
 func DefaultMinIOConfig() MinIOConfig {
 	return MinIOConfig{
-		Endpoint:  "localhost:9000",
-		AccessKey: "minioadmin",
-		SecretKey: "minioadmin",
-		Bucket:    "sandbox-workspaces",
-		UseSSL:    false,
-		WorkDir:   "/workspace",
+		Endpoint:               "localhost:9000",
+		AccessKey:              "minioadmin",
+		SecretKey:              "minioadmin",
+		Bucket:                 "sandbox-workspaces",
+		UseSSL:                 false,
+		WorkDir:                "/workspace",
+		PartSize:               defaultPartSize,
+		CopyBufferSize:         defaultCopyBufferSize,
+		MaxConcurrentTransfers: defaultMaxConcurrentTransfers,
 	}
 }
 
@@ -64,11 +134,16 @@ func NewMinIOStorage(config MinIOConfig) (*MinIOStorage, error) {
 		return nil, fmt.Errorf("failed to create docker client: %w", err)
 	}
 
+	partSize := config.PartSize
+	if partSize == 0 {
+		partSize = defaultPartSize
+	}
+
+	backend := &minioBackend{client: minioClient, bucket: config.Bucket, partSize: partSize}
 	storage := &MinIOStorage{
-		client:       minioClient,
-		dockerClient: dockerClient,
-		bucket:       config.Bucket,
-		workDir:      config.WorkDir,
+		objectWorkspaceStorage: newObjectWorkspaceStorage(backend, dockerClient, config.WorkDir, config.CopyBufferSize, config.MaxConcurrentTransfers),
+		client:                 minioClient,
+		bucket:                 config.Bucket,
 	}
 
 	// Ensure bucket exists
@@ -94,122 +169,17 @@ func (s *MinIOStorage) ensureBucket(ctx context.Context) error {
 		log.Printf("[MinIO] Created bucket: %s", s.bucket)
 	}
 
-	return nil
-}
-
-// workspaceKey generates the object key for a session's workspace
-func workspaceKey(sessionID string) string {
-	return fmt.Sprintf("workspaces/%s/workspace.tar.gz", sessionID)
-}
-
-// Save saves the workspace from a sandbox container to MinIO
-func (s *MinIOStorage) Save(ctx context.Context, sessionID, sandboxID string) (string, error) {
-	// Get container ID from sandbox ID (assuming container name format: sandbox-<sandboxID>)
-	containerName := "sandbox-" + sandboxID
-
-	// Copy from container
-	reader, _, err := s.dockerClient.CopyFromContainer(ctx, containerName, s.workDir)
-	if err != nil {
-		return "", fmt.Errorf("failed to copy from container: %w", err)
-	}
-	defer reader.Close()
-
-	// Compress the tar archive
-	var compressed bytes.Buffer
-	gzWriter := gzip.NewWriter(&compressed)
-
-	if _, err := io.Copy(gzWriter, reader); err != nil {
-		return "", fmt.Errorf("failed to compress workspace: %w", err)
-	}
-
-	if err := gzWriter.Close(); err != nil {
-		return "", fmt.Errorf("failed to close gzip writer: %w", err)
+	// Enable object versioning so ListVersions/RestoreVersion/Tag/Fork
+	// can address prior workspace.tar.gz revisions instead of only the
+	// current one; PutObject overwrites keep every previous version
+	// around under its own VersionID rather than replacing it in place.
+	if err := s.client.EnableVersioning(ctx, s.bucket); err != nil {
+		return fmt.Errorf("failed to enable bucket versioning: %w", err)
 	}
 
-	// Upload to MinIO
-	objectKey := workspaceKey(sessionID)
-	_, err = s.client.PutObject(ctx, s.bucket, objectKey, &compressed, int64(compressed.Len()),
-		minio.PutObjectOptions{
-			ContentType: "application/gzip",
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload workspace: %w", err)
-	}
-
-	log.Printf("[MinIO] Saved workspace for session %s (%d bytes)", sessionID, compressed.Len())
-	return objectKey, nil
-}
-
-// Restore restores the workspace from MinIO to a sandbox container
-func (s *MinIOStorage) Restore(ctx context.Context, sessionID, sandboxID string) error {
-	containerName := "sandbox-" + sandboxID
-	objectKey := workspaceKey(sessionID)
-
-	// Download from MinIO
-	object, err := s.client.GetObject(ctx, s.bucket, objectKey, minio.GetObjectOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get workspace object: %w", err)
-	}
-	defer object.Close()
-
-	// Decompress
-	gzReader, err := gzip.NewReader(object)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzReader.Close()
-
-	// The content from docker is already a tar, just need to extract and re-tar
-	// First, read all content
-	var content bytes.Buffer
-	if _, err := io.Copy(&content, gzReader); err != nil {
-		return fmt.Errorf("failed to decompress workspace: %w", err)
-	}
-
-	// Copy to container
-	if err := s.dockerClient.CopyToContainer(ctx, containerName, "/", &content, container.CopyToContainerOptions{}); err != nil {
-		return fmt.Errorf("failed to copy to container: %w", err)
-	}
-
-	log.Printf("[MinIO] Restored workspace for session %s to sandbox %s", sessionID, sandboxID)
 	return nil
 }
 
-// Delete deletes the saved workspace for a session
-func (s *MinIOStorage) Delete(ctx context.Context, sessionID string) error {
-	objectKey := workspaceKey(sessionID)
-
-	if err := s.client.RemoveObject(ctx, s.bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
-		return fmt.Errorf("failed to delete workspace: %w", err)
-	}
-
-	log.Printf("[MinIO] Deleted workspace for session %s", sessionID)
-	return nil
-}
-
-// Exists checks if a workspace exists for a session
-func (s *MinIOStorage) Exists(ctx context.Context, sessionID string) (bool, error) {
-	objectKey := workspaceKey(sessionID)
-
-	_, err := s.client.StatObject(ctx, s.bucket, objectKey, minio.StatObjectOptions{})
-	if err != nil {
-		// Check if it's a "not found" error
-		errResp := minio.ToErrorResponse(err)
-		if errResp.Code == "NoSuchKey" {
-			return false, nil
-		}
-		return false, fmt.Errorf("failed to check workspace: %w", err)
-	}
-
-	return true, nil
-}
-
-// Close closes the storage clients
-func (s *MinIOStorage) Close() error {
-	return s.dockerClient.Close()
-}
-
 // Helper function to create tar from directory content
 func createTarFromDir(dirPath string, files map[string][]byte) (*bytes.Buffer, error) {
 	var buf bytes.Buffer
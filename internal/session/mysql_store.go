@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MySQLStore implements Store using MySQL
+type MySQLStore struct {
+	sqlStore
+}
+
+// MySQLConfig holds MySQL connection configuration
+type MySQLConfig struct {
+	Host            string
+	Port            int
+	User            string
+	Password        string
+	DBName          string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultMySQLConfig returns default MySQL configuration
+func DefaultMySQLConfig() MySQLConfig {
+	return MySQLConfig{
+		Host:            "localhost",
+		Port:            3306,
+		User:            "root",
+		Password:        "root",
+		DBName:          "sandbox",
+		MaxOpenConns:    25,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 5 * time.Minute,
+	}
+}
+
+// NewMySQLStore creates a new MySQL store
+func NewMySQLStore(config MySQLConfig) (*MySQLStore, error) {
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		config.User, config.Password, config.Host, config.Port, config.DBName,
+	)
+
+	conn := &DBConnector{Config: DBConfig{
+		Driver:          "mysql",
+		DSN:             dsn,
+		MaxOpenConns:    config.MaxOpenConns,
+		MaxIdleConns:    config.MaxIdleConns,
+		ConnMaxLifetime: config.ConnMaxLifetime,
+	}}
+
+	if _, err := conn.GetDB(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &MySQLStore{sqlStore{conn: conn}}, nil
+}
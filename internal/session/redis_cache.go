@@ -7,16 +7,27 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/metrics"
 )
 
 const (
-	sessionKeyPrefix = "session:"
+	sessionKeyPrefix     = "session:"
+	sessionEventsChannel = "session-events"
 )
 
-// RedisCache implements Cache using Redis
+// RedisCache implements SessionStore using Redis. It speaks the plain
+// Redis protocol (SETEX/EXPIRE/PUBLISH/pipelines), so it works unmodified
+// against a Valkey server too, and it's built on redis.UniversalClient so
+// the same type serves both a single Redis node (NewRedisCache) and a
+// Redis Cluster (NewRedisClusterCache).
 type RedisCache struct {
-	client *redis.Client
+	client redis.UniversalClient
 	ttl    time.Duration
+	codec  codec
 }
 
 // RedisConfig holds Redis connection configuration
@@ -25,6 +36,12 @@ type RedisConfig struct {
 	Password string
 	DB       int
 	PoolSize int
+
+	// Codec selects how session payloads are compressed before being
+	// written to Redis: "", "none" (default), "gzip", or "zstd". Reads
+	// always auto-detect the codec a given entry was written with, so
+	// changing this is safe even with old entries still cached.
+	Codec string
 }
 
 // DefaultRedisConfig returns default Redis configuration
@@ -37,7 +54,18 @@ func DefaultRedisConfig() RedisConfig {
 	}
 }
 
-// NewRedisCache creates a new Redis cache
+func (c RedisConfig) codec() codec {
+	switch c.Codec {
+	case "gzip":
+		return codecGzip
+	case "zstd":
+		return codecZstd
+	default:
+		return codecNone
+	}
+}
+
+// NewRedisCache creates a new Redis cache backed by a single node.
 func NewRedisCache(config RedisConfig, defaultTTL time.Duration) (*RedisCache, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     config.Addr,
@@ -46,7 +74,23 @@ func NewRedisCache(config RedisConfig, defaultTTL time.Duration) (*RedisCache, e
 		PoolSize: config.PoolSize,
 	})
 
-	// Test connection
+	return newRedisCache(client, config, defaultTTL)
+}
+
+// NewRedisClusterCache creates a new Redis cache backed by a Redis
+// Cluster, for deployments past the point where a single node's memory or
+// throughput is enough.
+func NewRedisClusterCache(addrs []string, config RedisConfig, defaultTTL time.Duration) (*RedisCache, error) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:    addrs,
+		Password: config.Password,
+		PoolSize: config.PoolSize,
+	})
+
+	return newRedisCache(client, config, defaultTTL)
+}
+
+func newRedisCache(client redis.UniversalClient, config RedisConfig, defaultTTL time.Duration) (*RedisCache, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -57,6 +101,7 @@ func NewRedisCache(config RedisConfig, defaultTTL time.Duration) (*RedisCache, e
 	return &RedisCache{
 		client: client,
 		ttl:    defaultTTL,
+		codec:  config.codec(),
 	}, nil
 }
 
@@ -65,8 +110,27 @@ func sessionKey(id string) string {
 	return sessionKeyPrefix + id
 }
 
+// nextVersion returns a new monotonic version for a Set, derived from
+// wall-clock time: good enough to order two writes to the same key
+// without an extra Redis round-trip to maintain a counter.
+func nextVersion() int64 {
+	return time.Now().UnixNano()
+}
+
 // Get retrieves a session from cache
 func (c *RedisCache) Get(ctx context.Context, id string) (*Session, error) {
+	ctx, span := metrics.Tracer.Start(ctx, "session.RedisCache.Get", trace.WithAttributes(attribute.String("session.id", id)))
+	defer span.End()
+
+	session, err := c.get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return session, err
+}
+
+func (c *RedisCache) get(ctx context.Context, id string) (*Session, error) {
 	data, err := c.client.Get(ctx, sessionKey(id)).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -75,29 +139,42 @@ func (c *RedisCache) Get(ctx context.Context, id string) (*Session, error) {
 		return nil, fmt.Errorf("failed to get session from cache: %w", err)
 	}
 
-	var session Session
-	if err := json.Unmarshal(data, &session); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	session, _, err := decodeSession(data)
+	if err != nil {
+		return nil, err
 	}
-
-	return &session, nil
+	return session, nil
 }
 
 // Set stores a session in cache
 func (c *RedisCache) Set(ctx context.Context, session *Session, ttl time.Duration) error {
+	ctx, span := metrics.Tracer.Start(ctx, "session.RedisCache.Set", trace.WithAttributes(attribute.String("session.id", session.ID)))
+	defer span.End()
+
+	err := c.set(ctx, session, ttl)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (c *RedisCache) set(ctx context.Context, session *Session, ttl time.Duration) error {
 	if ttl == 0 {
 		ttl = c.ttl
 	}
 
-	data, err := json.Marshal(session)
+	version := nextVersion()
+	data, err := encodeSession(session, version, c.codec)
 	if err != nil {
-		return fmt.Errorf("failed to marshal session: %w", err)
+		return err
 	}
 
 	if err := c.client.Set(ctx, sessionKey(session.ID), data, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to set session in cache: %w", err)
 	}
 
+	c.publish(ctx, SessionEvent{Type: SessionEventPut, SessionID: session.ID, Version: version})
 	return nil
 }
 
@@ -106,9 +183,171 @@ func (c *RedisCache) Delete(ctx context.Context, id string) error {
 	if err := c.client.Del(ctx, sessionKey(id)).Err(); err != nil {
 		return fmt.Errorf("failed to delete session from cache: %w", err)
 	}
+
+	c.publish(ctx, SessionEvent{Type: SessionEventDelete, SessionID: id, Version: nextVersion()})
+	return nil
+}
+
+// MGet retrieves multiple sessions in a single pipelined round-trip.
+func (c *RedisCache) MGet(ctx context.Context, ids []string) (map[string]*Session, error) {
+	if len(ids) == 0 {
+		return map[string]*Session{}, nil
+	}
+
+	pipe := c.client.Pipeline()
+	cmds := make(map[string]*redis.StringCmd, len(ids))
+	for _, id := range ids {
+		cmds[id] = pipe.Get(ctx, sessionKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to pipeline mget: %w", err)
+	}
+
+	result := make(map[string]*Session, len(ids))
+	for id, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s from mget pipeline: %w", id, err)
+		}
+		session, _, err := decodeSession(data)
+		if err != nil {
+			return nil, err
+		}
+		result[id] = session
+	}
+	return result, nil
+}
+
+// MSet stores multiple sessions in a single pipelined round-trip, all
+// with the same ttl. This is what the session manager uses to flush a
+// batch of dirty sessions without one round-trip per session.
+func (c *RedisCache) MSet(ctx context.Context, sessions []*Session, ttl time.Duration) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+
+	events := make([]SessionEvent, 0, len(sessions))
+	pipe := c.client.Pipeline()
+	for _, session := range sessions {
+		version := nextVersion()
+		data, err := encodeSession(session, version, c.codec)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, sessionKey(session.ID), data, ttl)
+		events = append(events, SessionEvent{Type: SessionEventPut, SessionID: session.ID, Version: version})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to pipeline mset: %w", err)
+	}
+
+	for _, event := range events {
+		c.publish(ctx, event)
+	}
 	return nil
 }
 
+// MDelete removes multiple sessions in a single pipelined round-trip.
+func (c *RedisCache) MDelete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	pipe := c.client.Pipeline()
+	for _, id := range ids {
+		pipe.Del(ctx, sessionKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to pipeline mdelete: %w", err)
+	}
+
+	for _, id := range ids {
+		c.publish(ctx, SessionEvent{Type: SessionEventDelete, SessionID: id, Version: nextVersion()})
+	}
+	return nil
+}
+
+// Scan iterates every cached session ID using Redis's cursor-based SCAN,
+// rather than a blocking KEYS enumeration.
+func (c *RedisCache) Scan(ctx context.Context) (<-chan string, error) {
+	ids := make(chan string, 64)
+
+	go func() {
+		defer close(ids)
+
+		iter := c.client.Scan(ctx, 0, sessionKeyPrefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			id := iter.Val()[len(sessionKeyPrefix):]
+			select {
+			case ids <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ids, nil
+}
+
+// publish broadcasts a session event to every node's cache over the shared
+// pub/sub channel. Failures are logged, not returned, since the caller's
+// own write to Redis already succeeded.
+func (c *RedisCache) publish(ctx context.Context, event SessionEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if err := c.client.Publish(ctx, sessionEventsChannel, data).Err(); err != nil {
+		fmt.Printf("[RedisCache] Failed to publish session event: %v\n", err)
+	}
+}
+
+// Watch implements SessionWatcher by subscribing to the shared Redis
+// pub/sub channel, giving every node cross-node invalidation instead of
+// relying on each node's cache entries lazily expiring on their own TTL.
+func (c *RedisCache) Watch(ctx context.Context) (<-chan SessionEvent, error) {
+	sub := c.client.Subscribe(ctx, sessionEventsChannel)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to session events: %w", err)
+	}
+
+	events := make(chan SessionEvent, 64)
+	go func() {
+		defer close(events)
+		defer sub.Close()
+
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var event SessionEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
 // Touch updates the TTL of a cached session
 func (c *RedisCache) Touch(ctx context.Context, id string, ttl time.Duration) error {
 	if ttl == 0 {
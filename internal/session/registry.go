@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// StoreFactory constructs a Store from a driver-specific DSN.
+type StoreFactory func(dsn string) (Store, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]StoreFactory{
+		"postgres": func(dsn string) (Store, error) { return newSQLStore("postgres", dsn) },
+		"sqlite":   func(dsn string) (Store, error) { return newSQLStore("sqlite", dsn) },
+		"mysql":    func(dsn string) (Store, error) { return newSQLStore("mysql", dsn) },
+		"bolt":     func(dsn string) (Store, error) { return NewBoltStore(BoltConfig{Path: dsn}) },
+		"fs":       func(dsn string) (Store, error) { return NewFSStore(FSConfig{BaseDir: dsn}) },
+		"gdbm":     newGDBMStoreFactory,
+	}
+)
+
+// Register adds a named Store backend so callers can select one by name
+// (e.g. from a config file or flag) instead of importing and constructing
+// a concrete type directly. Downstream users can call Register from their
+// own package's init() to plug in a backend this package doesn't ship.
+// Registering a name a second time replaces the existing factory.
+func Register(name string, factory StoreFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Open constructs the named Store backend with the given DSN.
+func Open(name, dsn string) (Store, error) {
+	registryMu.Lock()
+	factory, ok := registry[name]
+	registryMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("session: unknown store backend %q", name)
+	}
+	return factory(dsn)
+}
+
+// newSQLStore opens a bare *sqlStore for one of the built-in SQL drivers,
+// used by the registry so Open doesn't have to thread each backend's
+// structured Config type through a single string DSN.
+func newSQLStore(driver, dsn string) (Store, error) {
+	conn := &DBConnector{Config: DBConfig{Driver: driver, DSN: dsn}}
+	if _, err := conn.GetDB(context.Background()); err != nil {
+		return nil, err
+	}
+	return &sqlStore{conn: conn}, nil
+}
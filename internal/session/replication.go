@@ -0,0 +1,271 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ReplicationConfig configures cross-region replication of workspace
+// snapshots from MinIOStorage to one or more secondary MinIO/S3-compatible
+// endpoints.
+type ReplicationConfig struct {
+	Secondaries []SecondaryConfig
+
+	// Concurrency bounds how many replication jobs run at once.
+	Concurrency int
+
+	// MaxRetries is how many times a failed secondary copy is retried
+	// (with exponential backoff starting at InitialBackoff) before its
+	// ReplicaRef is left at ReplicaStatusFailed for manual retry via the
+	// replication endpoint.
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry; each
+	// subsequent retry doubles it.
+	InitialBackoff time.Duration
+}
+
+// SecondaryConfig is one secondary replication target, named and
+// prioritized so RestoreWorkspace's fallback has a deterministic order
+// (lowest Priority first).
+type SecondaryConfig struct {
+	Name      string
+	Priority  int
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+}
+
+// DefaultReplicationConfig returns default replication configuration. It
+// has no secondaries configured; callers add their own.
+func DefaultReplicationConfig() ReplicationConfig {
+	return ReplicationConfig{
+		Concurrency:    4,
+		MaxRetries:     3,
+		InitialBackoff: 2 * time.Second,
+	}
+}
+
+type secondaryTarget struct {
+	SecondaryConfig
+	client *minio.Client
+}
+
+// Replicator asynchronously copies a session's primary workspace snapshot
+// (saved by MinIOStorage) to every configured secondary, recording
+// per-secondary status on the session so DefaultManager.RestoreWorkspace
+// can fall back to them in priority order if the primary read fails.
+type Replicator struct {
+	primary     *MinIOStorage
+	secondaries []secondaryTarget
+	store       Store
+	config      ReplicationConfig
+
+	jobs chan string // session IDs pending replication
+	wg   sync.WaitGroup
+}
+
+// NewReplicator creates a Replicator that copies primary's workspace
+// snapshots to config.Secondaries, persisting status onto each session via
+// store. Call Close to stop its worker pool.
+func NewReplicator(primary *MinIOStorage, store Store, config ReplicationConfig) (*Replicator, error) {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 2 * time.Second
+	}
+
+	secondaries := make([]secondaryTarget, 0, len(config.Secondaries))
+	for _, sc := range config.Secondaries {
+		client, err := minio.New(sc.Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(sc.AccessKey, sc.SecretKey, ""),
+			Secure: sc.UseSSL,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create minio client for secondary %s: %w", sc.Name, err)
+		}
+		secondaries = append(secondaries, secondaryTarget{SecondaryConfig: sc, client: client})
+	}
+	sort.Slice(secondaries, func(i, j int) bool { return secondaries[i].Priority < secondaries[j].Priority })
+
+	r := &Replicator{
+		primary:     primary,
+		secondaries: secondaries,
+		store:       store,
+		config:      config,
+		jobs:        make(chan string, 256),
+	}
+
+	for i := 0; i < config.Concurrency; i++ {
+		r.wg.Add(1)
+		go r.worker()
+	}
+
+	return r, nil
+}
+
+// Replicate enqueues sessionID's current workspace snapshot for
+// asynchronous replication to every secondary. Non-blocking: if the queue
+// is full the job is dropped and logged, since the next Pause (or a
+// manual retry through the replication endpoint) will enqueue it again.
+func (r *Replicator) Replicate(sessionID string) {
+	select {
+	case r.jobs <- sessionID:
+	default:
+		log.Printf("[Replicator] Replication queue full, dropping job for session %s", sessionID)
+	}
+}
+
+// Retry re-enqueues sessionID for replication, overwriting whatever status
+// its ReplicaRefs currently hold.
+func (r *Replicator) Retry(sessionID string) {
+	r.Replicate(sessionID)
+}
+
+func (r *Replicator) worker() {
+	defer r.wg.Done()
+	for sessionID := range r.jobs {
+		r.replicateOnce(context.Background(), sessionID)
+	}
+}
+
+func (r *Replicator) replicateOnce(ctx context.Context, sessionID string) {
+	sess, err := r.store.Get(ctx, sessionID)
+	if err != nil {
+		log.Printf("[Replicator] Failed to load session %s: %v", sessionID, err)
+		return
+	}
+	if sess.WorkspaceURL == "" {
+		return
+	}
+
+	data, err := r.fetchPrimary(ctx, sess.WorkspaceURL)
+	if err != nil {
+		log.Printf("[Replicator] Failed to read primary snapshot for session %s: %v", sessionID, err)
+		return
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	replicas := make([]ReplicaRef, len(r.secondaries))
+	for i, target := range r.secondaries {
+		replicas[i] = r.copyToSecondary(ctx, target, sess.WorkspaceURL, data, checksum)
+	}
+
+	sess.WorkspaceReplicas = replicas
+	if err := r.store.Update(ctx, sess); err != nil {
+		log.Printf("[Replicator] Failed to persist replica status for session %s: %v", sessionID, err)
+	}
+}
+
+func (r *Replicator) fetchPrimary(ctx context.Context, objectKey string) ([]byte, error) {
+	object, err := r.primary.client.GetObject(ctx, r.primary.bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, object); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Replicator) copyToSecondary(ctx context.Context, target secondaryTarget, objectKey string, data []byte, checksum string) ReplicaRef {
+	ref := ReplicaRef{
+		Name:      target.Name,
+		Endpoint:  target.Endpoint,
+		Priority:  target.Priority,
+		Checksum:  checksum,
+		UpdatedAt: time.Now(),
+	}
+
+	backoff := r.config.InitialBackoff
+	var lastErr error
+	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				ref.Status = ReplicaStatusFailed
+				ref.Error = ctx.Err().Error()
+				return ref
+			}
+			backoff *= 2
+		}
+
+		_, err := target.client.PutObject(ctx, target.Bucket, objectKey, bytes.NewReader(data), int64(len(data)),
+			minio.PutObjectOptions{ContentType: "application/gzip"})
+		if err == nil {
+			ref.Status = ReplicaStatusOK
+			return ref
+		}
+		lastErr = err
+	}
+
+	ref.Status = ReplicaStatusFailed
+	ref.Error = lastErr.Error()
+	return ref
+}
+
+// RestoreFrom restores sessionID's workspace from the named secondary into
+// sandboxID, for RestoreWorkspace's primary-failure fallback.
+func (r *Replicator) RestoreFrom(ctx context.Context, name, sessionID, sandboxID string) error {
+	var target *secondaryTarget
+	for i := range r.secondaries {
+		if r.secondaries[i].Name == name {
+			target = &r.secondaries[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("unknown replication secondary: %s", name)
+	}
+
+	object, err := target.client.GetObject(ctx, target.Bucket, workspaceKey(sessionID), minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get object from secondary %s: %w", name, err)
+	}
+	defer object.Close()
+
+	containerName := "sandbox-" + sandboxID
+	if err := r.primary.dockerClient.CopyToContainer(ctx, containerName, "/", object, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy to container from secondary %s: %w", name, err)
+	}
+	return nil
+}
+
+// Status returns the current replica status recorded for sessionID.
+func (r *Replicator) Status(ctx context.Context, sessionID string) ([]ReplicaRef, error) {
+	sess, err := r.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return sess.WorkspaceReplicas, nil
+}
+
+// Close stops accepting new jobs and waits for in-flight ones to finish.
+func (r *Replicator) Close() error {
+	close(r.jobs)
+	r.wg.Wait()
+	return nil
+}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sqlStore implements Store against any GORM dialector reachable through a
+// DBConnector. PostgresStore, SQLiteStore and MySQLStore each embed one so
+// the CRUD methods are written exactly once regardless of backend.
+type sqlStore struct {
+	conn *DBConnector
+}
+
+func (s *sqlStore) db(ctx context.Context) (*gorm.DB, error) {
+	return s.conn.GetDB(ctx)
+}
+
+// Create stores a new session
+func (s *sqlStore) Create(ctx context.Context, session *Session) error {
+	db, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+	session.ResourceVersion = 1
+	return db.Create(session).Error
+}
+
+// Get retrieves a session by ID
+func (s *sqlStore) Get(ctx context.Context, id string) (*Session, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := db.First(&session, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetByUser retrieves sessions by user ID
+func (s *sqlStore) GetByUser(ctx context.Context, userID string) ([]*Session, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	if err := db.Where("user_id = ?", userID).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// GetByBearerTokenHash retrieves a session by its hashed bearer token
+func (s *sqlStore) GetByBearerTokenHash(ctx context.Context, hash string) (*Session, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := db.First(&session, "bearer_token_hash = ?", hash).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("session not found for bearer token")
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update updates a session, enforcing optimistic concurrency: session's
+// ResourceVersion must match the currently stored value, or the write is
+// rejected with ErrConflict instead of silently clobbering a concurrent
+// writer's change.
+func (s *sqlStore) Update(ctx context.Context, session *Session) error {
+	db, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+
+	expectedVersion := session.ResourceVersion
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var current Session
+		if err := tx.Select("resource_version").First(&current, "id = ?", session.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("session not found: %s", session.ID)
+			}
+			return err
+		}
+		if current.ResourceVersion != expectedVersion {
+			return fmt.Errorf("%w: session %s", ErrConflict, session.ID)
+		}
+
+		session.UpdatedAt = time.Now()
+		session.ResourceVersion = expectedVersion + 1
+		return tx.Save(session).Error
+	})
+	if err != nil {
+		session.ResourceVersion = expectedVersion
+		return err
+	}
+	return nil
+}
+
+// Delete deletes a session
+func (s *sqlStore) Delete(ctx context.Context, id string) error {
+	db, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Delete(&Session{}, "id = ?", id).Error
+}
+
+// ListExpired lists expired sessions
+func (s *sqlStore) ListExpired(ctx context.Context) ([]*Session, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	if err := db.Where("expires_at < ?", time.Now()).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// DeleteExpired deletes expired sessions
+func (s *sqlStore) DeleteExpired(ctx context.Context) (int, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return 0, err
+	}
+	result := db.Where("expires_at < ?", time.Now()).Delete(&Session{})
+	return int(result.RowsAffected), result.Error
+}
+
+// ListExpiringWithin lists sessions not yet expired whose ExpiresAt falls
+// within window from now.
+func (s *sqlStore) ListExpiringWithin(ctx context.Context, window time.Duration) ([]*Session, error) {
+	db, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var sessions []*Session
+	if err := db.Where("expires_at >= ? AND expires_at < ?", now, now.Add(window)).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Close closes the database connection
+func (s *sqlStore) Close() error {
+	return s.conn.Close()
+}
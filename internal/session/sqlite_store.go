@@ -0,0 +1,38 @@
+package session
+
+import "context"
+
+// SQLiteStore implements Store using SQLite, for local development and
+// single-node deployments that don't want to stand up a PostgreSQL
+// instance just to run the session manager.
+type SQLiteStore struct {
+	sqlStore
+}
+
+// SQLiteConfig holds SQLite connection configuration
+type SQLiteConfig struct {
+	// Path is the database file, e.g. "sandbox.db". Use ":memory:" for an
+	// ephemeral in-process database (tests use this).
+	Path string
+}
+
+// DefaultSQLiteConfig returns default SQLite configuration
+func DefaultSQLiteConfig() SQLiteConfig {
+	return SQLiteConfig{
+		Path: "sandbox.db",
+	}
+}
+
+// NewSQLiteStore creates a new SQLite store
+func NewSQLiteStore(config SQLiteConfig) (*SQLiteStore, error) {
+	conn := &DBConnector{Config: DBConfig{
+		Driver: "sqlite",
+		DSN:    config.Path,
+	}}
+
+	if _, err := conn.GetDB(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return &SQLiteStore{sqlStore{conn: conn}}, nil
+}
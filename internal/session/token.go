@@ -0,0 +1,38 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrInvalidBearerToken is returned by ValidateSession when the token does
+// not match any session.
+var ErrInvalidBearerToken = errors.New("invalid bearer token")
+
+// ErrSessionExpired is returned by ValidateSession when the token matches an
+// expired session.
+var ErrSessionExpired = errors.New("session expired")
+
+// ErrConflict is returned by Store.Update when the session's
+// ResourceVersion doesn't match what's currently stored - another writer
+// updated it first. Callers retry by re-fetching and reapplying their
+// mutation rather than overwriting the concurrent write.
+var ErrConflict = errors.New("session was concurrently modified")
+
+// generateBearerToken returns a new random bearer token and its hash. The
+// raw token is handed to the caller exactly once; only the hash is stored.
+func generateBearerToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, hashBearerToken(token), nil
+}
+
+func hashBearerToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,298 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// TTLEvent identifies which lifecycle moment is asking a TTLPolicy for a
+// new expiry: the distinction matters because, say, SlidingWindowTTLPolicy
+// honors an explicit CreateSessionRequest.TTL on TTLEventCreate but not on
+// TTLEventTouch.
+type TTLEvent string
+
+const (
+	TTLEventCreate TTLEvent = "create"
+	TTLEventTouch  TTLEvent = "touch"
+	TTLEventResume TTLEvent = "resume"
+)
+
+// defaultWarnWindow is how long before ExpiresAt DefaultManager's built-in
+// SlidingWindowTTLPolicy fires EventAboutToExpire, used when
+// ManagerConfig doesn't set a TTLPolicy explicitly.
+const defaultWarnWindow = 10 * time.Minute
+
+// TTLPolicy decides how long a session should live in response to its
+// lifecycle. DefaultManager consults it on Create, Touch, and Resume
+// instead of hard-coding defaultTTL/maxTTL arithmetic, so swapping in a
+// usage-based or schedule-based extension strategy doesn't touch Manager.
+type TTLPolicy interface {
+	// Expiry returns the ExpiresAt sess should carry after event. now is
+	// the time of the event; requestedTTL is CreateSessionRequest.TTL and
+	// is only meaningful when event is TTLEventCreate (zero otherwise).
+	Expiry(ctx context.Context, now time.Time, sess *Session, event TTLEvent, requestedTTL time.Duration) time.Time
+
+	// NextExpiryCheck returns how long from now the cleanup loop should
+	// wait before re-evaluating sess, letting a policy like
+	// ScheduleTTLPolicy make the cleanup loop sleep through a quiet
+	// period instead of polling every session on a fixed interval.
+	NextExpiryCheck(now time.Time, sess *Session) time.Duration
+
+	// WarnBefore returns how long before ExpiresAt the manager should
+	// emit EventAboutToExpire. Zero disables the warning.
+	WarnBefore() time.Duration
+}
+
+// SlidingWindowTTLPolicy is the default TTLPolicy: every Touch or Resume
+// pushes ExpiresAt out to now+IdleTimeout, capped so a session never lives
+// past CreatedAt+MaxTTL regardless of how recently it was touched.
+type SlidingWindowTTLPolicy struct {
+	// IdleTimeout is how long a session may go without activity before
+	// expiring.
+	IdleTimeout time.Duration
+
+	// MaxTTL bounds total session lifetime from CreatedAt.
+	MaxTTL time.Duration
+
+	// WarnWindow is how long before ExpiresAt EventAboutToExpire fires.
+	// Zero disables the warning.
+	WarnWindow time.Duration
+}
+
+// NewSlidingWindowTTLPolicy returns a SlidingWindowTTLPolicy with the given
+// bounds.
+func NewSlidingWindowTTLPolicy(idleTimeout, maxTTL, warnWindow time.Duration) *SlidingWindowTTLPolicy {
+	return &SlidingWindowTTLPolicy{IdleTimeout: idleTimeout, MaxTTL: maxTTL, WarnWindow: warnWindow}
+}
+
+// Expiry extends by IdleTimeout (or requestedTTL on create, if given),
+// clamped to CreatedAt+MaxTTL.
+func (p *SlidingWindowTTLPolicy) Expiry(ctx context.Context, now time.Time, sess *Session, event TTLEvent, requestedTTL time.Duration) time.Time {
+	idle := p.IdleTimeout
+	if event == TTLEventCreate && requestedTTL > 0 {
+		idle = requestedTTL
+	}
+
+	expiry := now.Add(idle)
+	createdAt := sess.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	if maxExpiry := createdAt.Add(p.MaxTTL); p.MaxTTL > 0 && expiry.After(maxExpiry) {
+		expiry = maxExpiry
+	}
+	return expiry
+}
+
+// NextExpiryCheck returns the time remaining until sess.ExpiresAt, so the
+// cleanup loop wakes up right as it's due rather than on its next fixed
+// tick.
+func (p *SlidingWindowTTLPolicy) NextExpiryCheck(now time.Time, sess *Session) time.Duration {
+	return sess.ExpiresAt.Sub(now)
+}
+
+// WarnBefore returns WarnWindow.
+func (p *SlidingWindowTTLPolicy) WarnBefore() time.Duration {
+	return p.WarnWindow
+}
+
+// UsageSource supplies a session's actual resource consumption, letting
+// UsageBasedTTLPolicy extend busy sessions longer than idle ones instead
+// of treating every Touch the same. The sandbox agent's metrics endpoint
+// is the production implementation.
+type UsageSource interface {
+	// Usage returns sessionID's CPU and memory utilization, each in
+	// [0,1] as a fraction of the session's CPUCount/MemoryMB, averaged
+	// over the source's own sampling window. ok is false if no usage
+	// data is available yet (e.g. the sandbox just started).
+	Usage(ctx context.Context, sessionID string) (cpu, mem float64, ok bool)
+}
+
+// UsageBasedTTLPolicy scales the idle-timeout extension by how much of a
+// session's allotted CPU and memory it's actually consuming, so a session
+// running a heavy build gets more runway than one sitting idle at the
+// shell prompt.
+type UsageBasedTTLPolicy struct {
+	// BaseIdleTimeout is the extension an unused session gets (usage
+	// factor 0).
+	BaseIdleTimeout time.Duration
+
+	// MaxMultiplier bounds how large the usage factor can scale
+	// BaseIdleTimeout (at 100% utilization).
+	MaxMultiplier float64
+
+	// MaxTTL bounds total session lifetime from CreatedAt.
+	MaxTTL time.Duration
+
+	// WarnWindow is how long before ExpiresAt EventAboutToExpire fires.
+	WarnWindow time.Duration
+
+	// Source reports actual CPU/memory consumption per session.
+	Source UsageSource
+}
+
+// NewUsageBasedTTLPolicy returns a UsageBasedTTLPolicy reading consumption
+// from source.
+func NewUsageBasedTTLPolicy(baseIdleTimeout time.Duration, maxMultiplier float64, maxTTL, warnWindow time.Duration, source UsageSource) *UsageBasedTTLPolicy {
+	return &UsageBasedTTLPolicy{
+		BaseIdleTimeout: baseIdleTimeout,
+		MaxMultiplier:   maxMultiplier,
+		MaxTTL:          maxTTL,
+		WarnWindow:      warnWindow,
+		Source:          source,
+	}
+}
+
+// Expiry extends by BaseIdleTimeout scaled by the larger of sess's CPU and
+// memory utilization (1 + usage*(MaxMultiplier-1)), clamped to
+// CreatedAt+MaxTTL. Create gets BaseIdleTimeout (or requestedTTL, if
+// given) unscaled: there's no usage history for a session that doesn't
+// exist yet.
+func (p *UsageBasedTTLPolicy) Expiry(ctx context.Context, now time.Time, sess *Session, event TTLEvent, requestedTTL time.Duration) time.Time {
+	idle := p.BaseIdleTimeout
+	if event == TTLEventCreate && requestedTTL > 0 {
+		idle = requestedTTL
+	} else if event != TTLEventCreate && p.Source != nil {
+		if cpu, mem, ok := p.Source.Usage(ctx, sess.ID); ok {
+			usage := cpu
+			if mem > usage {
+				usage = mem
+			}
+			if usage < 0 {
+				usage = 0
+			}
+			if usage > 1 {
+				usage = 1
+			}
+			factor := 1 + usage*(p.MaxMultiplier-1)
+			idle = time.Duration(float64(p.BaseIdleTimeout) * factor)
+		}
+	}
+
+	expiry := now.Add(idle)
+	createdAt := sess.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	if maxExpiry := createdAt.Add(p.MaxTTL); p.MaxTTL > 0 && expiry.After(maxExpiry) {
+		expiry = maxExpiry
+	}
+	return expiry
+}
+
+// NextExpiryCheck returns the time remaining until sess.ExpiresAt.
+func (p *UsageBasedTTLPolicy) NextExpiryCheck(now time.Time, sess *Session) time.Duration {
+	return sess.ExpiresAt.Sub(now)
+}
+
+// WarnBefore returns WarnWindow.
+func (p *UsageBasedTTLPolicy) WarnBefore() time.Duration {
+	return p.WarnWindow
+}
+
+// WorkHours describes a recurring window, in a fixed timezone, during
+// which ScheduleTTLPolicy extends sessions normally.
+type WorkHours struct {
+	// Location is the timezone Start/End/Days are evaluated in. A nil
+	// Location is treated as time.Local.
+	Location *time.Location
+
+	// Start and End are hours of day (0-23) bounding the work window,
+	// e.g. Start: 9, End: 18 for 9am-6pm.
+	Start, End int
+
+	// Days lists the weekdays the window applies to, e.g. Monday through
+	// Friday. An empty Days matches every day.
+	Days []time.Weekday
+}
+
+// Contains reports whether t falls within the work window.
+func (w WorkHours) Contains(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+
+	if len(w.Days) > 0 {
+		matched := false
+		for _, d := range w.Days {
+			if t.Weekday() == d {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	hour := t.Hour()
+	return hour >= w.Start && hour < w.End
+}
+
+// nextWindowStart returns the next time t.Add(0) or later at which
+// WorkHours begins, checked hour by hour up to 8 days out (covering a
+// Days-restricted window that skips a long weekend).
+func (w WorkHours) nextWindowStart(t time.Time) time.Time {
+	for i := 0; i < 8*24; i++ {
+		candidate := t.Add(time.Duration(i) * time.Hour)
+		if w.Contains(candidate) {
+			return candidate
+		}
+	}
+	return t
+}
+
+// ScheduleTTLPolicy extends sessions by IdleTimeout as usual during
+// WorkHours, but pins ExpiresAt to the start of the next work window
+// (plus IdleTimeout) when activity happens outside it - so with Days set
+// to Monday through Friday and Start at 9, a session touched at 7pm on a
+// Friday gets its IdleTimeout counted from 9am Monday.
+type ScheduleTTLPolicy struct {
+	IdleTimeout time.Duration
+	MaxTTL      time.Duration
+	WarnWindow  time.Duration
+	WorkHours   WorkHours
+}
+
+// NewScheduleTTLPolicy returns a ScheduleTTLPolicy extending by
+// idleTimeout during workHours, capped at maxTTL from CreatedAt.
+func NewScheduleTTLPolicy(idleTimeout time.Duration, workHours WorkHours, maxTTL, warnWindow time.Duration) *ScheduleTTLPolicy {
+	return &ScheduleTTLPolicy{IdleTimeout: idleTimeout, MaxTTL: maxTTL, WarnWindow: warnWindow, WorkHours: workHours}
+}
+
+// Expiry extends by IdleTimeout from now if now is within WorkHours, or
+// from the start of the next work window otherwise, clamped to
+// CreatedAt+MaxTTL.
+func (p *ScheduleTTLPolicy) Expiry(ctx context.Context, now time.Time, sess *Session, event TTLEvent, requestedTTL time.Duration) time.Time {
+	idle := p.IdleTimeout
+	if event == TTLEventCreate && requestedTTL > 0 {
+		idle = requestedTTL
+	}
+
+	base := now
+	if !p.WorkHours.Contains(now) {
+		base = p.WorkHours.nextWindowStart(now)
+	}
+	expiry := base.Add(idle)
+
+	createdAt := sess.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	if maxExpiry := createdAt.Add(p.MaxTTL); p.MaxTTL > 0 && expiry.After(maxExpiry) {
+		expiry = maxExpiry
+	}
+	return expiry
+}
+
+// NextExpiryCheck returns the time remaining until sess.ExpiresAt.
+func (p *ScheduleTTLPolicy) NextExpiryCheck(now time.Time, sess *Session) time.Duration {
+	return sess.ExpiresAt.Sub(now)
+}
+
+// WarnBefore returns WarnWindow.
+func (p *ScheduleTTLPolicy) WarnBefore() time.Duration {
+	return p.WarnWindow
+}
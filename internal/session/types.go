@@ -29,6 +29,12 @@ type Session struct {
 	CPUCount int    `json:"cpu_count"`
 	MemoryMB int64  `json:"memory_mb"`
 
+	// Bearer token used by ValidateSession. Only the hash is persisted;
+	// BearerToken carries the raw value back to the caller the moment it is
+	// minted (on Create, rotation, or migration) and is never stored.
+	BearerTokenHash string `json:"-" gorm:"column:bearer_token_hash;index;size:64"`
+	BearerToken     string `json:"bearer_token,omitempty" gorm:"-"`
+
 	// Timestamps
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
@@ -38,6 +44,32 @@ type Session struct {
 
 	// Metadata
 	Metadata map[string]string `json:"metadata,omitempty" gorm:"-"`
+
+	// WorkspaceReplicas records replication status of this session's
+	// workspace snapshot across ReplicationConfig's secondary endpoints,
+	// in declared priority order. RestoreWorkspace falls back to these
+	// (in order) if the primary read fails.
+	WorkspaceReplicas []ReplicaRef `json:"workspace_replicas,omitempty" gorm:"-"`
+
+	// EventSeq is the sequence number of the last lifecycle Event emitted
+	// for this session. It's persisted here (rather than kept only in the
+	// event bus) so a subscriber that reconnects with ?since=N can tell
+	// whether it missed anything, even across a manager restart.
+	EventSeq int64 `json:"event_seq" gorm:"column:event_seq"`
+
+	// ResourceVersion is bumped by Store.Update on every successful write,
+	// Kubernetes-object style. A caller's copy carries the version it last
+	// read; Update compares it against the stored version and returns
+	// ErrConflict instead of silently clobbering a write from another
+	// replica if they don't match.
+	ResourceVersion uint64 `json:"resource_version" gorm:"column:resource_version"`
+
+	// ExpiryWarnedAt records when EventAboutToExpire was last emitted for
+	// this session, so the cleanup loop's warning scan doesn't re-emit it
+	// every pass while ExpiresAt sits inside the policy's WarnBefore
+	// window. Touch and Resume clear it again once they push ExpiresAt
+	// back out.
+	ExpiryWarnedAt *time.Time `json:"expiry_warned_at,omitempty" gorm:"column:expiry_warned_at"`
 }
 
 // TableName specifies the table name for GORM
@@ -45,6 +77,37 @@ func (Session) TableName() string {
 	return "sessions"
 }
 
+// ReplicaStatus is the state of a single secondary workspace copy.
+type ReplicaStatus string
+
+const (
+	ReplicaStatusPending ReplicaStatus = "pending"
+	ReplicaStatusOK      ReplicaStatus = "ok"
+	ReplicaStatusFailed  ReplicaStatus = "failed"
+)
+
+// ReplicaRef records one secondary's copy of a session's workspace
+// snapshot, in the priority order RestoreWorkspace's fallback walks them.
+type ReplicaRef struct {
+	Name      string        `json:"name"`
+	Endpoint  string        `json:"endpoint"`
+	Priority  int           `json:"priority"`
+	Status    ReplicaStatus `json:"status"`
+	Checksum  string        `json:"checksum,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// Snapshot describes one object-versioned revision of a session's
+// workspace, as recorded by MinIO bucket versioning and surfaced by
+// MinIOStorage.ListVersions.
+type Snapshot struct {
+	VersionID    string    `json:"version_id"`
+	IsLatest     bool      `json:"is_latest"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
 // IsExpired checks if the session has expired
 func (s *Session) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
@@ -65,31 +128,54 @@ type CreateSessionRequest struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
-// Manager defines the interface for session management
-type Manager interface {
+// SessionCreator defines the lifecycle-mutating half of session management:
+// everything that brings a session into or out of existence.
+type SessionCreator interface {
 	// Create creates a new session
 	Create(ctx context.Context, req CreateSessionRequest) (*Session, error)
 
+	// Pause pauses a session (saves workspace, releases sandbox)
+	Pause(ctx context.Context, id string) error
+
+	// Resume resumes a paused session
+	Resume(ctx context.Context, id string) (*Session, error)
+
+	// Delete deletes a session
+	Delete(ctx context.Context, id string) error
+}
+
+// SessionAccessor defines the read/auth half of session management. This is
+// the interface the gateway's authenticate path is restricted to: it can
+// validate a bearer token and look sessions up, but it cannot create or
+// delete them.
+type SessionAccessor interface {
+	// ValidateSession validates a short-lived bearer token (distinct from
+	// the session ID) and returns the session it grants access to. Unlike
+	// Get, a leaked session ID from a URL or log is not sufficient on its
+	// own to pass this check.
+	ValidateSession(ctx context.Context, bearerToken string) (*Session, error)
+
 	// Get retrieves a session by ID
 	Get(ctx context.Context, id string) (*Session, error)
 
 	// GetByUser retrieves all sessions for a user
 	GetByUser(ctx context.Context, userID string) ([]*Session, error)
 
-	// Update updates a session
-	Update(ctx context.Context, session *Session) error
-
-	// Delete deletes a session
-	Delete(ctx context.Context, id string) error
-
-	// Pause pauses a session (saves workspace, releases sandbox)
-	Pause(ctx context.Context, id string) error
+	// Touch updates the last active time and rotates the bearer token. It
+	// returns the session with the new raw token populated in
+	// Session.BearerToken, the same one-time disclosure Create uses, since
+	// the hash left on the stored session can't be turned back into it.
+	Touch(ctx context.Context, id string) (*Session, error)
+}
 
-	// Resume resumes a paused session
-	Resume(ctx context.Context, id string) (*Session, error)
+// Manager composes SessionCreator and SessionAccessor with the remaining
+// bookkeeping operations that don't cleanly belong to either.
+type Manager interface {
+	SessionCreator
+	SessionAccessor
 
-	// Touch updates the last active time
-	Touch(ctx context.Context, id string) error
+	// Update updates a session
+	Update(ctx context.Context, session *Session) error
 
 	// Cleanup removes expired sessions
 	Cleanup(ctx context.Context) (int, error)
@@ -101,6 +187,37 @@ type Manager interface {
 	UnbindSandbox(ctx context.Context, sessionID string) error
 }
 
+// SessionEventType identifies the kind of change a SessionWatcher reports.
+type SessionEventType string
+
+const (
+	SessionEventPut    SessionEventType = "put"
+	SessionEventDelete SessionEventType = "delete"
+	SessionEventExpire SessionEventType = "expire"
+)
+
+// SessionEvent is a single session change notification.
+type SessionEvent struct {
+	Type      SessionEventType `json:"type"`
+	SessionID string           `json:"session_id"`
+
+	// Version is the writer's monotonic version for SessionID at the time
+	// of the event. Subscribers compare it against their own cached
+	// version and only evict if Version >= their cached value, so a
+	// delayed invalidation for a since-overwritten entry can't clobber
+	// newer data.
+	Version int64 `json:"version,omitempty"`
+}
+
+// SessionWatcher produces a stream of session change events. RedisCache
+// implements it, and LayeredStore subscribes to it to invalidate its local
+// tier across nodes instead of relying on lazy TTL expiry.
+type SessionWatcher interface {
+	// Watch returns a channel of session events. The channel is closed when
+	// ctx is cancelled or the underlying subscription fails permanently.
+	Watch(ctx context.Context) (<-chan SessionEvent, error)
+}
+
 // Store defines the interface for session persistence
 type Store interface {
 	// Create stores a new session
@@ -112,6 +229,9 @@ type Store interface {
 	// GetByUser retrieves sessions by user ID
 	GetByUser(ctx context.Context, userID string) ([]*Session, error)
 
+	// GetByBearerTokenHash retrieves a session by its hashed bearer token
+	GetByBearerTokenHash(ctx context.Context, hash string) (*Session, error)
+
 	// Update updates a session
 	Update(ctx context.Context, session *Session) error
 
@@ -123,6 +243,11 @@ type Store interface {
 
 	// DeleteExpired deletes expired sessions
 	DeleteExpired(ctx context.Context) (int, error)
+
+	// ListExpiringWithin lists sessions not yet expired whose ExpiresAt
+	// falls within window from now, for TTLPolicy's about-to-expire
+	// warning.
+	ListExpiringWithin(ctx context.Context, window time.Duration) ([]*Session, error)
 }
 
 // Cache defines the interface for session caching
@@ -140,6 +265,31 @@ type Cache interface {
 	Touch(ctx context.Context, id string, ttl time.Duration) error
 }
 
+// SessionStore extends Cache with the batch and enumeration operations a
+// pipelined, multi-layer cache can offer that a plain key/value Cache
+// can't: flushing many dirty sessions in one round-trip, and iterating
+// without a full key scan. RedisCache and LayeredStore both implement it;
+// plain Cache remains the interface callers that only need Get/Set/Delete
+// depend on.
+type SessionStore interface {
+	Cache
+
+	// MGet retrieves multiple sessions in a single round-trip. Missing
+	// entries are simply absent from the result map, not an error.
+	MGet(ctx context.Context, ids []string) (map[string]*Session, error)
+
+	// MSet stores multiple sessions in a single round-trip, all with the
+	// same ttl.
+	MSet(ctx context.Context, sessions []*Session, ttl time.Duration) error
+
+	// MDelete removes multiple sessions in a single round-trip.
+	MDelete(ctx context.Context, ids []string) error
+
+	// Scan iterates every cached session ID without blocking the store
+	// the way a full KEYS enumeration would.
+	Scan(ctx context.Context) (<-chan string, error)
+}
+
 // WorkspaceStorage defines the interface for workspace persistence
 type WorkspaceStorage interface {
 	// Save saves the workspace from a sandbox
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/minio/minio-go/v7"
+)
+
+// tagObjectKey generates the pointer object key for a named tag.
+func tagObjectKey(sessionID, name string) string {
+	return fmt.Sprintf("tags/%s/%s", sessionID, name)
+}
+
+// tagPointer is the small JSON object Tag writes under tagObjectKey,
+// recording which MinIO object version a tag name currently points at.
+type tagPointer struct {
+	SessionID string    `json:"session_id"`
+	VersionID string    `json:"version_id"`
+	TaggedAt  time.Time `json:"tagged_at"`
+}
+
+// ListVersions returns sessionID's workspace.tar.gz object versions, most
+// recent first, relying on the bucket versioning ensureBucket enables.
+func (s *MinIOStorage) ListVersions(ctx context.Context, sessionID string) ([]Snapshot, error) {
+	key := workspaceKey(sessionID)
+
+	var snapshots []Snapshot
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:       key,
+		WithVersions: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list workspace versions: %w", obj.Err)
+		}
+		if obj.Key != key || obj.IsDeleteMarker {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			VersionID:    obj.VersionID,
+			IsLatest:     obj.IsLatest,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].LastModified.After(snapshots[j].LastModified)
+	})
+
+	return snapshots, nil
+}
+
+// RestoreVersion streams a specific prior version of sessionID's workspace
+// into sandboxID, letting a caller try an older snapshot without first
+// promoting it to the current head (unlike Restore, which always reads the
+// latest version).
+func (s *MinIOStorage) RestoreVersion(ctx context.Context, sessionID, versionID, sandboxID string) error {
+	object, err := s.client.GetObject(ctx, s.bucket, workspaceKey(sessionID), minio.GetObjectOptions{VersionID: versionID})
+	if err != nil {
+		return fmt.Errorf("failed to get workspace version %s: %w", versionID, err)
+	}
+	defer object.Close()
+
+	gzReader, err := gzip.NewReader(object)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzReader.Close()
+
+	containerName := "sandbox-" + sandboxID
+	if err := s.dockerClient.CopyToContainer(ctx, containerName, "/", gzReader, container.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy to container: %w", err)
+	}
+
+	log.Printf("[MinIO] Restored workspace version %s for session %s to sandbox %s", versionID, sessionID, sandboxID)
+	return nil
+}
+
+// Tag writes a small pointer object recording sessionID's current
+// workspace version under name. Nothing in this package reads the pointer
+// back: RestoreVersion takes a MinIO version ID, not a tag name.
+func (s *MinIOStorage) Tag(ctx context.Context, sessionID, name string) error {
+	snapshots, err := s.ListVersions(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no saved workspace for session %s", sessionID)
+	}
+
+	pointer := tagPointer{SessionID: sessionID, VersionID: snapshots[0].VersionID, TaggedAt: time.Now()}
+	data, err := json.Marshal(pointer)
+	if err != nil {
+		return fmt.Errorf("failed to encode tag: %w", err)
+	}
+
+	if err := s.backend.Put(ctx, tagObjectKey(sessionID, name), bytes.NewReader(data), int64(len(data))); err != nil {
+		return fmt.Errorf("failed to write tag %s: %w", name, err)
+	}
+
+	log.Printf("[MinIO] Tagged session %s workspace version %s as %q", sessionID, pointer.VersionID, name)
+	return nil
+}
+
+// Fork server-side copies srcSessionID's current workspace.tar.gz into
+// dstSessionID's key via MinIO CopyObject, so branching off to experiment
+// costs a metadata operation rather than a full download/re-upload.
+func (s *MinIOStorage) Fork(ctx context.Context, srcSessionID, dstSessionID string) error {
+	dst := minio.CopyDestOptions{Bucket: s.bucket, Object: workspaceKey(dstSessionID)}
+	src := minio.CopySrcOptions{Bucket: s.bucket, Object: workspaceKey(srcSessionID)}
+
+	if _, err := s.client.CopyObject(ctx, dst, src); err != nil {
+		return fmt.Errorf("failed to fork workspace from %s to %s: %w", srcSessionID, dstSessionID, err)
+	}
+
+	log.Printf("[MinIO] Forked workspace from session %s to session %s", srcSessionID, dstSessionID)
+	return nil
+}
@@ -0,0 +1,310 @@
+package session
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+
+	"github.com/cloud-sandbox/cloud-sandbox/internal/auth"
+	"github.com/cloud-sandbox/cloud-sandbox/internal/events"
+)
+
+// Backend is the blob-storage primitive every WorkspaceStorage provider
+// plugs in: put/get/delete/stat a single object by key. Extracting this
+// (rather than having each provider re-implement the container<->tar.gz
+// streaming dance) lets that logic live in exactly one place -
+// objectWorkspaceStorage below - shared by MinIOStorage, GCSStorage,
+// AzureBlobStorage, and FSStorage.
+type Backend interface {
+	// Put uploads the bytes read from r to key. size is the number of
+	// bytes r will yield, or -1 if unknown, in which case the backend
+	// streams/multiparts as needed rather than requiring the whole
+	// object up front.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+
+	// Get opens key for reading. The caller must close the returned
+	// ReadCloser.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// Stat reports whether key exists.
+	Stat(ctx context.Context, key string) (bool, error)
+}
+
+// workspaceKey generates the object key for a session's workspace.
+func workspaceKey(sessionID string) string {
+	return fmt.Sprintf("workspaces/%s/workspace.tar.gz", sessionID)
+}
+
+// objectWorkspaceStorage implements WorkspaceStorage by streaming a
+// sandbox container's filesystem through gzip/tar into a Backend, and
+// back. A goroutine pipes dockerClient.CopyFromContainer through a gzip
+// writer into an io.Pipe, and Backend.Put reads the pipe with size=-1 so
+// a multipart-capable backend can chunk the upload; Restore mirrors this
+// in reverse through a copyBufferSize-sized buffer. The tar is never
+// buffered or fully (de)compressed in memory, so memory use stays
+// roughly constant regardless of workspace size.
+type objectWorkspaceStorage struct {
+	backend      Backend
+	dockerClient *client.Client
+	workDir      string
+
+	copyBufferSize int
+
+	// transferSlots bounds how many Save/Restore calls run concurrently;
+	// acquireTransferSlot/releaseTransferSlot treat it as a semaphore.
+	transferSlots chan struct{}
+
+	// progress, when attached via AttachProgress, receives byte counts
+	// and completion timing for every Save and Restore.
+	progress ProgressCallback
+
+	// eventBus, when attached via AttachEventBus, receives a
+	// workspace.saved, workspace.restored, or workspace.deleted event
+	// after each successful Save/Restore/Delete.
+	eventBus *events.Bus
+}
+
+// newObjectWorkspaceStorage wires up the shared Save/Restore/Delete/Exists
+// logic against backend. copyBufferSize and maxConcurrent of zero fall
+// back to the same defaults MinIOConfig uses.
+func newObjectWorkspaceStorage(backend Backend, dockerClient *client.Client, workDir string, copyBufferSize, maxConcurrent int) *objectWorkspaceStorage {
+	if copyBufferSize == 0 {
+		copyBufferSize = defaultCopyBufferSize
+	}
+	if maxConcurrent == 0 {
+		maxConcurrent = defaultMaxConcurrentTransfers
+	}
+	return &objectWorkspaceStorage{
+		backend:        backend,
+		dockerClient:   dockerClient,
+		workDir:        workDir,
+		copyBufferSize: copyBufferSize,
+		transferSlots:  make(chan struct{}, maxConcurrent),
+	}
+}
+
+// ProgressCallback observes streaming Save/Restore progress, letting a
+// caller (the metrics package's counters/histograms, a progress bar) track
+// throughput without objectWorkspaceStorage depending on a concrete
+// metrics backend. Attach one via AttachProgress.
+type ProgressCallback interface {
+	// OnBytes reports bytesTransferred additional bytes moved by op
+	// ("save" or "restore") for sessionID. Called repeatedly as the
+	// stream proceeds, not just once at the end.
+	OnBytes(sessionID, op string, bytesTransferred int64)
+
+	// OnComplete reports that op finished for sessionID after d,
+	// successfully if err is nil.
+	OnComplete(sessionID, op string, d time.Duration, err error)
+}
+
+// AttachProgress wires a ProgressCallback into every subsequent Save and
+// Restore. A nil callback (the default) just means progress goes
+// unobserved.
+func (s *objectWorkspaceStorage) AttachProgress(progress ProgressCallback) {
+	s.progress = progress
+}
+
+// AttachEventBus wires the cross-cutting events.Bus into every subsequent
+// Save, Restore, and Delete, so operators can subscribe a webhook/Kafka
+// sink to workspace.saved, workspace.restored, and workspace.deleted. A
+// nil bus (the default) leaves those lifecycle transitions unpublished.
+func (s *objectWorkspaceStorage) AttachEventBus(bus *events.Bus) {
+	s.eventBus = bus
+}
+
+// acquireTransferSlot blocks until a concurrency slot is free or ctx is
+// done.
+func (s *objectWorkspaceStorage) acquireTransferSlot(ctx context.Context) error {
+	select {
+	case s.transferSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *objectWorkspaceStorage) releaseTransferSlot() {
+	<-s.transferSlots
+}
+
+// progressReader wraps an io.Reader, reporting every successful Read to a
+// ProgressCallback (if any) as it's consumed, so Save/Restore can stream
+// through it without buffering the whole transfer to count its bytes.
+type progressReader struct {
+	r         io.Reader
+	sessionID string
+	op        string
+	progress  ProgressCallback
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 && p.progress != nil {
+		p.progress.OnBytes(p.sessionID, p.op, int64(n))
+	}
+	return n, err
+}
+
+// reportComplete notifies s.progress (if attached) that op finished for
+// sessionID, timed from start.
+func (s *objectWorkspaceStorage) reportComplete(sessionID, op string, start time.Time, err error) {
+	if s.progress != nil {
+		s.progress.OnComplete(sessionID, op, time.Since(start), err)
+	}
+}
+
+// Save streams the workspace from a sandbox container to the backend.
+func (s *objectWorkspaceStorage) Save(ctx context.Context, sessionID, sandboxID string) (string, error) {
+	if err := s.acquireTransferSlot(ctx); err != nil {
+		return "", fmt.Errorf("failed to acquire transfer slot: %w", err)
+	}
+	defer s.releaseTransferSlot()
+
+	start := time.Now()
+	containerName := "sandbox-" + sandboxID
+
+	reader, _, err := s.dockerClient.CopyFromContainer(ctx, containerName, s.workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy from container: %w", err)
+	}
+	defer reader.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		gzWriter := gzip.NewWriter(pw)
+		if _, err := io.Copy(gzWriter, reader); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to compress workspace: %w", err))
+			return
+		}
+		if err := gzWriter.Close(); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to close gzip writer: %w", err))
+			return
+		}
+		pw.Close()
+	}()
+
+	countingReader := &progressReader{r: pr, sessionID: sessionID, op: "save", progress: s.progress}
+
+	objectKey := workspaceKey(sessionID)
+	err = s.backend.Put(ctx, objectKey, countingReader, -1)
+	s.reportComplete(sessionID, "save", start, err)
+	if err != nil {
+		// Unblock the compressor goroutine: it may still be writing into pw,
+		// and closing reader above doesn't touch the pipe.
+		pr.CloseWithError(err)
+		return "", fmt.Errorf("failed to upload workspace: %w", err)
+	}
+
+	if s.eventBus != nil {
+		s.eventBus.PublishAsync(events.Event{
+			Type:      events.TypeWorkspaceSaved,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: sandboxID,
+			SessionID: sessionID,
+		})
+	}
+
+	log.Printf("[Workspace] Saved workspace for session %s", sessionID)
+	return objectKey, nil
+}
+
+// Restore streams the workspace from the backend to a sandbox container.
+func (s *objectWorkspaceStorage) Restore(ctx context.Context, sessionID, sandboxID string) error {
+	if err := s.acquireTransferSlot(ctx); err != nil {
+		return fmt.Errorf("failed to acquire transfer slot: %w", err)
+	}
+	defer s.releaseTransferSlot()
+
+	start := time.Now()
+	containerName := "sandbox-" + sandboxID
+	objectKey := workspaceKey(sessionID)
+
+	object, err := s.backend.Get(ctx, objectKey)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace object: %w", err)
+	}
+	defer object.Close()
+
+	gzReader, err := gzip.NewReader(object)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzReader.Close()
+
+	countingReader := &progressReader{r: gzReader, sessionID: sessionID, op: "restore", progress: s.progress}
+
+	pr, pw := io.Pipe()
+	go func() {
+		buf := make([]byte, s.copyBufferSize)
+		if _, err := io.CopyBuffer(pw, countingReader, buf); err != nil {
+			pw.CloseWithError(fmt.Errorf("failed to decompress workspace: %w", err))
+			return
+		}
+		pw.Close()
+	}()
+
+	err = s.dockerClient.CopyToContainer(ctx, containerName, "/", pr, container.CopyToContainerOptions{})
+	s.reportComplete(sessionID, "restore", start, err)
+	if err != nil {
+		// Unblock the decompressor goroutine: it may still be writing into
+		// pw, and closing object/gzReader above doesn't touch the pipe.
+		pr.CloseWithError(err)
+		return fmt.Errorf("failed to copy to container: %w", err)
+	}
+
+	if s.eventBus != nil {
+		s.eventBus.PublishAsync(events.Event{
+			Type:      events.TypeWorkspaceRestored,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SandboxID: sandboxID,
+			SessionID: sessionID,
+		})
+	}
+
+	log.Printf("[Workspace] Restored workspace for session %s to sandbox %s", sessionID, sandboxID)
+	return nil
+}
+
+// Delete deletes the saved workspace for a session.
+func (s *objectWorkspaceStorage) Delete(ctx context.Context, sessionID string) error {
+	if err := s.backend.Delete(ctx, workspaceKey(sessionID)); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+
+	if s.eventBus != nil {
+		s.eventBus.PublishAsync(events.Event{
+			Type:      events.TypeWorkspaceDeleted,
+			UserID:    auth.GetUserIDFromContext(ctx),
+			SessionID: sessionID,
+		})
+	}
+
+	log.Printf("[Workspace] Deleted workspace for session %s", sessionID)
+	return nil
+}
+
+// Exists checks if a workspace exists for a session.
+func (s *objectWorkspaceStorage) Exists(ctx context.Context, sessionID string) (bool, error) {
+	exists, err := s.backend.Stat(ctx, workspaceKey(sessionID))
+	if err != nil {
+		return false, fmt.Errorf("failed to check workspace: %w", err)
+	}
+	return exists, nil
+}
+
+// Close closes the underlying docker client. Providers whose Backend also
+// owns a client connection (GCSStorage, AzureBlobStorage) close it first
+// and then delegate here.
+func (s *objectWorkspaceStorage) Close() error {
+	return s.dockerClient.Close()
+}
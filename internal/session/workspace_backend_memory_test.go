@@ -0,0 +1,157 @@
+package session
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// syntheticWorkspaceSize is large enough (just over 1 GiB) to prove
+// Save/Restore's streaming path doesn't buffer the whole workspace in
+// memory, while zeroReader synthesizes it without ever holding that much
+// itself.
+const syntheticWorkspaceSize = 1<<30 + 1<<20 // 1 GiB + 1 MiB
+
+// memoryCeiling is how much heap growth is tolerated while streaming
+// syntheticWorkspaceSize bytes - generous enough to absorb gzip/pipe
+// buffering and GC noise, while still being orders of magnitude below the
+// workspace size, which is the property under test: memory use bounded by
+// buffer size, not by workspace size.
+const memoryCeiling = 64 * 1024 * 1024 // 64 MiB
+
+// zeroReader yields n zero bytes without holding them all in memory at
+// once, standing in for dockerClient.CopyFromContainer's tar stream
+// (Save) or a Backend.Get's object body (Restore), neither of which this
+// test can exercise directly without a real Docker daemon and a real
+// object store.
+type zeroReader struct{ remaining int64 }
+
+func (z *zeroReader) Read(p []byte) (int, error) {
+	if z.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > z.remaining {
+		p = p[:z.remaining]
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	z.remaining -= int64(len(p))
+	return len(p), nil
+}
+
+// discardBackend is a Backend that throws away whatever it's given. These
+// tests care about the memory the streaming pipeline itself holds onto,
+// not about persisting anything.
+type discardBackend struct{}
+
+func (discardBackend) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (discardBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("discardBackend: Get not supported")
+}
+
+func (discardBackend) Delete(ctx context.Context, key string) error { return nil }
+
+func (discardBackend) Stat(ctx context.Context, key string) (bool, error) { return false, nil }
+
+// TestSaveStreamingMemoryCeiling reproduces Save's gzip/io.Pipe/Backend.Put
+// pipeline - what workspace_backend.go's streaming rewrite changed from
+// buffering the whole workspace in memory to - against a >1 GiB synthetic
+// workspace, and asserts heap growth stays well under the workspace size.
+// It can't call Save itself, since that method is hard-wired to a real
+// Docker daemon via CopyFromContainer; this exercises the identical
+// gzip->io.Pipe->Backend.Put chain Save builds, fed by a synthetic reader
+// standing in for the container's tar stream.
+func TestSaveStreamingMemoryCeiling(t *testing.T) {
+	backend := discardBackend{}
+	reader := &zeroReader{remaining: syntheticWorkspaceSize}
+
+	pr, pw := io.Pipe()
+	go func() {
+		gzWriter := gzip.NewWriter(pw)
+		if _, err := io.Copy(gzWriter, reader); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if err := gzWriter.Close(); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	if err := backend.Put(context.Background(), "workspace", pr, -1); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	if grew := int64(after.HeapAlloc) - int64(before.HeapAlloc); grew > memoryCeiling {
+		t.Fatalf("heap grew by %d bytes saving a %d byte workspace, want under %d", grew, syntheticWorkspaceSize, memoryCeiling)
+	}
+}
+
+// TestRestoreStreamingMemoryCeiling is TestSaveStreamingMemoryCeiling's
+// counterpart for Restore's decompress/io.Pipe/CopyToContainer direction.
+// It builds a >1 GiB pre-compressed "stored object" the same way, piping
+// gzip output as it's produced rather than materializing it, then runs it
+// through Restore's gzip.NewReader -> io.CopyBuffer -> io.Pipe chain and
+// asserts the same bounded heap growth.
+func TestRestoreStreamingMemoryCeiling(t *testing.T) {
+	compressedPR, compressedPW := io.Pipe()
+	go func() {
+		gzWriter := gzip.NewWriter(compressedPW)
+		if _, err := io.Copy(gzWriter, &zeroReader{remaining: syntheticWorkspaceSize}); err != nil {
+			compressedPW.CloseWithError(err)
+			return
+		}
+		if err := gzWriter.Close(); err != nil {
+			compressedPW.CloseWithError(err)
+			return
+		}
+		compressedPW.Close()
+	}()
+
+	gzReader, err := gzip.NewReader(compressedPR)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer gzReader.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		buf := make([]byte, defaultCopyBufferSize)
+		if _, err := io.CopyBuffer(pw, gzReader, buf); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	if _, err := io.Copy(io.Discard, pr); err != nil {
+		t.Fatalf("copying decompressed stream: %v", err)
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	if grew := int64(after.HeapAlloc) - int64(before.HeapAlloc); grew > memoryCeiling {
+		t.Fatalf("heap grew by %d bytes restoring a %d byte workspace, want under %d", grew, syntheticWorkspaceSize, memoryCeiling)
+	}
+}
@@ -0,0 +1,47 @@
+package session
+
+import "fmt"
+
+// WorkspaceStorageProvider selects which WorkspaceStorage backend
+// NewWorkspaceStorage constructs.
+type WorkspaceStorageProvider string
+
+const (
+	WorkspaceProviderMinIO     WorkspaceStorageProvider = "minio"
+	WorkspaceProviderGCS       WorkspaceStorageProvider = "gcs"
+	WorkspaceProviderAzureBlob WorkspaceStorageProvider = "azblob"
+	WorkspaceProviderFS        WorkspaceStorageProvider = "fs"
+)
+
+// WorkspaceStorageConfig selects a WorkspaceStorage provider and holds
+// that provider's config. Only the field matching Provider is read; the
+// others are ignored. Unlike the Store registry in registry.go, a single
+// DSN string can't carry MinIO's credentials, GCS's injected HTTP client,
+// or Azure's TokenCredential, so each provider keeps its own structured
+// config type and this just picks among them.
+type WorkspaceStorageConfig struct {
+	Provider WorkspaceStorageProvider
+
+	MinIO     MinIOConfig
+	GCS       GCSConfig
+	AzureBlob AzureBlobConfig
+	FS        FSStorageConfig
+}
+
+// NewWorkspaceStorage constructs the WorkspaceStorage backend named by
+// config.Provider. An empty Provider defaults to MinIO, matching this
+// package's original single-backend behavior.
+func NewWorkspaceStorage(config WorkspaceStorageConfig) (WorkspaceStorage, error) {
+	switch config.Provider {
+	case WorkspaceProviderMinIO, "":
+		return NewMinIOStorage(config.MinIO)
+	case WorkspaceProviderGCS:
+		return NewGCSStorage(config.GCS)
+	case WorkspaceProviderAzureBlob:
+		return NewAzureBlobStorage(config.AzureBlob)
+	case WorkspaceProviderFS:
+		return NewFSStorage(config.FS)
+	default:
+		return nil, fmt.Errorf("session: unknown workspace storage provider %q", config.Provider)
+	}
+}